@@ -0,0 +1,758 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/momentics/govna/pkg/govna"
+)
+
+const (
+	// calibrationSessionTTL - время жизни сессии калибровки без обращений клиента.
+	calibrationSessionTTL = 30 * time.Minute
+	// sessionTokenHeader - заголовок, которым создатель сессии калибровки подтверждает право
+	// измерять эталоны, завершать и отменять ее.
+	sessionTokenHeader = "X-Session-Token"
+	// calibrationDirEnv задает каталог калибровочных профилей; по умолчанию defaultCalibrationDir.
+	calibrationDirEnv     = "GOVNA_CALIBRATION_DIR"
+	defaultCalibrationDir = "calibrations"
+)
+
+// calibrations - калибровочные профили устройств, доступные для выбора параметром calibration.
+var calibrations = &calibrationStore{profiles: make(map[string]map[string]*govna.CalibrationProfile)}
+
+// calibrationStore хранит профили по порту устройства и имени профиля. После Load профили
+// сохраняются в каталоге файлами JSON вида <порт>/<имя>.json; порт и имя записываются в base64url,
+// чтобы пути устройств вроде /dev/ttyACM0 и любые имена профилей были допустимыми именами файлов.
+type calibrationStore struct {
+	mu       sync.RWMutex
+	dir      string
+	profiles map[string]map[string]*govna.CalibrationProfile
+}
+
+// Load читает профили из каталога dir и сохраняет в нем последующие изменения. Отсутствующий
+// каталог создается при первом сохранении; нечитаемые файлы пропускаются с записью в журнал.
+func (s *calibrationStore) Load(dir string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dir = dir
+	ports, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения каталога калибровок: %w", err)
+	}
+	loaded := 0
+	for _, entry := range ports {
+		decoded, err := base64.RawURLEncoding.DecodeString(entry.Name())
+		if !entry.IsDir() || err != nil {
+			continue
+		}
+		port := string(decoded)
+		files, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return loaded, fmt.Errorf("ошибка чтения каталога калибровок: %w", err)
+		}
+		for _, file := range files {
+			if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name(), file.Name())
+			profile, err := readProfileFile(path)
+			if err != nil {
+				log.Printf("Калибровочный профиль %s пропущен: %v", path, err)
+				continue
+			}
+			if s.profiles[port] == nil {
+				s.profiles[port] = make(map[string]*govna.CalibrationProfile)
+			}
+			s.profiles[port][profile.Name] = profile
+			loaded++
+		}
+	}
+	return loaded, nil
+}
+
+func readProfileFile(path string) (*govna.CalibrationProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return govna.LoadCalibrationJSON(f)
+}
+
+// profilePath возвращает файл профиля в каталоге хранилища. Вызывается с захваченным s.mu.
+func (s *calibrationStore) profilePath(port, name string) string {
+	return filepath.Join(s.dir, base64.RawURLEncoding.EncodeToString([]byte(port)),
+		base64.RawURLEncoding.EncodeToString([]byte(name))+".json")
+}
+
+// saveLocked записывает профиль во временный файл и переименовывает его, чтобы при сбое
+// на диске не остался частично записанный профиль.
+func (s *calibrationStore) saveLocked(port string, profile *govna.CalibrationProfile) error {
+	path := s.profilePath(port, profile.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ошибка сохранения калибровки: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".profile-*")
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения калибровки: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := profile.WriteJSON(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("ошибка сохранения калибровки: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("ошибка сохранения калибровки: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("ошибка сохранения калибровки: %w", err)
+	}
+	return nil
+}
+
+func (s *calibrationStore) Get(port, name string) *govna.CalibrationProfile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profiles[port][name]
+}
+
+// Put сохраняет профиль; при ошибке записи на диск хранилище не изменяется.
+func (s *calibrationStore) Put(port string, profile *govna.CalibrationProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dir != "" {
+		if err := s.saveLocked(port, profile); err != nil {
+			return err
+		}
+	}
+	if s.profiles[port] == nil {
+		s.profiles[port] = make(map[string]*govna.CalibrationProfile)
+	}
+	s.profiles[port][profile.Name] = profile
+	return nil
+}
+
+// Create сохраняет профиль, если у устройства нет профиля с тем же именем; false означает,
+// что такой профиль уже есть и хранилище не изменилось.
+func (s *calibrationStore) Create(port string, profile *govna.CalibrationProfile) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[port][profile.Name]; ok {
+		return false, nil
+	}
+	if s.dir != "" {
+		if err := s.saveLocked(port, profile); err != nil {
+			return true, err
+		}
+	}
+	if s.profiles[port] == nil {
+		s.profiles[port] = make(map[string]*govna.CalibrationProfile)
+	}
+	s.profiles[port][profile.Name] = profile
+	return true, nil
+}
+
+// Delete удаляет профиль; false означает, что профиля не было.
+func (s *calibrationStore) Delete(port, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[port][name]; !ok {
+		return false, nil
+	}
+	if s.dir != "" {
+		if err := os.Remove(s.profilePath(port, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return true, fmt.Errorf("ошибка удаления калибровки: %w", err)
+		}
+	}
+	delete(s.profiles[port], name)
+	return true, nil
+}
+
+// List возвращает профили устройства, упорядоченные по имени.
+func (s *calibrationStore) List(port string) []*govna.CalibrationProfile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	profiles := make([]*govna.CalibrationProfile, 0, len(s.profiles[port]))
+	for _, profile := range s.profiles[port] {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles
+}
+
+// calibrationSession - калибровка, которую клиент ведет последовательными запросами.
+// Token выдается создателю сессии и требуется для измерения, завершения и отмены.
+type calibrationSession struct {
+	ID    string
+	Port  string
+	Token string
+
+	mu       sync.Mutex
+	session  *govna.CalibrationSession
+	lastUsed time.Time
+}
+
+var calibrationSessions = &sessionStore{sessions: make(map[string]*calibrationSession)}
+
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*calibrationSession
+}
+
+func (s *sessionStore) Add(session *calibrationSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	s.sessions[session.ID] = session
+}
+
+func (s *sessionStore) Get(id string) *calibrationSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	return s.sessions[id]
+}
+
+func (s *sessionStore) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.sessions[id]
+	delete(s.sessions, id)
+	return ok
+}
+
+func (s *sessionStore) List() []*calibrationSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	sessions := make([]*calibrationSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return sessions
+}
+
+// expireLocked удаляет сессии, к которым не обращались дольше calibrationSessionTTL.
+func (s *sessionStore) expireLocked() {
+	for id, session := range s.sessions {
+		session.mu.Lock()
+		expired := time.Since(session.lastUsed) > calibrationSessionTTL
+		session.mu.Unlock()
+		if expired {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// randomHex возвращает n случайных байт в шестнадцатеричной записи.
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+type sessionRequest struct {
+	Port      string                      `json:"port"`
+	Name      string                      `json:"name"`
+	Sweep     *govna.SweepConfig          `json:"sweep,omitempty"`
+	Standards []govna.CalibrationStandard `json:"standards,omitempty"`
+}
+
+type sessionStatus struct {
+	ID        string                      `json:"id"`
+	Port      string                      `json:"port"`
+	Name      string                      `json:"name"`
+	Sweep     govna.SweepConfig           `json:"sweep"`
+	Standards []govna.CalibrationStandard `json:"standards"`
+	Completed int                         `json:"completed"`
+	Next      govna.CalibrationStandard   `json:"next,omitempty"`
+	Expires   time.Time                   `json:"expires"`
+	// Token возвращается только при создании сессии.
+	Token string `json:"token,omitempty"`
+}
+
+// status описывает состояние сессии. Вызывается с захваченным s.mu.
+func (s *calibrationSession) status() sessionStatus {
+	plan := s.session.Plan()
+	status := sessionStatus{
+		ID:        s.ID,
+		Port:      s.Port,
+		Name:      plan.Name,
+		Sweep:     plan.Sweep,
+		Completed: s.session.Completed(),
+		Expires:   s.lastUsed.Add(calibrationSessionTTL),
+	}
+	for _, step := range plan.Steps {
+		status.Standards = append(status.Standards, step.Standard)
+	}
+	if step, ok := s.session.Next(); ok {
+		status.Next = step.Standard
+	}
+	return status
+}
+
+type profileSummary struct {
+	Name      string                      `json:"name"`
+	Method    govna.CalibrationMethod     `json:"method"`
+	CreatedAt time.Time                   `json:"created_at"`
+	Sweep     govna.SweepConfig           `json:"sweep"`
+	Standards []govna.CalibrationStandard `json:"standards"`
+	Active    bool                        `json:"active"`
+}
+
+func summarizeProfile(profile *govna.CalibrationProfile, active *govna.CalibrationProfile) profileSummary {
+	summary := profileSummary{
+		Name:      profile.Name,
+		Method:    profile.Method,
+		CreatedAt: profile.CreatedAt,
+		Sweep:     profile.Sweep,
+		Active:    profile == active,
+	}
+	for standard := range profile.Standards {
+		summary.Standards = append(summary.Standards, standard)
+	}
+	slices.Sort(summary.Standards)
+	return summary
+}
+
+// activeProfile возвращает загруженный в устройство профиль, не открывая закрытое устройство.
+func activeProfile(pool *govna.VNAPool, port string) *govna.CalibrationProfile {
+	if !slices.Contains(pool.Devices(), port) {
+		return nil
+	}
+	vna, err := pool.Get(port)
+	if err != nil {
+		return nil
+	}
+	return vna.Calibration()
+}
+
+func profileLocation(port, name string) string {
+	return "/api/v1/calibrations/" + url.PathEscape(name) + "?port=" + url.QueryEscape(port)
+}
+
+// writeJSON отправляет value в JSON. Ответ кодируется до отправки заголовков, чтобы ошибку
+// кодирования можно было сообщить клиенту кодом 500, а не пустым ответом 200.
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		writeError(w, &apiError{Status: http.StatusInternalServerError, Code: "encoding_error",
+			Message: fmt.Sprintf("Ошибка формирования ответа: %v", err)})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
+
+func storageError(err error) *apiError {
+	return &apiError{Status: http.StatusInternalServerError, Code: "storage_error", Message: err.Error()}
+}
+
+// requireSessionToken проверяет, что запрос сделан создателем сессии. Иначе отправляет
+// ошибку 403 и возвращает false.
+func requireSessionToken(w http.ResponseWriter, r *http.Request, session *calibrationSession) bool {
+	if r.Header.Get(sessionTokenHeader) != session.Token {
+		writeError(w, &apiError{Status: http.StatusForbidden, Code: "session_forbidden",
+			Message: fmt.Sprintf("Сессией калибровки управляет ее создатель; передайте токен в заголовке %s", sessionTokenHeader)})
+		return false
+	}
+	return true
+}
+
+func methodNotAllowed(methods ...string) *apiError {
+	return &apiError{Status: http.StatusMethodNotAllowed, Code: "method_not_allowed",
+		Message: fmt.Sprintf("Поддерживаются методы: %s", strings.Join(methods, ", "))}
+}
+
+func invalidBody(err error) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Code: "invalid_body", Message: fmt.Sprintf("Некорректное тело запроса: %v", err)}
+}
+
+// validProfileName проверяет имя профиля: имя используется в пути /api/v1/calibrations/{name}.
+func validProfileName(name string) *apiError {
+	if name == "" {
+		return missingParameter("name")
+	}
+	if strings.ContainsAny(name, "/?#") {
+		return invalidParameter("name", "Имя профиля не может содержать символы '/', '?' и '#'")
+	}
+	return nil
+}
+
+// calibrationSessionsHandler создает сессию калибровки (POST) или перечисляет открытые (GET).
+// Тело POST: {"port", "name", "sweep" (по умолчанию defaultSweep), "standards" (по умолчанию
+// open, short, load)}. Ответ - состояние сессии с идентификатором, следующим эталоном и токеном,
+// который нужно передавать в заголовке X-Session-Token при дальнейшей работе с сессией.
+func calibrationSessionsHandler(pool *govna.VNAPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			statuses := []sessionStatus{}
+			for _, session := range calibrationSessions.List() {
+				session.mu.Lock()
+				statuses = append(statuses, session.status())
+				session.mu.Unlock()
+			}
+			writeJSON(w, http.StatusOK, statuses)
+		case http.MethodPost:
+			var request sessionRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				writeError(w, invalidBody(err))
+				return
+			}
+			if request.Port == "" {
+				writeError(w, missingParameter("port"))
+				return
+			}
+			if apiErr := validProfileName(request.Name); apiErr != nil {
+				writeError(w, apiErr)
+				return
+			}
+			plan := govna.CalibrationPlan{Name: request.Name, Sweep: defaultSweep}
+			if request.Sweep != nil {
+				plan.Sweep = *request.Sweep
+			}
+			standards := request.Standards
+			if len(standards) == 0 {
+				standards = defaultCalibrationStandards
+			}
+			for _, standard := range standards {
+				plan.Steps = append(plan.Steps, govna.CalibrationStep{Standard: standard})
+			}
+
+			if !requireLease(w, r, request.Port) {
+				return
+			}
+			vna, err := pool.Get(request.Port)
+			if err != nil {
+				writeError(w, deviceError(err))
+				return
+			}
+			if err := vna.Capabilities().ValidateSweep(plan.Sweep); err != nil {
+				writeError(w, sweepError(err))
+				return
+			}
+			// StartCalibration перестраивает сетку прибора, поэтому ждет в очереди устройства,
+			// чтобы не изменить сетку уже поставленных в очередь сканов.
+			var started *govna.CalibrationSession
+			if !scheduleHTTP(w, r, request.Port, govna.PriorityNormal, func() { started, err = vna.StartCalibration(plan) }) {
+				return
+			}
+			if err != nil {
+				writeError(w, invalidParameter("", fmt.Sprintf("Ошибка калибровки: %v", err)))
+				return
+			}
+			session := &calibrationSession{ID: randomHex(8), Port: request.Port, Token: randomHex(16),
+				session: started, lastUsed: time.Now()}
+			calibrationSessions.Add(session)
+			status := session.status()
+			status.Token = session.Token
+			w.Header().Set("Location", "/api/v1/calibration/sessions/"+session.ID)
+			writeJSON(w, http.StatusCreated, status)
+		default:
+			writeError(w, methodNotAllowed(http.MethodGet, http.MethodPost))
+		}
+	}
+}
+
+// calibrationSessionHandler обслуживает сессию калибровки. DELETE, measure и finish доступны
+// только создателю сессии (заголовок X-Session-Token), measure и finish также требуют аренды:
+//
+//	GET    /api/v1/calibration/sessions/{id}          - состояние и следующий эталон
+//	DELETE /api/v1/calibration/sessions/{id}          - отмена
+//	POST   /api/v1/calibration/sessions/{id}/measure  - эталон подключен, измерить;
+//	       тело {"standard"} необязательно и проверяется на совпадение со следующим эталоном
+//	POST   /api/v1/calibration/sessions/{id}/finish   - рассчитать и сохранить профиль;
+//	       тело {"name", "activate", "overwrite"} необязательно; профиль с тем же именем
+//	       заменяется только при overwrite, иначе - 409
+func calibrationSessionHandler(pool *govna.VNAPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/calibration/sessions/"), "/")
+		session := calibrationSessions.Get(id)
+		if session == nil {
+			writeError(w, &apiError{Status: http.StatusNotFound, Code: "session_not_found",
+				Message: fmt.Sprintf("Сессия калибровки %q не найдена", id)})
+			return
+		}
+
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			session.mu.Lock()
+			session.lastUsed = time.Now()
+			status := session.status()
+			session.mu.Unlock()
+			writeJSON(w, http.StatusOK, status)
+		case action == "" && r.Method == http.MethodDelete:
+			if requireSessionToken(w, r, session) {
+				calibrationSessions.Remove(id)
+				w.WriteHeader(http.StatusNoContent)
+			}
+		case action == "measure" && r.Method == http.MethodPost:
+			if requireSessionToken(w, r, session) && requireLease(w, r, session.Port) {
+				measureStandard(w, r, session)
+			}
+		case action == "finish" && r.Method == http.MethodPost:
+			if requireSessionToken(w, r, session) && requireLease(w, r, session.Port) {
+				finishSession(w, r, pool, session)
+			}
+		case action == "" || action == "measure" || action == "finish":
+			writeError(w, methodNotAllowed(map[string][]string{
+				"":        {http.MethodGet, http.MethodDelete},
+				"measure": {http.MethodPost},
+				"finish":  {http.MethodPost},
+			}[action]...))
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func measureStandard(w http.ResponseWriter, r *http.Request, session *calibrationSession) {
+	var request struct {
+		Standard govna.CalibrationStandard `json:"standard"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, invalidBody(err))
+			return
+		}
+	}
+
+	session.mu.Lock()
+	session.lastUsed = time.Now()
+	session.mu.Unlock()
+	next, ok := session.session.Next()
+	if !ok {
+		writeError(w, &apiError{Status: http.StatusConflict, Code: "calibration_complete",
+			Message: "Все эталоны измерены; завершите калибровку запросом finish"})
+		return
+	}
+	if request.Standard != "" && request.Standard != next.Standard {
+		writeError(w, &apiError{Status: http.StatusConflict, Code: "standard_mismatch", Field: "standard",
+			Message: fmt.Sprintf("Подключен эталон %s, ожидался %s", request.Standard, next.Standard)})
+		return
+	}
+
+	// Сканирование эталона ждет в очереди устройства наравне с остальными; сессия при этом
+	// не блокируется, и ее состояние можно запрашивать во время измерения.
+	var err error
+	if !scheduleHTTP(w, r, session.Port, govna.PriorityNormal, func() { err = session.session.Measure(r.Context()) }) {
+		return
+	}
+	switch {
+	case errors.Is(err, govna.ErrCalibrationBusy):
+		writeError(w, calibrationBusyError())
+		return
+	case err != nil:
+		writeError(w, deviceError(err))
+		return
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.lastUsed = time.Now()
+	writeJSON(w, http.StatusOK, session.status())
+}
+
+func calibrationBusyError() *apiError {
+	return &apiError{Status: http.StatusConflict, Code: "measurement_in_progress",
+		Message: "Эталон сессии уже измеряется; дождитесь окончания измерения"}
+}
+
+func finishSession(w http.ResponseWriter, r *http.Request, pool *govna.VNAPool, session *calibrationSession) {
+	var request struct {
+		Name      string `json:"name"`
+		Activate  bool   `json:"activate"`
+		Overwrite bool   `json:"overwrite"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, invalidBody(err))
+			return
+		}
+	}
+	if request.Name != "" {
+		if apiErr := validProfileName(request.Name); apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.lastUsed = time.Now()
+	if next, ok := session.session.Next(); ok {
+		writeError(w, &apiError{Status: http.StatusConflict, Code: "calibration_incomplete",
+			Message: fmt.Sprintf("Эталон %s не измерен", next.Standard)})
+		return
+	}
+	profile, err := session.session.Finish()
+	if errors.Is(err, govna.ErrCalibrationBusy) {
+		writeError(w, calibrationBusyError())
+		return
+	}
+	if err != nil {
+		writeError(w, &apiError{Status: http.StatusUnprocessableEntity, Code: "calibration_failed",
+			Message: fmt.Sprintf("Ошибка расчета калибровки: %v", err)})
+		return
+	}
+	if request.Name != "" {
+		profile.Name = request.Name
+	}
+	if request.Overwrite {
+		err = calibrations.Put(session.Port, profile)
+	} else {
+		var created bool
+		created, err = calibrations.Create(session.Port, profile)
+		if !created {
+			writeError(w, &apiError{Status: http.StatusConflict, Code: "calibration_exists", Field: "name",
+				Message: fmt.Sprintf("Калибровочный профиль %q уже есть; передайте overwrite, чтобы заменить его", profile.Name)})
+			return
+		}
+	}
+	if err != nil {
+		writeError(w, storageError(err))
+		return
+	}
+	calibrationSessions.Remove(session.ID)
+	if request.Activate {
+		vna, err := pool.Get(session.Port)
+		if err != nil {
+			writeError(w, deviceError(err))
+			return
+		}
+		if err := vna.LoadCalibration(profile); err != nil {
+			writeError(w, invalidParameter("", fmt.Sprintf("Ошибка калибровки: %v", err)))
+			return
+		}
+	}
+	w.Header().Set("Location", profileLocation(session.Port, profile.Name))
+	writeJSON(w, http.StatusCreated, summarizeProfile(profile, activeProfile(pool, session.Port)))
+}
+
+// calibrationProfilesHandler перечисляет профили устройства (GET ?port=) или загружает профиль
+// в формате JSON, полученный из GET /api/v1/calibrations/{name} (POST ?port=[&name=]).
+func calibrationProfilesHandler(pool *govna.VNAPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		port := r.URL.Query().Get("port")
+		if port == "" {
+			writeError(w, missingParameter("port"))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			active := activeProfile(pool, port)
+			summaries := []profileSummary{}
+			for _, profile := range calibrations.List(port) {
+				summaries = append(summaries, summarizeProfile(profile, active))
+			}
+			writeJSON(w, http.StatusOK, summaries)
+		case http.MethodPost:
+			profile, err := govna.LoadCalibrationJSON(r.Body)
+			if err != nil {
+				writeError(w, &apiError{Status: http.StatusBadRequest, Code: "invalid_calibration",
+					Message: fmt.Sprintf("Ошибка калибровки: %v", err)})
+				return
+			}
+			if name := r.URL.Query().Get("name"); name != "" {
+				profile.Name = name
+			}
+			if apiErr := validProfileName(profile.Name); apiErr != nil {
+				writeError(w, apiErr)
+				return
+			}
+			if err := calibrations.Put(port, profile); err != nil {
+				writeError(w, storageError(err))
+				return
+			}
+			w.Header().Set("Location", profileLocation(port, profile.Name))
+			writeJSON(w, http.StatusCreated, summarizeProfile(profile, activeProfile(pool, port)))
+		default:
+			writeError(w, methodNotAllowed(http.MethodGet, http.MethodPost))
+		}
+	}
+}
+
+// calibrationProfileHandler обслуживает профиль устройства (параметр port обязателен):
+//
+//	GET    /api/v1/calibrations/{name}           - профиль в формате JSON
+//	DELETE /api/v1/calibrations/{name}           - удалить; активный профиль выгружается из устройства
+//	                                               (DELETE и activate требуют аренды)
+//	POST   /api/v1/calibrations/{name}/activate  - загрузить профиль в устройство
+func calibrationProfileHandler(pool *govna.VNAPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/calibrations/"), "/")
+		port := r.URL.Query().Get("port")
+		if port == "" {
+			writeError(w, missingParameter("port"))
+			return
+		}
+		profile := calibrations.Get(port, name)
+		if profile == nil {
+			writeError(w, &apiError{Status: http.StatusNotFound, Code: "calibration_not_found",
+				Message: fmt.Sprintf("Калибровочный профиль %q не найден", name)})
+			return
+		}
+
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".json"))
+			profile.WriteJSON(w)
+		case action == "" && r.Method == http.MethodDelete:
+			if !requireLease(w, r, port) {
+				return
+			}
+			if active := activeProfile(pool, port); active == profile {
+				if vna, err := pool.Get(port); err == nil {
+					vna.ClearCalibration()
+				}
+			}
+			if _, err := calibrations.Delete(port, name); err != nil {
+				writeError(w, storageError(err))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case action == "activate" && r.Method == http.MethodPost:
+			if !requireLease(w, r, port) {
+				return
+			}
+			vna, err := pool.Get(port)
+			if err != nil {
+				writeError(w, deviceError(err))
+				return
+			}
+			if err := vna.LoadCalibration(profile); err != nil {
+				writeError(w, invalidParameter("", fmt.Sprintf("Ошибка калибровки: %v", err)))
+				return
+			}
+			writeJSON(w, http.StatusOK, summarizeProfile(profile, profile))
+		case action == "":
+			writeError(w, methodNotAllowed(http.MethodGet, http.MethodDelete))
+		case action == "activate":
+			writeError(w, methodNotAllowed(http.MethodPost))
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
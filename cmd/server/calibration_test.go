@@ -0,0 +1,236 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/momentics/govna/pkg/govna"
+)
+
+// idealProfile - профиль SOL идеального прибора на двух частотах.
+func idealProfile(name string) *govna.CalibrationProfile {
+	frequencies := []float64{1e6, 2e6}
+	measurement := func(value complex128) govna.CalibrationMeasurement {
+		return govna.CalibrationMeasurement{Frequencies: frequencies, S11: []complex128{value, value}}
+	}
+	return &govna.CalibrationProfile{
+		Name:        name,
+		Method:      govna.CalibrationMethodSOL,
+		Sweep:       govna.SweepConfig{Start: 1e6, Stop: 2e6, Points: 2},
+		Frequencies: frequencies,
+		Standards: map[govna.CalibrationStandard]govna.CalibrationMeasurement{
+			govna.CalibrationStandardOpen:  measurement(1),
+			govna.CalibrationStandardShort: measurement(-1),
+			govna.CalibrationStandardLoad:  measurement(0),
+		},
+		ErrorTerms: govna.CalibrationErrorTerms{
+			Directivity:        []complex128{0, 0},
+			SourceMatch:        []complex128{0, 0},
+			ReflectionTracking: []complex128{1, 1},
+		},
+	}
+}
+
+func newCalibrationStore() *calibrationStore {
+	return &calibrationStore{profiles: make(map[string]map[string]*govna.CalibrationProfile)}
+}
+
+func TestCalibrationStore_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	store := newCalibrationStore()
+	if _, err := store.Load(dir); err != nil {
+		t.Fatalf("Load of empty directory failed: %v", err)
+	}
+	for _, name := range []string{"bench", "../escape"} {
+		if err := store.Put("/dev/ttyACM0", idealProfile(name)); err != nil {
+			t.Fatalf("Put(%q) failed: %v", name, err)
+		}
+	}
+	if err := store.Put("/dev/ttyACM1", idealProfile("other")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if ok, err := store.Delete("/dev/ttyACM1", "other"); !ok || err != nil {
+		t.Fatalf("Delete failed: %v %v", ok, err)
+	}
+
+	// Файлы не выходят за пределы каталога, какими бы ни были порт и имя профиля.
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 profile files, got %v", matches)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dir)); len(entries) != 1 {
+		t.Fatalf("profiles written outside %s: %v", dir, entries)
+	}
+
+	restored := newCalibrationStore()
+	loaded, err := restored.Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded != 2 {
+		t.Fatalf("expected 2 profiles, got %d", loaded)
+	}
+	profile := restored.Get("/dev/ttyACM0", "../escape")
+	if profile == nil || len(profile.Frequencies) != 2 || profile.ErrorTerms.ReflectionTracking[1] != 1 {
+		t.Fatalf("profile not restored: %+v", profile)
+	}
+	if restored.Get("/dev/ttyACM1", "other") != nil {
+		t.Fatalf("deleted profile restored")
+	}
+}
+
+func TestCalibrationSession_RequiresToken(t *testing.T) {
+	const port = "/dev/test-session-token"
+	pool, _ := newTestDevice(t, port)
+	session := startTestSession(t, pool, port)
+	if session.Token == "" {
+		t.Fatalf("session token not returned on creation")
+	}
+
+	requests := []struct {
+		method, action string
+	}{
+		{http.MethodDelete, ""},
+		{http.MethodPost, "/measure"},
+		{http.MethodPost, "/finish"},
+	}
+	for _, request := range requests {
+		for _, token := range []string{"", "wrong"} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(request.method, "/api/v1/calibration/sessions/"+session.ID+request.action, nil)
+			if token != "" {
+				req.Header.Set(sessionTokenHeader, token)
+			}
+			calibrationSessionHandler(pool)(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("%s %q with token %q: expected 403, got %d: %s", request.method, request.action, token, rec.Code, rec.Body)
+			}
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	calibrationSessionHandler(pool)(rec, httptest.NewRequest(http.MethodGet, "/api/v1/calibration/sessions/"+session.ID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET: expected 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); strings.Contains(body, session.Token) {
+		t.Fatalf("session token leaked in status: %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/calibration/sessions/"+session.ID, nil)
+	req.Header.Set(sessionTokenHeader, session.Token)
+	calibrationSessionHandler(pool)(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE by creator: expected 204, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestCalibrationSession_StartWaitsForQueue(t *testing.T) {
+	const port = "/dev/test-session-queue"
+	pool, _ := newTestDevice(t, port)
+	vna, _ := pool.Get(port)
+	before := vna.Sweep()
+	clients := make([]string, jobsPerClient)
+	for i := range clients {
+		clients[i] = "calibrator"
+	}
+	occupyDevice(t, port, clients...)
+
+	rec := httptest.NewRecorder()
+	body := `{"port":"` + port + `","name":"cal","sweep":{"start":1e6,"stop":10e6,"points":11}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/calibration/sessions", strings.NewReader(body))
+	req.Header.Set(clientIDHeader, "calibrator")
+	calibrationSessionsHandler(pool)(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 while the client's scans are queued, got %d: %s", rec.Code, rec.Body)
+	}
+	if vna.Sweep() != before {
+		t.Fatalf("sweep changed outside the device queue: %+v", vna.Sweep())
+	}
+}
+
+// completeTestSession измеряет эталоны сессии идеальными open, short и load.
+func completeTestSession(t *testing.T, pool *govna.VNAPool, driver *testDriver, session sessionStatus) {
+	t.Helper()
+	for _, reflection := range []complex128{1, -1, 1e-12} {
+		driver.SetReflection(reflection)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/calibration/sessions/"+session.ID+"/measure", nil)
+		req.Header.Set(sessionTokenHeader, session.Token)
+		calibrationSessionHandler(pool)(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("measure: expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestCalibrationSession_FinishDoesNotOverwrite(t *testing.T) {
+	const port = "/dev/test-session-overwrite"
+	pool, driver := newTestDevice(t, port)
+	existing := idealProfile("cal")
+	if err := calibrations.Put(port, existing); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	t.Cleanup(func() { calibrations.Delete(port, "cal") })
+	session := startTestSession(t, pool, port)
+	completeTestSession(t, pool, driver, session)
+
+	finish := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/calibration/sessions/"+session.ID+"/finish", strings.NewReader(body))
+		req.Header.Set(sessionTokenHeader, session.Token)
+		calibrationSessionHandler(pool)(rec, req)
+		return rec
+	}
+	rec := finish(`{}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for an existing profile name, got %d: %s", rec.Code, rec.Body)
+	}
+	if body := decodeAPIError(t, rec); body.Code != "calibration_exists" {
+		t.Fatalf("expected calibration_exists, got %q", body.Code)
+	}
+	if calibrations.Get(port, "cal") != existing {
+		t.Fatalf("existing profile was replaced")
+	}
+
+	if rec := finish(`{"overwrite":true}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 with overwrite, got %d: %s", rec.Code, rec.Body)
+	}
+	if calibrations.Get(port, "cal") == existing {
+		t.Fatalf("profile was not replaced with overwrite")
+	}
+}
+
+func TestCalibrationProfile_DeleteRequiresLease(t *testing.T) {
+	const port = "/dev/test-profile-delete"
+	pool, _ := newTestDevice(t, port)
+	if err := calibrations.Put(port, idealProfile("stored")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	t.Cleanup(func() { calibrations.Delete(port, "stored") })
+	holder := acquireLease(t, pool, port, "bench")
+
+	remove := func(token string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/calibrations/stored?port="+port, nil)
+		if token != "" {
+			req.Header.Set(leaseTokenHeader, token)
+		}
+		calibrationProfileHandler(pool)(rec, req)
+		return rec.Code
+	}
+	if code := remove(""); code != http.StatusLocked {
+		t.Fatalf("expected 423 for an inactive profile of a leased device, got %d", code)
+	}
+	if calibrations.Get(port, "stored") == nil {
+		t.Fatalf("profile deleted without the lease")
+	}
+	if code := remove(holder.Token); code != http.StatusNoContent {
+		t.Fatalf("expected 204 for the lease holder, got %d", code)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/momentics/govna/pkg/govna"
+)
+
+// eventsKeepAlive - период комментариев SSE, не дающих прокси закрыть простаивающее соединение.
+const eventsKeepAlive = 15 * time.Second
+
+// eventsHandler передает события шины пула клиенту в формате Server-Sent Events.
+// Параметры device и type (через запятую или повторно) ограничивают устройства и типы событий.
+func eventsHandler(pool *govna.VNAPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, &apiError{Status: http.StatusInternalServerError, Code: "streaming_unsupported",
+				Message: "Сервер не поддерживает потоковую передачу"})
+			return
+		}
+		query := r.URL.Query()
+		filter := govna.EventFilter{Devices: listParameter(query["device"])}
+		for _, value := range listParameter(query["type"]) {
+			filter.Types = append(filter.Types, govna.EventType(value))
+		}
+
+		subscription := pool.Events().Subscribe(filter, 0)
+		defer subscription.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+		var id uint64
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case event, ok := <-subscription.C:
+				if !ok {
+					return
+				}
+				payload, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				id++
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event.Type, payload)
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// listParameter объединяет повторяющиеся параметры и списки через запятую.
+func listParameter(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/momentics/govna/pkg/govna"
+)
+
+// sseEvent - событие, прочитанное из потока Server-Sent Events.
+type sseEvent struct {
+	id, name string
+	data     govna.Event
+}
+
+// readSSEEvent читает следующее событие потока, пропуская комментарии.
+func readSSEEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	var data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event stream failed: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && data != "":
+			if err := json.Unmarshal([]byte(data), &event.data); err != nil {
+				t.Fatalf("event data is not JSON: %v: %s", err, data)
+			}
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestEventsHandler_FiltersAndFraming(t *testing.T) {
+	pool := govna.NewVNAPool()
+	server := httptest.NewServer(eventsHandler(pool))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/events?device=/dev/a&type=sweep.error,calibration.changed&type=limit.failed")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// Заголовки отправляются после подписки, поэтому все опубликованные далее события доходят до клиента.
+	bus := pool.Events()
+	bus.Publish(govna.Event{Type: govna.EventSweepError, Device: "/dev/b", Time: time.Now(), Message: "other device"})
+	bus.Publish(govna.Event{Type: govna.EventDeviceConnected, Device: "/dev/a", Time: time.Now(), Message: "other type"})
+	bus.Publish(govna.Event{Type: govna.EventSweepError, Device: "/dev/a", Time: time.Now(), Message: "first"})
+	bus.Publish(govna.Event{Type: govna.EventLimitFailed, Device: "/dev/a", Time: time.Now(), Message: "second"})
+
+	reader := bufio.NewReader(resp.Body)
+	for i, want := range []struct {
+		name, message string
+	}{{"sweep.error", "first"}, {"limit.failed", "second"}} {
+		event := readSSEEvent(t, reader)
+		if event.id != strconv.Itoa(i+1) || event.name != want.name {
+			t.Fatalf("event %d: unexpected framing id=%q event=%q", i, event.id, event.name)
+		}
+		if event.data.Device != "/dev/a" || event.data.Message != want.message || string(event.data.Type) != want.name {
+			t.Fatalf("event %d: unexpected data %+v", i, event.data)
+		}
+	}
+}
+
+func TestListParameter(t *testing.T) {
+	got := listParameter([]string{"a, b", "", "c,,"})
+	if strings.Join(got, "|") != "a|b|c" {
+		t.Fatalf("unexpected list %q", got)
+	}
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/momentics/govna/pkg/govna"
+	"github.com/momentics/govna/pkg/govnapb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcAddr - адрес gRPC-сервера, работающего рядом с HTTP.
+const grpcAddr = ":9090"
+
+// defaultCalibrationStandards - эталоны калибровки, если StartCalibration их не перечисляет.
+var defaultCalibrationStandards = []govna.CalibrationStandard{
+	govna.CalibrationStandardOpen, govna.CalibrationStandardShort, govna.CalibrationStandardLoad,
+}
+
+// vnaService реализует govnapb.VNAServiceServer поверх пула устройств.
+type vnaService struct {
+	govnapb.UnimplementedVNAServiceServer
+	pool *govna.VNAPool
+}
+
+func newGRPCServer(pool *govna.VNAPool) *grpc.Server {
+	server := grpc.NewServer()
+	govnapb.RegisterVNAServiceServer(server, &vnaService{pool: pool})
+	return server
+}
+
+func (s *vnaService) device(port string) (*govna.VNA, error) {
+	if port == "" {
+		return nil, status.Error(codes.InvalidArgument, "поле port обязательно")
+	}
+	vna, err := s.pool.Get(port)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "ошибка устройства: %v", err)
+	}
+	return vna, nil
+}
+
+// checkLease проверяет аренду устройства по токену из метаданных x-lease-token
+// (аналог заголовка X-Lease-Token). Возвращает аренду владельца, если клиент им не является.
+func checkLease(ctx context.Context, port string) (lease, bool) {
+	return leases.Check(port, leaseToken(ctx))
+}
+
+// leaseToken возвращает токен аренды из метаданных x-lease-token.
+func leaseToken(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(leaseTokenHeader); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// leaseStatus возвращает FailedPrecondition, если устройство арендовано другим клиентом.
+func leaseStatus(ctx context.Context, port string) error {
+	if holder, ok := checkLease(ctx, port); !ok {
+		return status.Errorf(codes.FailedPrecondition, "устройство %s арендовано клиентом %q до %s",
+			port, holder.Owner, holder.Expires.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// grpcClientID определяет клиента для очереди заданий: метаданные x-client-id или адрес клиента.
+func grpcClientID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(clientIDHeader); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// scheduleStatus преобразует ошибку очереди заданий: аренда другим клиентом - FailedPrecondition,
+// перегрузка - ResourceExhausted, истекшее ожидание - Unavailable.
+func scheduleStatus(err error) error {
+	var denied *leasedError
+	switch {
+	case errors.As(err, &denied):
+		return status.Error(codes.FailedPrecondition, denied.Error())
+	case errors.Is(err, govna.ErrQueueFull), errors.Is(err, govna.ErrClientQueueFull):
+		return status.Errorf(codes.ResourceExhausted, "устройство перегружено: %v", err)
+	case errors.Is(err, govna.ErrQueueTimeout):
+		return status.Errorf(codes.Unavailable, "%v", err)
+	}
+	return status.FromContextError(err).Err()
+}
+
+func (s *vnaService) ListDevices(ctx context.Context, req *govnapb.ListDevicesRequest) (*govnapb.ListDevicesResponse, error) {
+	resp := &govnapb.ListDevicesResponse{}
+	open := make(map[string]bool)
+	for _, port := range s.pool.Devices() {
+		open[port] = true
+		resp.Devices = append(resp.Devices, &govnapb.Device{Port: port, Open: true})
+	}
+	available, err := govna.AvailablePorts()
+	if err != nil {
+		log.Printf("Ошибка получения списка портов: %v", err)
+	}
+	for _, port := range available {
+		if !open[port] {
+			resp.Devices = append(resp.Devices, &govnapb.Device{Port: port})
+		}
+	}
+	return resp, nil
+}
+
+func (s *vnaService) GetInfo(ctx context.Context, req *govnapb.GetInfoRequest) (*govnapb.DeviceInfo, error) {
+	vna, err := s.device(req.GetPort())
+	if err != nil {
+		return nil, err
+	}
+	identity, err := vna.Identify()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "ошибка идентификации: %v", err)
+	}
+	info := &govnapb.DeviceInfo{
+		Port:         req.GetPort(),
+		Identity:     identity,
+		Capabilities: capabilitiesToProto(vna.Capabilities()),
+		Sweep:        sweepToProto(vna.Sweep()),
+	}
+	if profile := vna.Calibration(); profile != nil {
+		info.Calibration = profile.Name
+	}
+	return info, nil
+}
+
+func (s *vnaService) SetSweep(ctx context.Context, req *govnapb.SetSweepRequest) (*govnapb.SetSweepResponse, error) {
+	vna, err := s.device(req.GetPort())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetSweep() == nil {
+		return nil, status.Error(codes.InvalidArgument, "поле sweep обязательно")
+	}
+	if err := leaseStatus(ctx, req.GetPort()); err != nil {
+		return nil, err
+	}
+	if err := vna.SetSweep(sweepFromProto(req.GetSweep())); err != nil {
+		return nil, sweepStatus(err)
+	}
+	return &govnapb.SetSweepResponse{Sweep: sweepToProto(vna.Sweep())}, nil
+}
+
+// Scan выполняет сканирование. Клиент, не владеющий арендой устройства, получает последний скан
+// без нового сканирования, а если сканирований не было - FailedPrecondition.
+func (s *vnaService) Scan(ctx context.Context, req *govnapb.ScanRequest) (*govnapb.ScanResponse, error) {
+	vna, err := s.device(req.GetPort())
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := checkLease(ctx, req.GetPort()); !ok {
+		data, meta, ok := vna.LastScan()
+		if !ok {
+			return nil, leaseStatus(ctx, req.GetPort())
+		}
+		meta.Port = req.GetPort()
+		return scanToProto(&data, meta), nil
+	}
+	sweep := vna.Sweep()
+	if req.GetSweep() != nil {
+		sweep = sweepFromProto(req.GetSweep())
+	} else if sweep.Points == 0 {
+		sweep = defaultSweep
+	}
+
+	var data govna.VNAData
+	var meta govna.ScanMetadata
+	var duration time.Duration
+	if err := runLeasedJob(ctx, req.GetPort(), grpcClientID(ctx), leaseToken(ctx), govna.PriorityNormal, func() {
+		start := time.Now()
+		data, meta, err = vna.Measure(ctx, govna.MeasureRequest{Sweep: sweep, Calibration: vna.Calibration()})
+		duration = time.Since(start)
+	}); err != nil {
+		return nil, scheduleStatus(err)
+	}
+	if err != nil {
+		var sweepErr *govna.SweepError
+		if errors.As(err, &sweepErr) {
+			return nil, sweepStatus(err)
+		}
+		return nil, status.Errorf(codes.Unavailable, "ошибка сканирования: %v", err)
+	}
+	scanDuration.WithLabelValues(req.GetPort()).Observe(duration.Seconds())
+	meta.Port = req.GetPort()
+	return scanToProto(&data, meta), nil
+}
+
+// StreamSweeps передает кадры непрерывного сканирования. Кадры с ошибками устройства пропускаются:
+// сканирование повторяется, а пропуск виден по номеру скана и событию sweep.error. Аренда
+// проверяется перед каждым кадром: потеря аренды завершает поток с FailedPrecondition.
+func (s *vnaService) StreamSweeps(req *govnapb.StreamSweepsRequest, stream grpc.ServerStreamingServer[govnapb.ScanResponse]) error {
+	vna, err := s.device(req.GetPort())
+	if err != nil {
+		return err
+	}
+	if err := leaseStatus(stream.Context(), req.GetPort()); err != nil {
+		return err
+	}
+	if vna.Sweep().Points == 0 {
+		if err := vna.SetSweep(defaultSweep); err != nil {
+			return sweepStatus(err)
+		}
+	}
+
+	vna.SetStreamScheduler(streamScheduler(req.GetPort()))
+	subscription := vna.Subscribe(govna.StreamOptions{Decimation: int(req.GetDecimation())})
+	defer subscription.Close()
+	streamSubscribers.WithLabelValues(req.GetPort()).Inc()
+	defer streamSubscribers.WithLabelValues(req.GetPort()).Dec()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case frame, ok := <-subscription.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "устройство закрыто")
+			}
+			if err := leaseStatus(stream.Context(), req.GetPort()); err != nil {
+				return err
+			}
+			if frame.Err != nil {
+				continue
+			}
+			frame.Metadata.Port = req.GetPort()
+			if err := stream.Send(scanToProto(&frame.Data, frame.Metadata)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Calibrate ведет калибровку по двунаправленному потоку: первое сообщение клиента - StartCalibration,
+// далее на каждый CalibrationPrompt клиент отвечает StandardReady или CancelCalibration.
+func (s *vnaService) Calibrate(stream grpc.BidiStreamingServer[govnapb.CalibrationClientMessage, govnapb.CalibrationServerMessage]) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	start := first.GetStart()
+	if start == nil {
+		return status.Error(codes.InvalidArgument, "первое сообщение должно быть StartCalibration")
+	}
+	if start.GetName() == "" {
+		return status.Error(codes.InvalidArgument, "поле name обязательно")
+	}
+	vna, err := s.device(start.GetPort())
+	if err != nil {
+		return err
+	}
+	if err := leaseStatus(stream.Context(), start.GetPort()); err != nil {
+		return err
+	}
+
+	plan := govna.CalibrationPlan{Name: start.GetName(), Sweep: defaultSweep}
+	if start.GetSweep() != nil {
+		plan.Sweep = sweepFromProto(start.GetSweep())
+	}
+	standards := defaultCalibrationStandards
+	if len(start.GetStandards()) > 0 {
+		standards = nil
+		for _, standard := range start.GetStandards() {
+			standards = append(standards, govna.CalibrationStandard(standard))
+		}
+	}
+	for _, standard := range standards {
+		plan.Steps = append(plan.Steps, govna.CalibrationStep{Standard: standard})
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	ready := make(chan string)
+	received := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err == nil && msg.GetCancel() != nil {
+				err = status.Error(codes.Canceled, "калибровка отменена клиентом")
+			}
+			if err == nil && msg.GetReady() == nil {
+				err = status.Error(codes.InvalidArgument, "ожидалось сообщение StandardReady")
+			}
+			if err != nil {
+				received <- err
+				return
+			}
+			select {
+			case ready <- msg.GetReady().GetStandard():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	prompt := func(ctx context.Context, step int, standard govna.CalibrationStandard) error {
+		if err := stream.Send(&govnapb.CalibrationServerMessage{Message: &govnapb.CalibrationServerMessage_Prompt{
+			Prompt: &govnapb.CalibrationPrompt{Standard: string(standard), Step: int32(step), Total: int32(len(plan.Steps))},
+		}}); err != nil {
+			return err
+		}
+		select {
+		case got := <-ready:
+			if got != "" && got != string(standard) {
+				return status.Errorf(codes.InvalidArgument, "подключен эталон %s, ожидался %s", got, standard)
+			}
+			return nil
+		case err := <-received:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	calibrationStatus := func(err error) error {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		var sweepErr *govna.SweepError
+		if errors.As(err, &sweepErr) {
+			return sweepStatus(err)
+		}
+		return status.Errorf(codes.Aborted, "ошибка калибровки: %v", err)
+	}
+
+	// Перестройка сетки и эталоны выполняются через очередь устройства, как и обычные сканы,
+	// с повторной проверкой аренды перед каждым заданием.
+	var session *govna.CalibrationSession
+	if err := runLeasedJob(ctx, start.GetPort(), grpcClientID(ctx), leaseToken(ctx), govna.PriorityNormal, func() {
+		session, err = vna.StartCalibration(plan)
+	}); err != nil {
+		return scheduleStatus(err)
+	}
+	if err != nil {
+		return calibrationStatus(err)
+	}
+	for step := 1; ; step++ {
+		next, ok := session.Next()
+		if !ok {
+			break
+		}
+		if err := prompt(ctx, step, next.Standard); err != nil {
+			return calibrationStatus(err)
+		}
+		if err := runLeasedJob(ctx, start.GetPort(), grpcClientID(ctx), leaseToken(ctx), govna.PriorityNormal, func() {
+			err = session.Measure(ctx)
+		}); err != nil {
+			return scheduleStatus(err)
+		}
+		if err != nil {
+			return calibrationStatus(err)
+		}
+	}
+	profile, err := session.Finish()
+	if err == nil {
+		err = vna.LoadCalibration(profile)
+	}
+	if err != nil {
+		return calibrationStatus(err)
+	}
+	if err := calibrations.Put(start.GetPort(), profile); err != nil {
+		return status.Errorf(codes.Internal, "%v", err)
+	}
+	return stream.Send(&govnapb.CalibrationServerMessage{Message: &govnapb.CalibrationServerMessage_Result{
+		Result: calibrationToProto(profile),
+	}})
+}
+
+func (s *vnaService) LoadCalibration(ctx context.Context, req *govnapb.LoadCalibrationRequest) (*govnapb.CalibrationResult, error) {
+	profile := calibrations.Get(req.GetPort(), req.GetName())
+	if profile == nil {
+		return nil, status.Errorf(codes.NotFound, "калибровочный профиль %q не найден", req.GetName())
+	}
+	vna, err := s.device(req.GetPort())
+	if err != nil {
+		return nil, err
+	}
+	if err := leaseStatus(ctx, req.GetPort()); err != nil {
+		return nil, err
+	}
+	if err := vna.SetSweep(profile.Sweep); err != nil {
+		return nil, sweepStatus(err)
+	}
+	if err := vna.LoadCalibration(profile); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "ошибка калибровки: %v", err)
+	}
+	return calibrationToProto(profile), nil
+}
+
+func (s *vnaService) ClearCalibration(ctx context.Context, req *govnapb.ClearCalibrationRequest) (*govnapb.ClearCalibrationResponse, error) {
+	vna, err := s.device(req.GetPort())
+	if err != nil {
+		return nil, err
+	}
+	if err := leaseStatus(ctx, req.GetPort()); err != nil {
+		return nil, err
+	}
+	vna.ClearCalibration()
+	return &govnapb.ClearCalibrationResponse{}, nil
+}
+
+// sweepStatus преобразует ошибку SetSweep: некорректные параметры - InvalidArgument, остальное - ошибка устройства.
+func sweepStatus(err error) error {
+	var sweepErr *govna.SweepError
+	if errors.As(err, &sweepErr) {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("sweep.%s: %s", sweepErr.Field, sweepErr.Message))
+	}
+	return status.Errorf(codes.Unavailable, "ошибка устройства: %v", err)
+}
+
+func sweepFromProto(p *govnapb.SweepConfig) govna.SweepConfig {
+	return govna.SweepConfig{Start: p.GetStart(), Stop: p.GetStop(), Points: int(p.GetPoints()), Type: govna.SweepType(p.GetType())}
+}
+
+func sweepToProto(c govna.SweepConfig) *govnapb.SweepConfig {
+	return &govnapb.SweepConfig{Start: c.Start, Stop: c.Stop, Points: int32(c.Points), Type: string(c.Type)}
+}
+
+func capabilitiesToProto(c govna.DeviceCapabilities) *govnapb.Capabilities {
+	p := &govnapb.Capabilities{
+		Model:        c.Model,
+		MinFrequency: c.MinFrequency,
+		MaxFrequency: c.MaxFrequency,
+		MinPoints:    int32(c.MinPoints),
+		MaxPoints:    int32(c.MaxPoints),
+	}
+	for _, t := range c.SweepTypes {
+		p.SweepTypes = append(p.SweepTypes, string(t))
+	}
+	return p
+}
+
+func calibrationToProto(profile *govna.CalibrationProfile) *govnapb.CalibrationResult {
+	return &govnapb.CalibrationResult{Name: profile.Name, Method: string(profile.Method), Sweep: sweepToProto(profile.Sweep)}
+}
+
+func scanToProto(data *govna.VNAData, meta govna.ScanMetadata) *govnapb.ScanResponse {
+	return &govnapb.ScanResponse{
+		Metadata: &govnapb.ScanMetadata{
+			Device:          meta.Device,
+			Port:            meta.Port,
+			Calibration:     meta.Calibration,
+			Sweep:           sweepToProto(meta.Sweep),
+			StartedUnixNano: meta.Started.UnixNano(),
+			DurationNs:      int64(meta.Duration),
+			Sequence:        meta.Sequence,
+		},
+		Data: &govnapb.ScanData{
+			Frequencies: data.Frequencies,
+			S11:         interleave(data.S11),
+			S21:         interleave(data.S21),
+			S12:         interleave(data.S12),
+			S22:         interleave(data.S22),
+			Z0:          interleave(data.Z0),
+		},
+	}
+}
+
+// interleave записывает комплексные значения парами re, im.
+func interleave(values []complex128) []float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]float64, 0, 2*len(values))
+	for _, v := range values {
+		out = append(out, real(v), imag(v))
+	}
+	return out
+}
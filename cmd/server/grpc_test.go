@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/momentics/govna/pkg/govna"
+	"github.com/momentics/govna/pkg/govnapb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCClient запускает gRPC-сервис пула в памяти и возвращает подключенного клиента.
+func newGRPCClient(t *testing.T, pool *govna.VNAPool) govnapb.VNAServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := newGRPCServer(pool)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return govnapb.NewVNAServiceClient(conn)
+}
+
+func expectCode(t *testing.T, name string, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("%s: expected %s, got %v", name, code, err)
+	}
+}
+
+func TestGRPC_StatusCodes(t *testing.T) {
+	const port = "/dev/test-grpc-status"
+	pool, driver := newTestDevice(t, port)
+	client := newGRPCClient(t, pool)
+	ctx := context.Background()
+
+	_, err := client.GetInfo(ctx, &govnapb.GetInfoRequest{})
+	expectCode(t, "missing port", err, codes.InvalidArgument)
+	_, err = client.GetInfo(ctx, &govnapb.GetInfoRequest{Port: "/dev/test-grpc-missing"})
+	expectCode(t, "unknown device", err, codes.Unavailable)
+	_, err = client.SetSweep(ctx, &govnapb.SetSweepRequest{Port: port})
+	expectCode(t, "missing sweep", err, codes.InvalidArgument)
+	_, err = client.Scan(ctx, &govnapb.ScanRequest{Port: port, Sweep: &govnapb.SweepConfig{Start: 1e6, Stop: 10e6, Points: 11, Type: "log"}})
+	expectCode(t, "unsupported sweep type", err, codes.InvalidArgument)
+	_, err = client.Scan(ctx, &govnapb.ScanRequest{Port: port, Sweep: &govnapb.SweepConfig{Start: 1e6, Stop: 10e6, Points: 1000}})
+	expectCode(t, "too many points", err, codes.InvalidArgument)
+	_, err = client.LoadCalibration(ctx, &govnapb.LoadCalibrationRequest{Port: port, Name: "missing"})
+	expectCode(t, "unknown calibration", err, codes.NotFound)
+
+	clients := make([]string, jobsPerClient)
+	for i := range clients {
+		clients[i] = "grpc-client"
+	}
+	occupyDevice(t, port, clients...)
+	busy := metadata.AppendToOutgoingContext(ctx, clientIDHeader, "grpc-client")
+	_, err = client.Scan(busy, &govnapb.ScanRequest{Port: port})
+	expectCode(t, "client limit", err, codes.ResourceExhausted)
+	if driver.Scans() != 0 {
+		t.Fatalf("rejected requests must not scan, got %d scans", driver.Scans())
+	}
+}
+
+func TestGRPC_ScanLeaseFallback(t *testing.T) {
+	const port = "/dev/test-grpc-lease"
+	pool, driver := newTestDevice(t, port)
+	client := newGRPCClient(t, pool)
+	holder := acquireLease(t, pool, port, "bench")
+	ctx := context.Background()
+	owner := metadata.AppendToOutgoingContext(ctx, leaseTokenHeader, holder.Token)
+
+	// Сканов еще не было: другому клиенту нечего отдать.
+	_, err := client.Scan(ctx, &govnapb.ScanRequest{Port: port})
+	expectCode(t, "scan before owner", err, codes.FailedPrecondition)
+	_, err = client.SetSweep(ctx, &govnapb.SetSweepRequest{Port: port, Sweep: &govnapb.SweepConfig{Start: 1e6, Stop: 10e6, Points: 11}})
+	expectCode(t, "set sweep without lease", err, codes.FailedPrecondition)
+
+	scan, err := client.Scan(owner, &govnapb.ScanRequest{Port: port, Sweep: &govnapb.SweepConfig{Start: 1e6, Stop: 10e6, Points: 11}})
+	if err != nil {
+		t.Fatalf("owner Scan failed: %v", err)
+	}
+	if len(scan.GetData().GetFrequencies()) != 11 || scan.GetMetadata().GetPort() != port {
+		t.Fatalf("unexpected owner scan %+v", scan.GetMetadata())
+	}
+
+	// Остальные клиенты получают скан владельца без нового сканирования.
+	last, err := client.Scan(ctx, &govnapb.ScanRequest{Port: port})
+	if err != nil {
+		t.Fatalf("read-only Scan failed: %v", err)
+	}
+	if last.GetMetadata().GetSequence() != scan.GetMetadata().GetSequence() || driver.Scans() != 1 {
+		t.Fatalf("expected the owner's scan without rescanning, got sequence %d after %d scans",
+			last.GetMetadata().GetSequence(), driver.Scans())
+	}
+}
+
+func TestGRPC_StreamSweeps(t *testing.T) {
+	const port = "/dev/test-grpc-stream"
+	pool, _ := newTestDevice(t, port)
+	client := newGRPCClient(t, pool)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.StreamSweeps(ctx, &govnapb.StreamSweepsRequest{Port: port, Decimation: 2})
+	if err != nil {
+		t.Fatalf("StreamSweeps failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		frame, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		meta := frame.GetMetadata()
+		if meta.GetPort() != port || len(frame.GetData().GetFrequencies()) != defaultSweep.Points || (meta.GetSequence()-1)%2 != 0 {
+			t.Fatalf("unexpected frame %+v", meta)
+		}
+	}
+	// Уже полученные клиентом кадры дочитываются до отмены потока.
+	cancel()
+	for {
+		if _, err := stream.Recv(); err != nil {
+			expectCode(t, "cancelled stream", err, codes.Canceled)
+			break
+		}
+	}
+
+	// Поток, начатый без аренды, завершается, когда устройство арендует другой клиент.
+	stream, err = client.StreamSweeps(context.Background(), &govnapb.StreamSweepsRequest{Port: port})
+	if err != nil {
+		t.Fatalf("StreamSweeps failed: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	acquireLease(t, pool, port, "bench")
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	expectCode(t, "lease taken during the stream", err, codes.FailedPrecondition)
+
+	stream, err = client.StreamSweeps(context.Background(), &govnapb.StreamSweepsRequest{Port: port})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	expectCode(t, "stream of a leased device", err, codes.FailedPrecondition)
+}
+
+// calibrate начинает калибровку по gRPC и возвращает поток после первого запроса эталона.
+func calibrate(t *testing.T, client govnapb.VNAServiceClient, port, name string) (govnapb.VNAService_CalibrateClient, *govnapb.CalibrationPrompt) {
+	t.Helper()
+	stream, err := client.Calibrate(context.Background())
+	if err != nil {
+		t.Fatalf("Calibrate failed: %v", err)
+	}
+	if err := stream.Send(&govnapb.CalibrationClientMessage{Message: &govnapb.CalibrationClientMessage_Start{Start: &govnapb.StartCalibration{
+		Port: port, Name: name, Sweep: &govnapb.SweepConfig{Start: 1e6, Stop: 10e6, Points: 11},
+	}}}); err != nil {
+		t.Fatalf("Send start failed: %v", err)
+	}
+	msg, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv prompt failed: %v", err)
+	}
+	if msg.GetPrompt() == nil {
+		t.Fatalf("expected a prompt, got %+v", msg)
+	}
+	return stream, msg.GetPrompt()
+}
+
+func sendReady(t *testing.T, stream govnapb.VNAService_CalibrateClient, standard string) {
+	t.Helper()
+	if err := stream.Send(&govnapb.CalibrationClientMessage{Message: &govnapb.CalibrationClientMessage_Ready{
+		Ready: &govnapb.StandardReady{Standard: standard},
+	}}); err != nil {
+		t.Fatalf("Send ready failed: %v", err)
+	}
+}
+
+func TestGRPC_Calibrate(t *testing.T) {
+	const port = "/dev/test-grpc-calibrate"
+	pool, driver := newTestDevice(t, port)
+	client := newGRPCClient(t, pool)
+
+	// Идеальные эталоны: open - 1, short - -1, load - 0.
+	reflections := map[string]complex128{"open": 1, "short": -1, "load": 0}
+	stream, prompt := calibrate(t, client, port, "grpc-cal")
+	for step := 1; ; step++ {
+		if prompt.GetStep() != int32(step) || prompt.GetTotal() != 3 {
+			t.Fatalf("unexpected prompt %+v at step %d", prompt, step)
+		}
+		reflection, ok := reflections[prompt.GetStandard()]
+		if !ok {
+			t.Fatalf("unexpected standard %q", prompt.GetStandard())
+		}
+		// Нулевое значение драйвер заменяет на 0.5, поэтому load задается малым отражением.
+		if reflection == 0 {
+			reflection = 1e-12
+		}
+		driver.SetReflection(reflection)
+		sendReady(t, stream, prompt.GetStandard())
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		if result := msg.GetResult(); result != nil {
+			if result.GetName() != "grpc-cal" || step != 3 {
+				t.Fatalf("unexpected result %+v after step %d", result, step)
+			}
+			break
+		}
+		prompt = msg.GetPrompt()
+	}
+	t.Cleanup(func() { calibrations.Delete(port, "grpc-cal") })
+	if calibrations.Get(port, "grpc-cal") == nil {
+		t.Fatalf("calibration profile was not stored")
+	}
+	if vna, _ := pool.Get(port); vna.Calibration() == nil || vna.Calibration().Name != "grpc-cal" {
+		t.Fatalf("calibration profile was not loaded into the device")
+	}
+
+	stream, prompt = calibrate(t, client, port, "wrong")
+	sendReady(t, stream, "short")
+	_, err := stream.Recv()
+	expectCode(t, "wrong standard", err, codes.InvalidArgument)
+	if prompt.GetStandard() != "open" {
+		t.Fatalf("expected open first, got %q", prompt.GetStandard())
+	}
+
+	stream, _ = calibrate(t, client, port, "cancelled")
+	if err := stream.Send(&govnapb.CalibrationClientMessage{Message: &govnapb.CalibrationClientMessage_Cancel{
+		Cancel: &govnapb.CancelCalibration{},
+	}}); err != nil {
+		t.Fatalf("Send cancel failed: %v", err)
+	}
+	_, err = stream.Recv()
+	expectCode(t, "cancel", err, codes.Canceled)
+
+	stream, err = client.Calibrate(context.Background())
+	if err == nil {
+		err = stream.Send(&govnapb.CalibrationClientMessage{Message: &govnapb.CalibrationClientMessage_Ready{Ready: &govnapb.StandardReady{}}})
+	}
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	expectCode(t, "missing start", err, codes.InvalidArgument)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/momentics/govna/pkg/govna"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// jobQueueDepth - число сканирований, ожидающих одно устройство; сверх него - 503.
+	jobQueueDepth = 16
+	// jobsPerClient - число сканирований одного клиента в очереди и в работе; сверх него - 429.
+	jobsPerClient = 4
+	// jobQueueWait - наибольшее время ожидания сканирования в очереди.
+	jobQueueWait = 30 * time.Second
+	// clientIDHeader - заголовок с идентификатором клиента для справедливого чередования;
+	// без него клиент определяется по IP-адресу.
+	clientIDHeader = "X-Client-ID"
+)
+
+var (
+	jobQueueTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "govna_job_queue_seconds",
+			Help: "Time scan jobs spent waiting in the device queue",
+		},
+		[]string{"port", "priority"},
+	)
+	jobQueueLength = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "govna_job_queue_depth",
+			Help: "Number of scan jobs waiting for the device",
+		},
+		[]string{"port"},
+	)
+	jobsRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "govna_jobs_rejected_total",
+			Help: "Number of scan jobs rejected or abandoned by reason",
+		},
+		[]string{"port", "reason"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(jobQueueTime, jobQueueLength, jobsRejected)
+}
+
+// schedulers - очереди заданий устройств: сканирования одного устройства выполняются по одному
+// в порядке приоритета с чередованием клиентов.
+var schedulers = &schedulerTable{schedulers: make(map[string]*govna.Scheduler)}
+
+type schedulerTable struct {
+	mu         sync.Mutex
+	schedulers map[string]*govna.Scheduler
+}
+
+func (t *schedulerTable) Get(port string) *govna.Scheduler {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	scheduler, ok := t.schedulers[port]
+	if !ok {
+		depth := jobQueueLength.WithLabelValues(port)
+		scheduler = govna.NewScheduler(govna.SchedulerOptions{
+			MaxQueue:      jobQueueDepth,
+			MaxPerClient:  jobsPerClient,
+			MaxWait:       jobQueueWait,
+			OnQueueChange: func(queued int) { depth.Set(float64(queued)) },
+		})
+		t.schedulers[port] = scheduler
+	}
+	return scheduler
+}
+
+// runJob выполняет fn в очереди устройства и учитывает время ожидания в метриках.
+// Ошибка означает, что fn не выполнялась.
+func runJob(ctx context.Context, port, client string, priority govna.JobPriority, fn func()) error {
+	waited, err := schedulers.Get(port).Do(ctx, client, priority, fn)
+	if err != nil {
+		reason := "cancelled"
+		switch {
+		case errors.Is(err, govna.ErrQueueFull):
+			reason = "queue_full"
+		case errors.Is(err, govna.ErrClientQueueFull):
+			reason = "client_limit"
+		case errors.Is(err, govna.ErrQueueTimeout):
+			reason = "timeout"
+		}
+		jobsRejected.WithLabelValues(port, reason).Inc()
+		return err
+	}
+	jobQueueTime.WithLabelValues(port, priority.String()).Observe(waited.Seconds())
+	return nil
+}
+
+// runLeasedJob выполняет fn в очереди устройства, как runJob, но перед запуском повторно проверяет
+// аренду по токену token: пока задание ждало в очереди, устройство мог арендовать другой клиент.
+// В этом случае fn не выполняется и возвращается *leasedError.
+func runLeasedJob(ctx context.Context, port, client, token string, priority govna.JobPriority, fn func()) error {
+	var denied *leasedError
+	err := runJob(ctx, port, client, priority, func() {
+		if holder, ok := leases.Check(port, token); !ok {
+			denied = &leasedError{holder: holder}
+			return
+		}
+		fn()
+	})
+	if err != nil {
+		return err
+	}
+	if denied != nil {
+		return denied
+	}
+	return nil
+}
+
+// scheduleHTTP ставит сканирование HTTP-запроса в очередь устройства с проверкой аренды
+// по заголовку X-Lease-Token. Если задание не выполнено, отправляет клиенту ошибку:
+// 423 - устройство арендовано, 429 - превышено число запросов клиента, 503 - устройство перегружено.
+func scheduleHTTP(w http.ResponseWriter, r *http.Request, port string, priority govna.JobPriority, fn func()) bool {
+	err := runLeasedJob(r.Context(), port, clientID(r), r.Header.Get(leaseTokenHeader), priority, fn)
+	if err == nil {
+		return true
+	}
+	var denied *leasedError
+	if errors.As(err, &denied) {
+		writeError(w, deviceLeasedError(denied.holder))
+		return false
+	}
+	apiErr := &apiError{Status: http.StatusServiceUnavailable, Code: "device_busy",
+		Message: fmt.Sprintf("Устройство %s перегружено: %v", port, err)}
+	retryAfter := "1"
+	switch {
+	case errors.Is(err, govna.ErrClientQueueFull):
+		apiErr.Status, apiErr.Code = http.StatusTooManyRequests, "too_many_requests"
+		apiErr.Message = fmt.Sprintf("Не более %d одновременных сканирований устройства %s на клиента", jobsPerClient, port)
+	case errors.Is(err, govna.ErrQueueTimeout):
+		apiErr.Code = "queue_timeout"
+		retryAfter = strconv.Itoa(int(jobQueueWait.Seconds()))
+	case r.Context().Err() != nil:
+		// Клиент отключился, ответ уже некому отправлять.
+		return false
+	}
+	w.Header().Set("Retry-After", retryAfter)
+	writeError(w, apiErr)
+	return false
+}
+
+// clientID определяет клиента для справедливого чередования: заголовок X-Client-ID или IP-адрес.
+func clientID(r *http.Request) string {
+	if id := r.Header.Get(clientIDHeader); id != "" {
+		return id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// parsePriority разбирает параметр priority=low|normal|high (по умолчанию normal).
+func parsePriority(query url.Values) (govna.JobPriority, *apiError) {
+	switch value := query.Get("priority"); value {
+	case "", "normal":
+		return govna.PriorityNormal, nil
+	case "low":
+		return govna.PriorityLow, nil
+	case "high":
+		return govna.PriorityHigh, nil
+	default:
+		return 0, &apiError{Status: http.StatusBadRequest, Code: "unsupported_value", Field: "priority",
+			Message: fmt.Sprintf("Приоритет %q не поддерживается; допустимы low, normal, high", value)}
+	}
+}
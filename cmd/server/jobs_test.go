@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/momentics/govna/pkg/govna"
+)
+
+// testDriver - устройство без последовательного порта: S11 = reflection (по умолчанию 0.5)
+// на сетке последней настройки.
+type testDriver struct {
+	mu         sync.Mutex
+	sweep      govna.SweepConfig
+	scans      int
+	reflection complex128
+}
+
+func (d *testDriver) Identify() (string, error) { return "test", nil }
+func (d *testDriver) Close() error              { return nil }
+
+func (d *testDriver) Capabilities() govna.DeviceCapabilities {
+	return govna.DeviceCapabilities{Model: "Test VNA", MinFrequency: 10e3, MaxFrequency: 1e9, MinPoints: 1, MaxPoints: 201,
+		SweepTypes: []govna.SweepType{govna.SweepLinear}}
+}
+
+func (d *testDriver) SetSweep(config govna.SweepConfig) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sweep = config
+	return nil
+}
+
+func (d *testDriver) Scan() (govna.VNAData, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.scans++
+	reflection := d.reflection
+	if reflection == 0 {
+		reflection = 0.5
+	}
+	data := govna.VNAData{}
+	for i := 0; i < d.sweep.Points; i++ {
+		step := (d.sweep.Stop - d.sweep.Start) / float64(max(d.sweep.Points-1, 1))
+		data.Frequencies = append(data.Frequencies, d.sweep.Start+float64(i)*step)
+		data.S11 = append(data.S11, reflection)
+	}
+	return data, nil
+}
+
+// SetReflection задает S11 следующих сканов.
+func (d *testDriver) SetReflection(reflection complex128) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reflection = reflection
+}
+
+func (d *testDriver) Scans() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.scans
+}
+
+// newTestDevice открывает в новом пуле тестовое устройство с путем port. Пути устройств
+// разных тестов должны различаться: очереди и аренды сервера общие для пакета.
+func newTestDevice(t *testing.T, port string) (*govna.VNAPool, *testDriver) {
+	t.Helper()
+	pool := govna.NewVNAPool()
+	driver := &testDriver{}
+	if _, err := pool.Add(port, driver); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	t.Cleanup(func() { pool.Close(port) })
+	return pool, driver
+}
+
+// occupyDevice занимает очередь устройства: задание первого клиента выполняется, пока тест
+// не завершится, задания остальных ждут в очереди.
+func occupyDevice(t *testing.T, port string, clients ...string) {
+	t.Helper()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client string) {
+			defer wg.Done()
+			runJob(context.Background(), port, client, govna.PriorityNormal, func() {
+				if i == 0 {
+					close(started)
+				}
+				<-release
+			})
+		}(i, client)
+		if i == 0 {
+			<-started
+		}
+	}
+	waitQueued(t, port, len(clients)-1)
+	t.Cleanup(func() {
+		close(release)
+		wg.Wait()
+	})
+}
+
+// waitQueued ждет, пока в очереди устройства окажется n заданий.
+func waitQueued(t *testing.T, port string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for schedulers.Get(port).Len() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued jobs, got %d", n, schedulers.Get(port).Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func decodeAPIError(t *testing.T, rec *httptest.ResponseRecorder) apiError {
+	t.Helper()
+	var body apiError
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("error body is not JSON: %v", err)
+	}
+	return body
+}
+
+func TestScheduleHTTP_ClientLimit(t *testing.T) {
+	const port = "/dev/test-client-limit"
+	pool, driver := newTestDevice(t, port)
+	clients := make([]string, jobsPerClient)
+	for i := range clients {
+		clients[i] = "busy"
+	}
+	occupyDevice(t, port, clients...)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/quality?port="+port, nil)
+	req.Header.Set(clientIDHeader, "busy")
+	qualityHandler(pool)(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Fatalf("expected Retry-After 1, got %q", got)
+	}
+	if body := decodeAPIError(t, rec); body.Code != "too_many_requests" {
+		t.Fatalf("expected code too_many_requests, got %q", body.Code)
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("rejected request must not scan, got %d scans", driver.Scans())
+	}
+}
+
+func TestScheduleHTTP_QueueFull(t *testing.T) {
+	const port = "/dev/test-queue-full"
+	pool, driver := newTestDevice(t, port)
+	clients := []string{"holder"}
+	for i := 0; i < jobQueueDepth; i++ {
+		clients = append(clients, fmt.Sprintf("queued-%d", i))
+	}
+	occupyDevice(t, port, clients...)
+
+	for _, path := range []string{"/api/v1/scan", "/api/v1/quality"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path+"?port="+port, nil)
+		req.Header.Set(clientIDHeader, "late")
+		switch path {
+		case "/api/v1/scan":
+			scanHandler(pool)(rec, req)
+		default:
+			qualityHandler(pool)(rec, req)
+		}
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s: expected 503, got %d: %s", path, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "1" {
+			t.Fatalf("%s: expected Retry-After 1, got %q", path, got)
+		}
+		if body := decodeAPIError(t, rec); body.Code != "device_busy" {
+			t.Fatalf("%s: expected code device_busy, got %q", path, body.Code)
+		}
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("rejected requests must not scan, got %d scans", driver.Scans())
+	}
+}
+
+func TestScheduleHTTP_LeaseTakenWhileQueued(t *testing.T) {
+	const port = "/dev/test-lease-queued"
+	pool, driver := newTestDevice(t, port)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go runJob(context.Background(), port, "holder", govna.PriorityNormal, func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/quality?port="+port, nil)
+		qualityHandler(pool)(rec, req)
+	}()
+	waitQueued(t, port, 1)
+
+	// Аренда выдана, пока запрос ждал в очереди: сканировать ему уже нельзя.
+	holder, ok := leases.Acquire(port, "owner", time.Minute)
+	if !ok {
+		t.Fatalf("Acquire failed")
+	}
+	defer leases.Release(port, holder.Token)
+	close(release)
+	<-done
+
+	if rec.Code != http.StatusLocked {
+		t.Fatalf("expected 423, got %d: %s", rec.Code, rec.Body)
+	}
+	if body := decodeAPIError(t, rec); body.Code != "device_leased" || !strings.Contains(body.Message, "owner") {
+		t.Fatalf("unexpected error %+v", body)
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("leased device must not be scanned, got %d scans", driver.Scans())
+	}
+}
+
+// startTestSession создает сессию калибровки open, short, load на тестовом устройстве.
+func startTestSession(t *testing.T, pool *govna.VNAPool, port string) sessionStatus {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	body := `{"port":"` + port + `","name":"cal","sweep":{"start":1e6,"stop":10e6,"points":11}}`
+	calibrationSessionsHandler(pool)(rec, httptest.NewRequest(http.MethodPost, "/api/v1/calibration/sessions", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var status sessionStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("decode session: %v", err)
+	}
+	t.Cleanup(func() { calibrationSessions.Remove(status.ID) })
+	return status
+}
+
+func TestCalibrationMeasure_ClientLimit(t *testing.T) {
+	const port = "/dev/test-calibration-limit"
+	pool, driver := newTestDevice(t, port)
+	session := startTestSession(t, pool, port)
+	clients := make([]string, jobsPerClient)
+	for i := range clients {
+		clients[i] = "calibrator"
+	}
+	occupyDevice(t, port, clients...)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/calibration/sessions/"+session.ID+"/measure", nil)
+	req.Header.Set(clientIDHeader, "calibrator")
+	req.Header.Set(sessionTokenHeader, session.Token)
+	calibrationSessionHandler(pool)(rec, req)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected 429 with Retry-After, got %d: %s", rec.Code, rec.Body)
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("rejected measurement must not scan, got %d scans", driver.Scans())
+	}
+}
+
+func TestCalibrationMeasure_SessionAvailableWhileQueued(t *testing.T) {
+	const port = "/dev/test-calibration-queued"
+	pool, driver := newTestDevice(t, port)
+	session := startTestSession(t, pool, port)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go runJob(context.Background(), port, "holder", govna.PriorityNormal, func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	measured := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/calibration/sessions/"+session.ID+"/measure", nil)
+		req.Header.Set(sessionTokenHeader, session.Token)
+		calibrationSessionHandler(pool)(measured, req)
+	}()
+	waitQueued(t, port, 1)
+
+	// Скан эталона ждет очереди устройства, а состояние сессии остается доступным.
+	rec := httptest.NewRecorder()
+	calibrationSessionHandler(pool)(rec, httptest.NewRequest(http.MethodGet, "/api/v1/calibration/sessions/"+session.ID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("queued measurement must not scan yet, got %d scans", driver.Scans())
+	}
+
+	close(release)
+	<-done
+	if measured.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", measured.Code, measured.Body)
+	}
+	var status sessionStatus
+	if err := json.NewDecoder(measured.Body).Decode(&status); err != nil {
+		t.Fatalf("decode session: %v", err)
+	}
+	if status.Completed != 1 || status.Next != govna.CalibrationStandardShort {
+		t.Fatalf("expected open measured and short next, got %+v", status)
+	}
+}
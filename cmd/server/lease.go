@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/momentics/govna/pkg/govna"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// defaultLeaseTTL и maxLeaseTTL - срок аренды по умолчанию и наибольший допустимый срок.
+	defaultLeaseTTL = 5 * time.Minute
+	maxLeaseTTL     = time.Hour
+	// leaseTokenHeader - заголовок, которым владелец аренды подтверждает право настраивать устройство.
+	leaseTokenHeader = "X-Lease-Token"
+)
+
+var (
+	deviceLeased = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "govna_device_leased",
+			Help: "Whether the device is exclusively leased (1) or free (0)",
+		},
+		[]string{"port"},
+	)
+	leaseDenied = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "govna_lease_denied_total",
+			Help: "Number of requests refused or served read-only because another client holds the lease",
+		},
+		[]string{"port"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(deviceLeased, leaseDenied)
+}
+
+// lease - исключительная аренда устройства. Token выдается только владельцу при получении аренды.
+type lease struct {
+	Port     string    `json:"port"`
+	Owner    string    `json:"owner"`
+	Token    string    `json:"token,omitempty"`
+	Acquired time.Time `json:"acquired"`
+	Expires  time.Time `json:"expires"`
+
+	timer *time.Timer
+}
+
+// public возвращает копию аренды без токена.
+func (l *lease) public() lease {
+	return lease{Port: l.Port, Owner: l.Owner, Acquired: l.Acquired, Expires: l.Expires}
+}
+
+// leases - аренды устройств пула. Пока устройство арендовано, настраивать и сканировать его
+// может только владелец; остальные клиенты получают последний скан только для чтения.
+var leases = &leaseTable{leases: make(map[string]*lease)}
+
+type leaseTable struct {
+	mu     sync.Mutex
+	leases map[string]*lease
+}
+
+// Acquire выдает аренду устройства. Если устройство арендовано другим клиентом, возвращает
+// действующую аренду и false.
+func (t *leaseTable) Acquire(port, owner string, ttl time.Duration) (lease, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if current, ok := t.leases[port]; ok {
+		return current.public(), false
+	}
+	now := time.Now()
+	l := &lease{Port: port, Owner: owner, Token: randomHex(16), Acquired: now, Expires: now.Add(ttl)}
+	token := l.Token
+	l.timer = time.AfterFunc(ttl, func() { t.expire(port, token) })
+	t.leases[port] = l
+	deviceLeased.WithLabelValues(port).Set(1)
+	return *l, true
+}
+
+// Renew продлевает аренду владельца на ttl от текущего момента.
+func (t *leaseTable) Renew(port, token string, ttl time.Duration) (lease, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l, ok := t.leases[port]
+	if !ok || l.Token != token {
+		return lease{}, false
+	}
+	l.Expires = time.Now().Add(ttl)
+	l.timer.Reset(ttl)
+	return *l, true
+}
+
+// Release освобождает устройство; false означает, что token не принадлежит владельцу аренды.
+func (t *leaseTable) Release(port, token string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l, ok := t.leases[port]
+	if !ok || l.Token != token {
+		return false
+	}
+	t.removeLocked(l)
+	return true
+}
+
+func (t *leaseTable) expire(port, token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Таймер мог сработать одновременно с Renew: аренда снимается, только если срок действительно истек.
+	if l, ok := t.leases[port]; ok && l.Token == token && !time.Now().Before(l.Expires) {
+		t.removeLocked(l)
+	}
+}
+
+func (t *leaseTable) removeLocked(l *lease) {
+	l.timer.Stop()
+	delete(t.leases, l.Port)
+	deviceLeased.WithLabelValues(l.Port).Set(0)
+}
+
+// Check сообщает, может ли клиент с токеном token настраивать устройство. Если нет,
+// возвращает аренду владельца и учитывает отказ в метриках.
+func (t *leaseTable) Check(port, token string) (lease, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l, ok := t.leases[port]
+	if !ok || l.Token == token {
+		return lease{}, true
+	}
+	leaseDenied.WithLabelValues(port).Inc()
+	return l.public(), false
+}
+
+// List возвращает действующие аренды, упорядоченные по порту.
+func (t *leaseTable) List() []lease {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]lease, 0, len(t.leases))
+	for _, l := range t.leases {
+		out = append(out, l.public())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Port < out[j].Port })
+	return out
+}
+
+// leasedError сообщает, что устройство арендовано другим клиентом.
+type leasedError struct {
+	holder lease
+}
+
+func (e *leasedError) Error() string {
+	return fmt.Sprintf("устройство %s арендовано клиентом %q до %s", e.holder.Port, e.holder.Owner,
+		e.holder.Expires.Format(time.RFC3339))
+}
+
+func deviceLeasedError(holder lease) *apiError {
+	return &apiError{Status: http.StatusLocked, Code: "device_leased",
+		Message: fmt.Sprintf("Устройство %s арендовано клиентом %q до %s", holder.Port, holder.Owner,
+			holder.Expires.Format(time.RFC3339))}
+}
+
+// requireLease проверяет, что клиент может настраивать устройство. Иначе отправляет
+// ошибку 423 и возвращает false.
+func requireLease(w http.ResponseWriter, r *http.Request, port string) bool {
+	holder, ok := leases.Check(port, r.Header.Get(leaseTokenHeader))
+	if !ok {
+		writeError(w, deviceLeasedError(holder))
+	}
+	return ok
+}
+
+// serveLastScan отдает клиенту, не владеющему арендой, последний скан устройства без нового
+// сканирования. Заголовки X-Read-Only и X-Lease-Owner сообщают, что данные получены владельцем.
+func serveLastScan(w http.ResponseWriter, vna *govna.VNA, port, format string, traces []govna.TraceSpec, holder lease) {
+	data, meta, ok := vna.LastScan()
+	if !ok {
+		writeError(w, deviceLeasedError(holder))
+		return
+	}
+	meta.Port = port
+	w.Header().Set("X-Read-Only", "true")
+	w.Header().Set("X-Lease-Owner", holder.Owner)
+	w.Header().Set("X-Scan-Sequence", strconv.FormatUint(meta.Sequence, 10))
+	writeScan(w, format, &data, meta, govna.QualityReport{}, traces)
+}
+
+// leaseTTL разбирает срок аренды в секундах; 0 означает defaultLeaseTTL.
+func leaseTTL(seconds float64) (time.Duration, *apiError) {
+	if seconds == 0 {
+		return defaultLeaseTTL, nil
+	}
+	ttl := time.Duration(seconds * float64(time.Second))
+	if ttl < time.Second || ttl > maxLeaseTTL {
+		return 0, &apiError{Status: http.StatusBadRequest, Code: "out_of_range", Field: "ttl",
+			Message: fmt.Sprintf("Срок аренды должен быть от 1 до %.0f секунд", maxLeaseTTL.Seconds())}
+	}
+	return ttl, nil
+}
+
+// leasesHandler управляет арендой устройств:
+//
+//	GET    /api/v1/leases                     - действующие аренды (без токенов)
+//	POST   /api/v1/leases {"port", "owner", "ttl"} - получить аренду; ответ содержит token
+//	DELETE /api/v1/leases?port=               - освободить устройство (заголовок X-Lease-Token)
+//
+// Получить аренду устройства, арендованного другим клиентом, нельзя (409) до ее освобождения
+// или истечения срока.
+func leasesHandler(pool *govna.VNAPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, leases.List())
+		case http.MethodPost:
+			var request struct {
+				Port  string  `json:"port"`
+				Owner string  `json:"owner"`
+				TTL   float64 `json:"ttl"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				writeError(w, invalidBody(err))
+				return
+			}
+			if request.Port == "" {
+				writeError(w, missingParameter("port"))
+				return
+			}
+			if request.Owner == "" {
+				writeError(w, missingParameter("owner"))
+				return
+			}
+			ttl, apiErr := leaseTTL(request.TTL)
+			if apiErr != nil {
+				writeError(w, apiErr)
+				return
+			}
+			if _, err := pool.Get(request.Port); err != nil {
+				writeError(w, deviceError(err))
+				return
+			}
+			l, ok := leases.Acquire(request.Port, request.Owner, ttl)
+			if !ok {
+				apiErr := deviceLeasedError(l)
+				apiErr.Status = http.StatusConflict
+				writeError(w, apiErr)
+				return
+			}
+			writeJSON(w, http.StatusCreated, l)
+		case http.MethodDelete:
+			port := r.URL.Query().Get("port")
+			if port == "" {
+				writeError(w, missingParameter("port"))
+				return
+			}
+			if !leases.Release(port, r.Header.Get(leaseTokenHeader)) {
+				writeError(w, &apiError{Status: http.StatusForbidden, Code: "not_lease_owner",
+					Message: fmt.Sprintf("Аренда устройства %s не найдена или принадлежит другому клиенту", port)})
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, methodNotAllowed(http.MethodGet, http.MethodPost, http.MethodDelete))
+		}
+	}
+}
+
+// leaseRenewHandler продлевает аренду: POST /api/v1/leases/renew?port=[&ttl=секунды]
+// с заголовком X-Lease-Token.
+func leaseRenewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, methodNotAllowed(http.MethodPost))
+		return
+	}
+	query := r.URL.Query()
+	port := query.Get("port")
+	if port == "" {
+		writeError(w, missingParameter("port"))
+		return
+	}
+	seconds, apiErr := floatParameter(query, "ttl", 0)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	ttl, apiErr := leaseTTL(seconds)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	l, ok := leases.Renew(port, r.Header.Get(leaseTokenHeader), ttl)
+	if !ok {
+		writeError(w, &apiError{Status: http.StatusForbidden, Code: "not_lease_owner",
+			Message: fmt.Sprintf("Аренда устройства %s не найдена или принадлежит другому клиенту", port)})
+		return
+	}
+	writeJSON(w, http.StatusOK, l)
+}
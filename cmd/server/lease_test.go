@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/momentics/govna/pkg/govna"
+)
+
+// acquireLease арендует устройство через API и возвращает аренду с токеном.
+func acquireLease(t *testing.T, pool *govna.VNAPool, port, owner string) lease {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	body := `{"port":"` + port + `","owner":"` + owner + `","ttl":60}`
+	leasesHandler(pool)(rec, httptest.NewRequest(http.MethodPost, "/api/v1/leases", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var l lease
+	if err := json.NewDecoder(rec.Body).Decode(&l); err != nil {
+		t.Fatalf("decode lease: %v", err)
+	}
+	t.Cleanup(func() { leases.Release(port, l.Token) })
+	return l
+}
+
+func TestLeases_AcquireReleaseConflict(t *testing.T) {
+	const port = "/dev/test-lease-api"
+	pool, _ := newTestDevice(t, port)
+	holder := acquireLease(t, pool, port, "bench")
+	if holder.Token == "" {
+		t.Fatalf("lease token not returned")
+	}
+
+	rec := httptest.NewRecorder()
+	body := `{"port":"` + port + `","owner":"intruder"}`
+	leasesHandler(pool)(rec, httptest.NewRequest(http.MethodPost, "/api/v1/leases", strings.NewReader(body)))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("second lease: expected 409, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	leasesHandler(pool)(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/leases?port="+port, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("release without token: expected 403, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/leases?port="+port, nil)
+	req.Header.Set(leaseTokenHeader, holder.Token)
+	leasesHandler(pool)(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("release by owner: expected 204, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestScanHandler_LeaseEnforcement(t *testing.T) {
+	const port = "/dev/test-lease-scan"
+	pool, driver := newTestDevice(t, port)
+	holder := acquireLease(t, pool, port, "bench")
+	scan := func(token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/scan?port="+port+"&points=11", nil)
+		if token != "" {
+			req.Header.Set(leaseTokenHeader, token)
+		}
+		scanHandler(pool)(rec, req)
+		return rec
+	}
+
+	// Сканов еще не было: другому клиенту нечего отдать.
+	if rec := scan(""); rec.Code != http.StatusLocked {
+		t.Fatalf("expected 423 before first scan, got %d: %s", rec.Code, rec.Body)
+	} else if body := decodeAPIError(t, rec); body.Code != "device_leased" {
+		t.Fatalf("expected device_leased, got %q", body.Code)
+	}
+
+	rec := scan(holder.Token)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Read-Only") != "" {
+		t.Fatalf("owner scan: expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	sequence := rec.Header().Get("X-Scan-Sequence")
+
+	// Остальные клиенты получают скан владельца без нового сканирования.
+	rec = scan("wrong-token")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("read-only scan: expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("X-Read-Only") != "true" || rec.Header().Get("X-Lease-Owner") != "bench" ||
+		rec.Header().Get("X-Scan-Sequence") != sequence {
+		t.Fatalf("unexpected read-only headers %v", rec.Header())
+	}
+	if driver.Scans() != 1 {
+		t.Fatalf("expected only the owner's scan, got %d scans", driver.Scans())
+	}
+}
+
+func TestHandlers_RequireLease(t *testing.T) {
+	const port = "/dev/test-lease-handlers"
+	pool, driver := newTestDevice(t, port)
+	acquireLease(t, pool, port, "bench")
+
+	requests := []struct {
+		name    string
+		handler http.HandlerFunc
+		request *http.Request
+	}{
+		{"quality", qualityHandler(pool), httptest.NewRequest(http.MethodGet, "/api/v1/quality?port="+port, nil)},
+		{"limits", limitTestHandler(pool), httptest.NewRequest(http.MethodPost, "/api/v1/limits?port="+port, strings.NewReader("[]"))},
+		{"calibration session", calibrationSessionsHandler(pool), httptest.NewRequest(http.MethodPost, "/api/v1/calibration/sessions",
+			strings.NewReader(`{"port":"`+port+`","name":"cal"}`))},
+		{"stream", streamHandler(pool), httptest.NewRequest(http.MethodGet, "/api/v1/stream?port="+port, nil)},
+	}
+	for _, request := range requests {
+		rec := httptest.NewRecorder()
+		request.handler(rec, request.request)
+		if rec.Code != http.StatusLocked {
+			t.Fatalf("%s: expected 423, got %d: %s", request.name, rec.Code, rec.Body)
+		}
+		if body := decodeAPIError(t, rec); body.Code != "device_leased" || !strings.Contains(body.Message, "bench") {
+			t.Fatalf("%s: unexpected error %+v", request.name, body)
+		}
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("leased device must not be scanned, got %d scans", driver.Scans())
+	}
+}
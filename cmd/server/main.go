@@ -2,12 +2,21 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -24,21 +33,92 @@ var (
 		},
 		[]string{"port"},
 	)
+	limitTests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "govna_limit_tests_total",
+			Help: "Number of limit-line tests by result",
+		},
+		[]string{"port", "result"},
+	)
+	qualityChecks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "govna_data_quality_checks_total",
+			Help: "Number of data-quality checks by check and result",
+		},
+		[]string{"port", "check", "result"},
+	)
 )
 
+// defaultSweep - параметры сканирования, используемые обработчиками HTTP, если запрос их не задает.
+var defaultSweep = govna.SweepConfig{Start: 1e6, Stop: 900e6, Points: 101}
+
+// apiError - ошибка API в формате JSON. Field указывает параметр запроса, вызвавший ошибку.
+type apiError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, err *apiError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(err)
+}
+
+func missingParameter(field string) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Code: "missing_parameter", Field: field,
+		Message: fmt.Sprintf("Параметр '%s' обязателен", field)}
+}
+
+func invalidParameter(field, message string) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Code: "invalid_parameter", Field: field, Message: message}
+}
+
+func deviceError(err error) *apiError {
+	return &apiError{Status: http.StatusInternalServerError, Code: "device_error", Message: fmt.Sprintf("Ошибка устройства: %v", err)}
+}
+
+func scanError(err error) *apiError {
+	return &apiError{Status: http.StatusInternalServerError, Code: "scan_error", Message: fmt.Sprintf("Ошибка сканирования: %v", err)}
+}
+
 func init() {
-	prometheus.MustRegister(scanDuration)
+	prometheus.MustRegister(scanDuration, limitTests, qualityChecks)
 }
 
 func main() {
 	pool := govna.NewVNAPool()
 	defer pool.CloseAll()
 
+	calibrationDir := os.Getenv(calibrationDirEnv)
+	if calibrationDir == "" {
+		calibrationDir = defaultCalibrationDir
+	}
+	loaded, err := calibrations.Load(calibrationDir)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки калибровок: %v", err)
+	}
+	log.Printf("Загружено калибровочных профилей из %s: %d", calibrationDir, loaded)
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/v1/scan", scanHandler(pool))
+	mux.HandleFunc("/api/v1/limits", limitTestHandler(pool))
+	mux.HandleFunc("/api/v1/quality", qualityHandler(pool))
+	mux.HandleFunc("/api/v1/stream", streamHandler(pool))
+	mux.HandleFunc("/api/v1/events", eventsHandler(pool))
+	mux.HandleFunc("/api/v1/calibration/sessions", calibrationSessionsHandler(pool))
+	mux.HandleFunc("/api/v1/calibration/sessions/", calibrationSessionHandler(pool))
+	mux.HandleFunc("/api/v1/calibrations", calibrationProfilesHandler(pool))
+	mux.HandleFunc("/api/v1/calibrations/", calibrationProfileHandler(pool))
+	mux.HandleFunc("/api/v1/leases", leasesHandler(pool))
+	mux.HandleFunc("/api/v1/leases/renew", leaseRenewHandler)
 	mux.Handle("/metrics", promhttp.Handler())
 
-	server := &http.Server{Addr: ":8080", Handler: mux}
+	// Контекст запросов отменяется при остановке, чтобы завершить долгие потоки SSE.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	server := &http.Server{Addr: ":8080", Handler: mux, BaseContext: func(net.Listener) context.Context { return baseCtx }}
+	server.RegisterOnShutdown(cancelRequests)
 
 	go func() {
 		log.Println("Сервер запущен на http://localhost:8080")
@@ -47,6 +127,30 @@ func main() {
 		}
 	}()
 
+	grpcServer := newGRPCServer(pool)
+	grpcListener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatalf("Ошибка gRPC сервера: %v", err)
+	}
+	go func() {
+		log.Printf("gRPC сервер запущен на %s", grpcAddr)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatalf("Ошибка gRPC сервера: %v", err)
+		}
+	}()
+
+	scpiServer := newSCPIServer(pool)
+	scpiListener, err := net.Listen("tcp", scpiAddr)
+	if err != nil {
+		log.Fatalf("Ошибка SCPI сервера: %v", err)
+	}
+	go func() {
+		log.Printf("SCPI сервер запущен на %s", scpiAddr)
+		if err := scpiServer.Serve(scpiListener); err != nil {
+			log.Fatalf("Ошибка SCPI сервера: %v", err)
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -55,43 +159,736 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	scpiServer.Close()
+	// GracefulStop ждет завершения потоков сканирования, поэтому ограничен тем же таймаутом.
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Ошибка при корректном завершении сервера: %v", err)
 	}
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
 	log.Println("Сервер успешно остановлен.")
 }
 
+// scanHandler выполняет сканирование и возвращает данные в формате, выбранном negotiateFormat.
+// Параметры сканирования описаны в parseScanRequest, параметры обработки - в parseProcessing.
+// Клиент, не владеющий арендой устройства, получает последний скан только для чтения.
+// Сканирование ставится в очередь устройства с приоритетом priority=low|normal|high; при
+// перегрузке возвращается 429 (лимит клиента, см. X-Client-ID) или 503 с заголовком Retry-After.
 func scanHandler(pool *govna.VNAPool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		port := query.Get("port")
+		if port == "" {
+			writeError(w, missingParameter("port"))
+			return
+		}
+		format, apiErr := negotiateFormat(r)
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		traces, apiErr := parseTraces(query)
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		priority, apiErr := parsePriority(query)
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+
+		vna, err := pool.Get(port)
+		if err != nil {
+			writeError(w, deviceError(err))
+			return
+		}
+		if holder, ok := leases.Check(port, r.Header.Get(leaseTokenHeader)); !ok {
+			serveLastScan(w, vna, port, format, traces, holder)
+			return
+		}
+
+		request, apiErr := parseScanRequest(vna, query)
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		if apiErr := parseProcessing(&request, query, port, clientID(r)); apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+
+		var data govna.VNAData
+		var meta govna.ScanMetadata
+		var duration time.Duration
+		if !scheduleHTTP(w, r, port, priority, func() {
+			start := time.Now()
+			data, meta, err = vna.Measure(r.Context(), request.MeasureRequest)
+			duration = time.Since(start)
+		}) {
+			return
+		}
+		if err != nil {
+			writeError(w, scanError(err))
+			return
+		}
+		scanDuration.WithLabelValues(port).Observe(duration.Seconds())
+		meta.Port = port
+
+		if request.StoreMemory {
+			memories.Put(port, clientID(r), data)
+		}
+
+		report, qualityErr := checkQuality(port, &data)
+		w.Header().Set("X-Data-Quality", qualityStatus(report, qualityErr))
+		w.Header().Set("X-Scan-Sequence", strconv.FormatUint(meta.Sequence, 10))
+		if request.Averaging != nil {
+			w.Header().Set("X-Averaged-Sweeps", strconv.Itoa(request.Averaging.Sweeps()))
+		}
+		writeScan(w, format, &data, meta, report, traces)
+	}
+}
+
+// Форматы ответа сканирования.
+const (
+	formatTouchstone = "touchstone"
+	formatJSON       = "json"
+	formatCSV        = "csv"
+	formatBinary     = "binary"
+)
+
+var formatContentTypes = map[string]string{
+	formatTouchstone: "text/plain; charset=utf-8",
+	formatJSON:       "application/json",
+	formatCSV:        "text/csv; charset=utf-8",
+	formatBinary:     "application/octet-stream",
+}
+
+// formatOrder - порядок предпочтения форматов при равных весах типов в заголовке Accept.
+var formatOrder = []string{formatTouchstone, formatJSON, formatCSV, formatBinary}
+
+// acceptFormats сопоставляет типы из заголовка Accept форматам ответа. Диапазоны вида type/*
+// сопоставляются по типу Content-Type формата, */* - любому формату.
+var acceptFormats = map[string]string{
+	"text/plain":               formatTouchstone,
+	"application/x-touchstone": formatTouchstone,
+	"application/json":         formatJSON,
+	"text/csv":                 formatCSV,
+	"application/octet-stream": formatBinary,
+}
+
+// negotiateFormat выбирает формат ответа: параметр format имеет приоритет над заголовком Accept.
+// Из заголовка Accept выбирается формат с наибольшим весом q; вес формата задает самый точный
+// подходящий ему диапазон (тип, затем type/*, затем */*), q=0 исключает формат. При равных
+// весах выбирается формат, указанный в заголовке раньше, затем - в порядке formatOrder.
+// Без заголовка возвращается Touchstone.
+func negotiateFormat(r *http.Request) (string, *apiError) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, ok := formatContentTypes[format]; !ok {
+			return "", &apiError{Status: http.StatusBadRequest, Code: "unsupported_value", Field: "format",
+				Message: fmt.Sprintf("Формат %q не поддерживается; допустимы touchstone, json, csv, binary", format)}
+		}
+		return format, nil
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return formatTouchstone, nil
+	}
+	// Для каждого формата - вес и позиция самого точного подходящего диапазона.
+	type match struct {
+		specificity, index int
+		q                  float64
+	}
+	matches := make(map[string]match)
+	for index, element := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(element, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		q, ok := parseQuality(params)
+		if !ok {
+			continue
+		}
+		for _, format := range formatOrder {
+			specificity := 0
+			switch {
+			case acceptFormats[mediaType] == format:
+				specificity = 3
+			case strings.HasSuffix(mediaType, "/*") && mediaType != "*/*" &&
+				strings.HasPrefix(formatContentTypes[format], strings.TrimSuffix(mediaType, "*")):
+				specificity = 2
+			case mediaType == "*/*":
+				specificity = 1
+			default:
+				continue
+			}
+			current, found := matches[format]
+			if !found || specificity > current.specificity || (specificity == current.specificity && q > current.q) {
+				matches[format] = match{specificity: specificity, index: index, q: q}
+			}
+		}
+	}
+	best, bestMatch := "", match{}
+	for _, format := range formatOrder {
+		m, ok := matches[format]
+		if !ok || m.q <= 0 {
+			continue
+		}
+		if best == "" || m.q > bestMatch.q || (m.q == bestMatch.q && m.index < bestMatch.index) {
+			best, bestMatch = format, m
+		}
+	}
+	if best == "" {
+		return "", &apiError{Status: http.StatusNotAcceptable, Code: "not_acceptable",
+			Message: fmt.Sprintf("Ни один из типов %q не поддерживается", accept)}
+	}
+	return best, nil
+}
+
+// parseQuality возвращает вес q из параметров элемента заголовка Accept (по умолчанию 1).
+// false означает некорректный вес: такой элемент игнорируется.
+func parseQuality(params string) (float64, bool) {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || !(q >= 0 && q <= 1) {
+			return 0, false
+		}
+		return q, true
+	}
+	return 1, true
+}
+
+// parseTraces разбирает параметр traces вида "S11:logmag,S21:gdelay:4" для форматов JSON и CSV
+// (третье поле - апертура группового времени запаздывания) и проверяет трассы до сканирования.
+func parseTraces(query url.Values) ([]govna.TraceSpec, *apiError) {
+	value := query.Get("traces")
+	if value == "" {
+		return nil, nil
+	}
+	var traces []govna.TraceSpec
+	for _, item := range strings.Split(value, ",") {
+		fields := strings.Split(strings.TrimSpace(item), ":")
+		if len(fields) < 2 || len(fields) > 3 || fields[0] == "" || fields[1] == "" {
+			return nil, invalidParameter("traces", fmt.Sprintf("Некорректная трасса %q: ожидается параметр:формат[:апертура]", item))
+		}
+		spec := govna.TraceSpec{
+			Parameter: govna.SParameter(strings.ToUpper(fields[0])),
+			Format:    govna.TraceFormat(fields[1]),
+		}
+		if len(fields) == 3 {
+			aperture, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, invalidParameter("traces", fmt.Sprintf("Некорректная апертура трассы %q", item))
+			}
+			spec.Aperture = aperture
+		}
+		if err := spec.Validate(); err != nil {
+			return nil, invalidParameter("traces", fmt.Sprintf("Некорректная трасса %q: %v", item, err))
+		}
+		traces = append(traces, spec)
+	}
+	return traces, nil
+}
+
+// encodingError сообщает об ошибке формирования ответа по уже полученным данным.
+func encodingError(err error) *apiError {
+	return &apiError{Status: http.StatusInternalServerError, Code: "encoding_error",
+		Message: fmt.Sprintf("Ошибка формирования ответа: %v", err)}
+}
+
+// writeScan кодирует данные в выбранном формате и записывает ответ. Ответ кодируется до отправки
+// заголовков, поэтому ошибка кодирования возвращается клиенту кодом 500.
+func writeScan(w http.ResponseWriter, format string, data *govna.VNAData, meta govna.ScanMetadata,
+	report govna.QualityReport, traces []govna.TraceSpec) {
+	body, err := encodeScan(format, data, meta, qualityComments(report), traces)
+	if err != nil {
+		writeError(w, encodingError(err))
+		return
+	}
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	w.Write(body)
+}
+
+// encodeScan кодирует данные в выбранном формате. Для Touchstone метаданные и комментарии
+// (например, предупреждения о качестве данных) записываются в заголовок файла.
+func encodeScan(format string, data *govna.VNAData, meta govna.ScanMetadata, comments string, traces []govna.TraceSpec) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case formatJSON:
+		err = data.WriteJSON(&buf, meta, traces...)
+	case formatCSV:
+		err = data.WriteCSV(&buf, meta, traces...)
+	case formatBinary:
+		err = data.WriteBinary(&buf, meta)
+	default:
+		var touchstone string
+		touchstone, err = data.ToTouchstone()
+		buf.WriteString(metadataComments(meta) + comments + touchstone)
+	}
+	return buf.Bytes(), err
+}
+
+// metadataComments формирует строки комментариев Touchstone с метаданными скана.
+func metadataComments(meta govna.ScanMetadata) string {
+	var sb strings.Builder
+	if meta.Device != "" {
+		sb.WriteString(fmt.Sprintf("! Device: %s (%s)\n", meta.Device, meta.Port))
+	}
+	if meta.Calibration != "" {
+		sb.WriteString(fmt.Sprintf("! Calibration: %s\n", meta.Calibration))
+	}
+	sb.WriteString(fmt.Sprintf("! Sequence: %d, sweep time: %.1f ms\n", meta.Sequence, float64(meta.Duration)/float64(time.Millisecond)))
+	return sb.String()
+}
+
+// scanRequest - проверенные параметры сканирования из строки запроса. Калибровка и обработка
+// относятся только к этому запросу и передаются в VNA.Measure, не меняя настроек устройства.
+type scanRequest struct {
+	govna.MeasureRequest
+	// StoreMemory - сохранить результат как трассу памяти клиента (memory=store).
+	StoreMemory bool
+}
+
+// parseScanRequest разбирает параметры start, stop, points, sweep (тип сетки из возможностей
+// устройства, сейчас только linear) и calibration (имя профиля или off; по умолчанию - профиль,
+// активированный на устройстве) и проверяет их по возможностям устройства. Не заданные
+// параметры сетки берутся из калибровочного профиля, а без него - из defaultSweep.
+func parseScanRequest(vna *govna.VNA, query url.Values) (scanRequest, *apiError) {
+	var request scanRequest
+	profile := vna.Calibration()
+	switch name := query.Get("calibration"); name {
+	case "":
+	case "off", "none":
+		profile = nil
+	default:
+		profile = calibrations.Get(query.Get("port"), name)
+		if profile == nil {
+			return scanRequest{}, &apiError{Status: http.StatusNotFound, Code: "calibration_not_found",
+				Field: "calibration", Message: fmt.Sprintf("Калибровочный профиль %q не найден", name)}
+		}
+	}
+	request.Calibration = profile
+
+	request.Sweep = defaultSweep
+	if profile != nil {
+		request.Sweep = profile.Sweep
+	}
+	var apiErr *apiError
+	if request.Sweep.Start, apiErr = floatParameter(query, "start", request.Sweep.Start); apiErr != nil {
+		return scanRequest{}, apiErr
+	}
+	if request.Sweep.Stop, apiErr = floatParameter(query, "stop", request.Sweep.Stop); apiErr != nil {
+		return scanRequest{}, apiErr
+	}
+	if value := query.Get("points"); value != "" {
+		points, err := strconv.Atoi(value)
+		if err != nil {
+			return scanRequest{}, invalidParameter("points", "Параметр 'points' должен быть целым числом")
+		}
+		request.Sweep.Points = points
+	}
+	if value := query.Get("sweep"); value != "" {
+		request.Sweep.Type = govna.SweepType(value)
+	}
+
+	if err := vna.Capabilities().ValidateSweep(request.Sweep); err != nil {
+		return scanRequest{}, sweepError(err)
+	}
+	if profile != nil && !sameSweep(request.Sweep, profile.Sweep) {
+		return scanRequest{}, &apiError{Status: http.StatusConflict, Code: "calibration_mismatch", Field: "calibration",
+			Message: fmt.Sprintf("Параметры сканирования не совпадают с калибровкой %q; используйте calibration=off", profile.Name)}
+	}
+	return request, nil
+}
+
+// sweepError преобразует ошибку проверки параметров сканирования в ошибку API
+// с полем запроса, вызвавшим ошибку.
+func sweepError(err error) *apiError {
+	var sweepErr *govna.SweepError
+	if !errors.As(err, &sweepErr) {
+		return invalidParameter("", err.Error())
+	}
+	field, code := sweepErr.Field, "out_of_range"
+	if field == "type" {
+		field, code = "sweep", "unsupported_value"
+	}
+	return &apiError{Status: http.StatusBadRequest, Code: code, Field: field, Message: err.Error()}
+}
+
+func sameSweep(a, b govna.SweepConfig) bool {
+	if a.Type == "" {
+		a.Type = govna.SweepLinear
+	}
+	if b.Type == "" {
+		b.Type = govna.SweepLinear
+	}
+	return a == b
+}
+
+func floatParameter(query url.Values, name string, fallback float64) (float64, *apiError) {
+	value := query.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return 0, invalidParameter(name, fmt.Sprintf("Параметр '%s' должен быть числом", name))
+	}
+	return parsed, nil
+}
+
+// maxAverages - наибольшее число свипов, усредняемых в одном запросе или в среднем клиента.
+const maxAverages = 64
+
+// parseProcessing разбирает параметры обработки запроса: average=N (N свипов этого запроса
+// векторно усредняются), averaging=running|exponential|off (см. parseAveraging),
+// smooth=N (апертура сглаживания) и memory=store|div|sub|add|off.
+// Трасса памяти своя у каждого клиента (см. clientID): store сохраняет результат запроса,
+// div, sub и add применяют ранее сохраненную трассу.
+func parseProcessing(request *scanRequest, query url.Values, port, client string) *apiError {
+	averager, apiErr := parseAveraging(query, port, client)
+	if apiErr != nil {
+		return apiErr
+	}
+	if averager != nil {
+		request.Averaging = averager
+	} else if value := query.Get("average"); value != "" {
+		count, apiErr := parseAverageCount(value)
+		if apiErr != nil {
+			return apiErr
+		}
+		request.Averages = count
+	}
+
+	if value := query.Get("smooth"); value != "" {
+		aperture, err := strconv.Atoi(value)
+		if err != nil || aperture < 0 {
+			return invalidParameter("smooth", fmt.Sprintf("Некорректное значение smooth: %q", value))
+		}
+		request.Smoothing = aperture
+	}
+
+	switch value := govna.MemoryOperation(query.Get("memory")); value {
+	case "", govna.MemoryOff:
+	case "store":
+		request.StoreMemory = true
+	case govna.MemoryDivide, govna.MemorySubtract, govna.MemoryAdd:
+		request.Memory = memories.Get(port, client)
+		if request.Memory == nil {
+			return &apiError{Status: http.StatusConflict, Code: "memory_not_stored", Field: "memory",
+				Message: "Трасса памяти не сохранена; выполните сканирование с memory=store"}
+		}
+		request.MemoryMath = value
+	default:
+		return &apiError{Status: http.StatusBadRequest, Code: "unsupported_value", Field: "memory",
+			Message: fmt.Sprintf("Операция с памятью %q не поддерживается; допустимы store, div, sub, add, off", value)}
+	}
+	return nil
+}
+
+// parseAverageCount разбирает число усреднений из параметра average.
+func parseAverageCount(value string) (int, *apiError) {
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return 0, invalidParameter("average", fmt.Sprintf("Некорректное значение average: %q", value))
+	}
+	if count > maxAverages {
+		return 0, &apiError{Status: http.StatusBadRequest, Code: "out_of_range", Field: "average",
+			Message: fmt.Sprintf("Число усреднений не может превышать %d", maxAverages)}
+	}
+	return count, nil
+}
+
+// parseAveraging разбирает параметр averaging=running|exponential|off. С режимом running или
+// exponential параметр average=N задает число свипов в среднем клиента: среднее продолжается
+// от запроса к запросу и от кадра к кадру потока и сбрасывается при смене режима, числа
+// усреднений, сетки частот или калибровки. off удаляет накопленное среднее. Без параметра
+// averaging возвращается nil.
+func parseAveraging(query url.Values, port, client string) (*govna.Averager, *apiError) {
+	switch mode := govna.AveragingMode(query.Get("averaging")); mode {
+	case "":
+		return nil, nil
+	case "off":
+		averagers.Delete(port, client)
+		return nil, nil
+	case govna.AveragingRunning, govna.AveragingExponential:
+		value := query.Get("average")
+		if value == "" {
+			return nil, missingParameter("average")
+		}
+		count, apiErr := parseAverageCount(value)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		averager, err := averagers.Get(port, client, govna.AveragingConfig{Mode: mode, Count: count})
+		if err != nil {
+			return nil, invalidParameter("average", err.Error())
+		}
+		return averager, nil
+	default:
+		return nil, &apiError{Status: http.StatusBadRequest, Code: "unsupported_value", Field: "averaging",
+			Message: fmt.Sprintf("Режим усреднения %q не поддерживается; допустимы running, exponential, off", mode)}
+	}
+}
+
+// averagers - средние клиентов по устройствам.
+var averagers = &averagerStore{averagers: make(map[memoryKey]*govna.Averager)}
+
+type averagerStore struct {
+	mu        sync.Mutex
+	averagers map[memoryKey]*govna.Averager
+}
+
+// Get возвращает среднее клиента, заменяя его новым, если изменились режим или число усреднений.
+func (s *averagerStore) Get(port, client string, config govna.AveragingConfig) (*govna.Averager, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := memoryKey{port, client}
+	if averager := s.averagers[key]; averager != nil && averager.Config() == config {
+		return averager, nil
+	}
+	averager, err := govna.NewAverager(config)
+	if err != nil {
+		return nil, err
+	}
+	s.averagers[key] = averager
+	return averager, nil
+}
+
+func (s *averagerStore) Delete(port, client string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.averagers, memoryKey{port, client})
+}
+
+// memories - трассы памяти клиентов по устройствам.
+var memories = &memoryStore{traces: make(map[memoryKey]*govna.VNAData)}
+
+type memoryKey struct {
+	port, client string
+}
+
+type memoryStore struct {
+	mu     sync.Mutex
+	traces map[memoryKey]*govna.VNAData
+}
+
+func (s *memoryStore) Get(port, client string) *govna.VNAData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.traces[memoryKey{port, client}]
+}
+
+func (s *memoryStore) Put(port, client string, data govna.VNAData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.traces[memoryKey{port, client}] = &data
+}
+
+// limitTestHandler выполняет сканирование и проверяет его по маскам из тела запроса.
+// Маски передаются в JSON (по умолчанию) или в CSV (Content-Type: text/csv) с параметрами
+// name, parameter и format в строке запроса.
+func limitTestHandler(pool *govna.VNAPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, &apiError{Status: http.StatusMethodNotAllowed, Code: "method_not_allowed", Message: "Поддерживается только метод POST"})
+			return
+		}
 		port := r.URL.Query().Get("port")
 		if port == "" {
-			http.Error(w, "Параметр 'port' обязателен", http.StatusBadRequest)
+			writeError(w, missingParameter("port"))
+			return
+		}
+		if !requireLease(w, r, port) {
+			return
+		}
+		priority, apiErr := parsePriority(r.URL.Query())
+		if apiErr != nil {
+			writeError(w, apiErr)
 			return
 		}
 
+		var masks []govna.LimitMask
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+			trace := govna.TraceSpec{
+				Parameter: govna.SParameter(r.URL.Query().Get("parameter")),
+				Format:    govna.TraceFormat(r.URL.Query().Get("format")),
+			}
+			mask, err := govna.LoadLimitMaskCSV(r.Body, r.URL.Query().Get("name"), trace)
+			if err != nil {
+				writeError(w, &apiError{Status: http.StatusBadRequest, Code: "invalid_mask", Message: fmt.Sprintf("Ошибка маски: %v", err)})
+				return
+			}
+			masks = []govna.LimitMask{mask}
+		} else {
+			var err error
+			masks, err = govna.LoadLimitMasksJSON(r.Body)
+			if err != nil {
+				writeError(w, &apiError{Status: http.StatusBadRequest, Code: "invalid_mask", Message: fmt.Sprintf("Ошибка маски: %v", err)})
+				return
+			}
+		}
+
 		vna, err := pool.Get(port)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Ошибка устройства: %v", err), http.StatusInternalServerError)
+			writeError(w, deviceError(err))
+			return
+		}
+		request, apiErr := parseScanRequest(vna, r.URL.Query())
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		var data govna.VNAData
+		if !scheduleHTTP(w, r, port, priority, func() { data, _, err = vna.Measure(r.Context(), request.MeasureRequest) }) {
+			return
+		}
+		if err != nil {
+			writeError(w, scanError(err))
+			return
+		}
+
+		result, err := vna.CheckLimits(&data, masks...)
+		if err != nil {
+			writeError(w, &apiError{Status: http.StatusBadRequest, Code: "invalid_mask", Message: fmt.Sprintf("Ошибка проверки масок: %v", err)})
+			return
+		}
+		outcome := "pass"
+		if !result.Pass {
+			outcome = "fail"
+		}
+		limitTests.WithLabelValues(port, outcome).Inc()
+
+		response := struct {
+			govna.LimitResult
+			Quality *govna.QualityReport `json:"quality,omitempty"`
+		}{LimitResult: result}
+		report, qualityErr := checkQuality(port, &data)
+		if qualityErr == nil {
+			response.Quality = &report
+		}
+		w.Header().Set("X-Data-Quality", qualityStatus(report, qualityErr))
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// qualityHandler выполняет сканирование и возвращает полный отчет о достоверности данных.
+func qualityHandler(pool *govna.VNAPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		port := r.URL.Query().Get("port")
+		if port == "" {
+			writeError(w, missingParameter("port"))
+			return
+		}
+		if !requireLease(w, r, port) {
+			return
+		}
+		priority, apiErr := parsePriority(r.URL.Query())
+		if apiErr != nil {
+			writeError(w, apiErr)
 			return
 		}
 
-		// Здесь можно парсить параметры из запроса
-		sweepCfg := govna.SweepConfig{Start: 1e6, Stop: 900e6, Points: 101}
-		if err := vna.SetSweep(sweepCfg); err != nil {
-			http.Error(w, fmt.Sprintf("Ошибка установки параметров: %v", err), http.StatusInternalServerError)
+		vna, err := pool.Get(port)
+		if err != nil {
+			writeError(w, deviceError(err))
+			return
+		}
+		request, apiErr := parseScanRequest(vna, r.URL.Query())
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		var data govna.VNAData
+		if !scheduleHTTP(w, r, port, priority, func() { data, _, err = vna.Measure(r.Context(), request.MeasureRequest) }) {
+			return
+		}
+		if err != nil {
+			writeError(w, scanError(err))
 			return
 		}
 
-		start := time.Now()
-		data, err := vna.GetData()
+		report, err := checkQuality(port, &data)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Ошибка сканирования: %v", err), http.StatusInternalServerError)
+			writeError(w, &apiError{Status: http.StatusUnprocessableEntity, Code: "quality_check_failed",
+				Message: fmt.Sprintf("Проверка качества данных не выполнена: %v", err)})
 			return
 		}
-		duration := time.Since(start).Seconds()
-		scanDuration.WithLabelValues(port).Observe(duration)
+		w.Header().Set("X-Data-Quality", qualityStatus(report, nil))
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// checkQuality проверяет достоверность данных и учитывает результаты в метриках.
+// Ошибка означает, что данные не проверены, и такой отчет нельзя считать пройденным.
+func checkQuality(port string, data *govna.VNAData) (govna.QualityReport, error) {
+	report, err := data.CheckQuality(govna.QualityConfig{})
+	if err != nil {
+		log.Printf("Ошибка проверки качества данных (%s): %v", port, err)
+		return govna.QualityReport{}, err
+	}
+	for _, check := range report.Checks {
+		result := "pass"
+		switch {
+		case check.Skipped:
+			result = "skipped"
+		case !check.Pass:
+			result = "fail"
+		}
+		qualityChecks.WithLabelValues(port, string(check.Check), result).Inc()
+	}
+	return report, nil
+}
 
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		w.Write([]byte(data.ToTouchstone()))
+// qualityStatus возвращает значение заголовка X-Data-Quality: "pass", список непройденных проверок
+// или "unchecked", если проверка не выполнялась (err - ошибка checkQuality).
+func qualityStatus(report govna.QualityReport, err error) string {
+	if err != nil {
+		return "unchecked"
+	}
+	var failed []string
+	for _, check := range report.Checks {
+		if !check.Pass {
+			failed = append(failed, string(check.Check))
+		}
+	}
+	if len(failed) == 0 {
+		return "pass"
+	}
+	return "fail; " + strings.Join(failed, ",")
+}
+
+// qualityComments формирует строки комментариев Touchstone с предупреждениями о качестве данных.
+func qualityComments(report govna.QualityReport) string {
+	var sb strings.Builder
+	for _, check := range report.Checks {
+		if check.Pass || len(check.Issues) == 0 {
+			continue
+		}
+		worst := check.Issues[0]
+		for _, issue := range check.Issues {
+			if issue.Value > worst.Value {
+				worst = issue
+			}
+		}
+		if check.Check == govna.CheckCausality {
+			sb.WriteString(fmt.Sprintf("! Data quality warning: %s check failed for %s (%.1f dB at t = %.3f ns)\n",
+				check.Check, worst.Parameter, 20*math.Log10(worst.Value), worst.Time*1e9))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("! Data quality warning: %s check failed at %d point(s) (worst %.4g at %.6g MHz)\n",
+			check.Check, len(check.Issues), worst.Value, worst.Frequency/1e6))
 	}
+	return sb.String()
 }
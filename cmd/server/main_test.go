@@ -0,0 +1,179 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/momentics/govna/pkg/govna"
+)
+
+func TestQualityHandler_EncodingError(t *testing.T) {
+	const port = "/dev/test-quality-nan"
+	pool, driver := newTestDevice(t, port)
+	driver.SetReflection(complex(math.NaN(), 0))
+
+	rec := httptest.NewRecorder()
+	qualityHandler(pool)(rec, httptest.NewRequest(http.MethodGet, "/api/v1/quality?port="+port+"&points=11", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 for a report with NaN values, got %d: %s", rec.Code, rec.Body)
+	}
+	if body := decodeAPIError(t, rec); body.Code != "encoding_error" {
+		t.Fatalf("expected encoding_error, got %q", body.Code)
+	}
+}
+
+func TestCheckQuality_Unchecked(t *testing.T) {
+	report, err := checkQuality("/dev/test-quality-empty", &govna.VNAData{})
+	if err == nil {
+		t.Fatalf("expected error for empty data")
+	}
+	if status := qualityStatus(report, err); status != "unchecked" {
+		t.Fatalf("expected unchecked status, got %q", status)
+	}
+	if status := qualityStatus(govna.QualityReport{Pass: true}, nil); status != "pass" {
+		t.Fatalf("expected pass status, got %q", status)
+	}
+}
+
+func TestScanHandler_SweepType(t *testing.T) {
+	const port = "/dev/test-scan-sweep"
+	pool, driver := newTestDevice(t, port)
+	scan := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		scanHandler(pool)(rec, httptest.NewRequest(http.MethodGet, "/api/v1/scan?port="+port+"&points=11"+query, nil))
+		return rec
+	}
+
+	rec := scan("&sweep=log")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unsupported sweep type, got %d: %s", rec.Code, rec.Body)
+	}
+	if body := decodeAPIError(t, rec); body.Code != "unsupported_value" || body.Field != "sweep" {
+		t.Fatalf("unexpected error %+v", body)
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("rejected request must not scan, got %d scans", driver.Scans())
+	}
+
+	if rec := scan("&sweep=linear"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a linear sweep, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestScanHandler_Traces(t *testing.T) {
+	const port = "/dev/test-scan-traces"
+	pool, driver := newTestDevice(t, port)
+	scan := func(traces string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/scan?port="+port+"&points=11&traces="+traces, nil)
+		req.Header.Set("Accept", "application/json")
+		scanHandler(pool)(rec, req)
+		return rec
+	}
+
+	for _, traces := range []string{"S31:logmag", "S11:bogus", "S21:gdelay:-1", "S11:gdelay:x", "S11"} {
+		rec := scan(traces)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", traces, rec.Code, rec.Body)
+		}
+		if body := decodeAPIError(t, rec); body.Code != "invalid_parameter" || body.Field != "traces" {
+			t.Fatalf("%s: unexpected error %+v", traces, body)
+		}
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("invalid traces must be rejected before scanning, got %d scans", driver.Scans())
+	}
+
+	if rec := scan("S11:logmag,S11:gdelay:4"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for valid traces, got %d: %s", rec.Code, rec.Body)
+	}
+	// S21 отсутствует в данных однопортового устройства: это ошибка формирования ответа, а не запроса.
+	rec := scan("S21:logmag")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 for a trace missing in the data, got %d: %s", rec.Code, rec.Body)
+	}
+	if body := decodeAPIError(t, rec); body.Code != "encoding_error" {
+		t.Fatalf("expected encoding_error, got %q", body.Code)
+	}
+}
+
+func TestScanHandler_ClientAveraging(t *testing.T) {
+	const port = "/dev/test-scan-averaging"
+	pool, driver := newTestDevice(t, port)
+	t.Cleanup(func() { averagers.Delete(port, "avg-client") })
+	scan := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/scan?port="+port+"&points=11"+query, nil)
+		req.Header.Set(clientIDHeader, "avg-client")
+		scanHandler(pool)(rec, req)
+		return rec
+	}
+
+	for _, query := range []string{"&averaging=running", "&averaging=bogus&average=4", "&averaging=exponential&average=x"} {
+		if rec := scan(query); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", query, rec.Code, rec.Body)
+		}
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("invalid averaging must be rejected before scanning, got %d scans", driver.Scans())
+	}
+
+	// Среднее клиента продолжается от запроса к запросу.
+	for i, want := range []string{"1", "2", "3"} {
+		rec := scan("&averaging=running&average=4")
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Averaged-Sweeps") != want {
+			t.Fatalf("request %d: expected %s averaged sweeps, got %q (%d: %s)",
+				i, want, rec.Header().Get("X-Averaged-Sweeps"), rec.Code, rec.Body)
+		}
+	}
+	// Смена числа усреднений начинает среднее заново.
+	if rec := scan("&averaging=running&average=2"); rec.Header().Get("X-Averaged-Sweeps") != "1" {
+		t.Fatalf("expected the average to restart, got %q", rec.Header().Get("X-Averaged-Sweeps"))
+	}
+	// Без averaging запрос не усредняется, а off удаляет среднее клиента.
+	if rec := scan(""); rec.Code != http.StatusOK || rec.Header().Get("X-Averaged-Sweeps") != "" {
+		t.Fatalf("expected an unaveraged scan, got %d with %q", rec.Code, rec.Header().Get("X-Averaged-Sweeps"))
+	}
+	if rec := scan("&averaging=off"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for averaging=off, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := scan("&averaging=running&average=2"); rec.Header().Get("X-Averaged-Sweeps") != "1" {
+		t.Fatalf("expected off to drop the client average, got %q", rec.Header().Get("X-Averaged-Sweeps"))
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		accept, format string
+	}{
+		{"", formatTouchstone},
+		{"*/*", formatTouchstone},
+		{"application/*", formatJSON},
+		{"text/*;q=0.5, application/octet-stream", formatBinary},
+		{"application/json;q=0.2, text/csv;q=0.8", formatCSV},
+		{"application/*, application/json;q=0", formatBinary},
+		{"*/*;q=0.1, text/csv", formatCSV},
+		{"text/csv, application/json", formatCSV},
+		{"application/json;q=1.5, text/csv;q=0.3", formatCSV},
+		{"image/png, */*;q=0", ""},
+		{"application/json;q=0", ""},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/scan", nil)
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		format, apiErr := negotiateFormat(req)
+		if c.format == "" {
+			if apiErr == nil || apiErr.Status != http.StatusNotAcceptable {
+				t.Fatalf("%q: expected 406, got %q (%+v)", c.accept, format, apiErr)
+			}
+			continue
+		}
+		if apiErr != nil || format != c.format {
+			t.Fatalf("%q: expected %s, got %q (%+v)", c.accept, c.format, format, apiErr)
+		}
+	}
+}
@@ -0,0 +1,853 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/momentics/govna/pkg/govna"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scpiAddr - адрес SCPI-сервера; 5025 - стандартный порт SCPI-сокета приборов LXI.
+const scpiAddr = ":5025"
+
+const (
+	// scpiErrorQueueSize - глубина очереди ошибок SYST:ERR?; при переполнении последняя ошибка
+	// заменяется на -350.
+	scpiErrorQueueSize = 16
+	// scpiMarkers - число маркеров MARK1..MARK8.
+	scpiMarkers = 8
+	// scpiMaxLine - максимальная длина строки команд.
+	scpiMaxLine = 64 * 1024
+)
+
+var scpiConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "govna_scpi_connections",
+	Help: "Number of connected SCPI clients",
+})
+
+func init() {
+	prometheus.MustRegister(scpiConnections)
+}
+
+// scpiMnemonics сопоставляет длинные формы узлов SCPI коротким. Узлы, у которых формы совпадают,
+// перечислены с одинаковыми ключом и значением.
+var scpiMnemonics = map[string]string{
+	"SENSE": "SENS", "FREQUENCY": "FREQ", "START": "STAR", "STOP": "STOP", "CENTER": "CENT", "SPAN": "SPAN",
+	"SWEEP": "SWE", "POINTS": "POIN", "DATA": "DATA", "INITIATE": "INIT", "IMMEDIATE": "IMM",
+	"CALCULATE": "CALC", "PARAMETER": "PAR", "DEFINE": "DEF", "FORMAT": "FORM",
+	"MARKER": "MARK", "STATE": "STAT", "X": "X", "Y": "Y", "FUNCTION": "FUNC", "EXECUTE": "EXEC", "AOFF": "AOFF",
+	"SYSTEM": "SYST", "ERROR": "ERR", "NEXT": "NEXT", "DEVICE": "DEV", "LEASE": "LEAS",
+}
+
+// scpiOptional - необязательные узлы, отбрасываемые при сопоставлении команд: SENS:FREQ:STAR
+// и FREQ:STAR, INIT:IMM и INIT, CALC:MARK1:STAT и CALC:MARK1, SYST:ERR:NEXT? и SYST:ERR?.
+var scpiOptional = map[string]bool{"IMM": true, "STAT": true, "NEXT": true}
+
+// scpiFormats сопоставляет форматы CALC:FORM форматам трасс.
+var scpiFormats = map[string]govna.TraceFormat{
+	"MLOG": govna.FormatLogMag, "MLIN": govna.FormatLinMag, "PHAS": govna.FormatPhase,
+	"UPH": govna.FormatUnwrappedPhase, "GDEL": govna.FormatGroupDelay, "REAL": govna.FormatReal,
+	"IMAG": govna.FormatImag, "SWR": govna.FormatVSWR,
+}
+
+// scpiError - ошибка из очереди SYST:ERR? со стандартным кодом SCPI.
+type scpiError struct {
+	Code    int
+	Message string
+}
+
+func (e *scpiError) Error() string {
+	return fmt.Sprintf("%+d,\"%s\"", e.Code, strings.ReplaceAll(e.Message, `"`, `'`))
+}
+
+func scpiErrorf(code int, message string, args ...any) *scpiError {
+	return &scpiError{Code: code, Message: fmt.Sprintf(message, args...)}
+}
+
+var (
+	errSCPIUndefinedHeader = &scpiError{Code: -113, Message: "Undefined header"}
+	errSCPIMissingParam    = &scpiError{Code: -109, Message: "Missing parameter"}
+	errSCPINoData          = &scpiError{Code: -230, Message: "Data corrupt or stale; no sweep, send INIT first"}
+)
+
+// scpiServer принимает подключения SCPI-клиентов. Каждое подключение имеет собственные
+// параметры сканирования, трассу, маркеры и очередь ошибок, как отдельный сеанс прибора.
+type scpiServer struct {
+	pool *govna.VNAPool
+	// ctx отменяется при Close и прерывает сканирования открытых подключений.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+func newSCPIServer(pool *govna.VNAPool) *scpiServer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &scpiServer{pool: pool, ctx: ctx, cancel: cancel, conns: make(map[net.Conn]struct{})}
+}
+
+// Serve обслуживает подключения до вызова Close.
+func (s *scpiServer) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+// Close останавливает прием подключений, прерывает выполняемые сканирования и разрывает подключения.
+func (s *scpiServer) Close() {
+	s.cancel()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *scpiServer) serveConn(conn net.Conn) {
+	// Сканирования подключения ждут очереди и выполняются в его контексте: задания не переживают
+	// подключение и прерываются при остановке сервера.
+	ctx, cancel := context.WithCancel(s.ctx)
+	scpiConnections.Inc()
+	defer func() {
+		cancel()
+		scpiConnections.Dec()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	client := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(client); err == nil {
+		client = host
+	}
+	session := newSCPISession(ctx, s.pool, client)
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), scpiMaxLine)
+	writer := bufio.NewWriter(conn)
+	for scanner.Scan() {
+		responses := session.execute(scanner.Text())
+		if len(responses) == 0 {
+			continue
+		}
+		writer.WriteString(strings.Join(responses, ";"))
+		writer.WriteByte('\n')
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Printf("Ошибка SCPI-подключения (%s): %v", conn.RemoteAddr(), err)
+	}
+}
+
+type scpiMarker struct {
+	On bool
+	X  float64
+}
+
+// scpiSession - состояние одного SCPI-подключения. Параметры сканирования накапливаются
+// командами SENS и передаются устройству командой INIT.
+type scpiSession struct {
+	ctx     context.Context
+	pool    *govna.VNAPool
+	client  string
+	port    string
+	token   string
+	sweep   govna.SweepConfig
+	trace   govna.TraceSpec
+	data    *govna.VNAData
+	markers [scpiMarkers + 1]scpiMarker
+	errors  []*scpiError
+}
+
+// newSCPISession создает сеанс; client - идентификатор клиента в очереди заданий устройства,
+// ctx ограничивает ожидание и выполнение сканирований сеанса.
+func newSCPISession(ctx context.Context, pool *govna.VNAPool, client string) *scpiSession {
+	s := &scpiSession{ctx: ctx, pool: pool, client: client}
+	s.reset()
+	return s
+}
+
+// reset возвращает сеанс в исходное состояние (*RST); выбранное устройство сохраняется.
+func (s *scpiSession) reset() {
+	s.sweep = govna.SweepConfig{}
+	s.trace = govna.TraceSpec{Parameter: govna.S11, Format: govna.FormatLogMag}
+	s.data = nil
+	s.markers = [scpiMarkers + 1]scpiMarker{}
+}
+
+// scpiCommand - разобранная команда: Header - короткие формы узлов без необязательных
+// (например, CALC:MARK:X), Suffixes - числовые суффиксы узлов (MARK2 -> 2).
+type scpiCommand struct {
+	Header   string
+	Query    bool
+	Args     []string
+	Suffixes map[string]int
+}
+
+func (c scpiCommand) suffix(node string) int {
+	if n, ok := c.Suffixes[node]; ok {
+		return n
+	}
+	return 1
+}
+
+// execute выполняет строку команд, разделенных ';', и возвращает ответы на запросы.
+// Команда без ведущего ':' после ';' продолжает путь предыдущей, как в SCPI; общие команды (*)
+// путь не меняют.
+func (s *scpiSession) execute(line string) []string {
+	var responses []string
+	var prefix []string
+	for _, text := range splitSCPI(strings.TrimSpace(line), ';') {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		command, nodes, err := parseSCPI(text, prefix)
+		if err == nil {
+			if !strings.HasPrefix(command.Header, "*") {
+				prefix = nodes[:len(nodes)-1]
+			}
+			var response string
+			response, err = s.dispatch(command)
+			if err == nil && command.Query {
+				responses = append(responses, response)
+			}
+		}
+		if err != nil {
+			s.pushError(err)
+		}
+	}
+	return responses
+}
+
+func (s *scpiSession) pushError(err error) {
+	var scpiErr *scpiError
+	if !errors.As(err, &scpiErr) {
+		scpiErr = scpiErrorf(-240, "Hardware error; %v", err)
+	}
+	if len(s.errors) >= scpiErrorQueueSize {
+		s.errors[len(s.errors)-1] = &scpiError{Code: -350, Message: "Queue overflow"}
+		return
+	}
+	s.errors = append(s.errors, scpiErr)
+}
+
+// splitSCPI делит строку по разделителю вне кавычек.
+func splitSCPI(text string, sep rune) []string {
+	var parts []string
+	var quote rune
+	start := 0
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == sep:
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// parseSCPI разбирает команду. prefix - узлы пути предыдущей команды без последнего.
+// Возвращает также полный путь команды для следующей относительной команды.
+func parseSCPI(text string, prefix []string) (scpiCommand, []string, error) {
+	header, rest, _ := strings.Cut(text, " ")
+	command := scpiCommand{Suffixes: make(map[string]int)}
+	if rest = strings.TrimSpace(rest); rest != "" {
+		for _, arg := range splitSCPI(rest, ',') {
+			command.Args = append(command.Args, strings.Trim(strings.TrimSpace(arg), `"'`))
+		}
+	}
+	if strings.HasSuffix(header, "?") {
+		command.Query = true
+		header = strings.TrimSuffix(header, "?")
+	}
+	if strings.HasPrefix(header, "*") {
+		command.Header = strings.ToUpper(header)
+		return command, []string{command.Header}, nil
+	}
+
+	var nodes []string
+	if !strings.HasPrefix(header, ":") {
+		nodes = append(nodes, prefix...)
+	}
+	for _, node := range strings.Split(strings.TrimPrefix(header, ":"), ":") {
+		if node == "" {
+			return scpiCommand{}, nil, &scpiError{Code: -102, Message: "Syntax error"}
+		}
+		nodes = append(nodes, node)
+	}
+
+	var canonical []string
+	for i, node := range nodes {
+		name := strings.ToUpper(strings.TrimRight(node, "0123456789"))
+		short, ok := scpiMnemonics[name]
+		if !ok {
+			for _, s := range scpiMnemonics {
+				if s == name {
+					short, ok = s, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return scpiCommand{}, nil, errSCPIUndefinedHeader
+		}
+		if digits := node[len(name):]; digits != "" {
+			n, err := strconv.Atoi(digits)
+			if err != nil {
+				return scpiCommand{}, nil, errSCPIUndefinedHeader
+			}
+			command.Suffixes[short] = n
+		}
+		if scpiOptional[short] || (i == 0 && short == "SENS") {
+			continue
+		}
+		canonical = append(canonical, short)
+	}
+	command.Header = strings.Join(canonical, ":")
+	return command, nodes, nil
+}
+
+func (s *scpiSession) dispatch(c scpiCommand) (string, error) {
+	key := c.Header
+	if c.Query {
+		key += "?"
+	}
+	switch key {
+	case "*IDN?":
+		return s.identify()
+	case "*RST":
+		s.reset()
+		return "", nil
+	case "*CLS":
+		s.errors = nil
+		return "", nil
+	case "*OPC?":
+		// Команды выполняются синхронно, к моменту ответа все операции завершены.
+		return "1", nil
+	case "*OPC", "*WAI":
+		return "", nil
+	case "SYST:ERR?":
+		if len(s.errors) == 0 {
+			return (&scpiError{Code: 0, Message: "No error"}).Error(), nil
+		}
+		err := s.errors[0]
+		s.errors = s.errors[1:]
+		return err.Error(), nil
+	case "SYST:DEV":
+		// Расширение GoVNA: выбор устройства по пути порта. Без него используется первое доступное.
+		if len(c.Args) == 0 {
+			return "", errSCPIMissingParam
+		}
+		s.port = c.Args[0]
+		s.sweep = govna.SweepConfig{}
+		s.data = nil
+		return "", nil
+	case "SYST:LEAS":
+		// Расширение GoVNA: токен аренды устройства (см. /api/v1/leases), дающий право на INIT.
+		if len(c.Args) == 0 {
+			return "", errSCPIMissingParam
+		}
+		s.token = c.Args[0]
+		return "", nil
+	case "SYST:DEV?":
+		port, err := s.devicePort()
+		return strconv.Quote(port), err
+	case "FORM:DATA":
+		if len(c.Args) == 0 {
+			return "", errSCPIMissingParam
+		}
+		if format := strings.ToUpper(c.Args[0]); format != "ASC" && format != "ASCII" {
+			return "", scpiErrorf(-224, "Illegal parameter value; only ASCii data format is supported")
+		}
+		return "", nil
+	case "FORM:DATA?":
+		return "ASC", nil
+	case "FREQ:STAR", "FREQ:STOP", "FREQ:CENT", "FREQ:SPAN":
+		return "", s.setFrequency(c.Header, c.Args)
+	case "FREQ:STAR?", "FREQ:STOP?", "FREQ:CENT?", "FREQ:SPAN?":
+		return s.frequency(c.Header)
+	case "SWE:POIN":
+		return "", s.setPoints(c.Args)
+	case "SWE:POIN?":
+		sweep, err := s.currentSweep()
+		return strconv.Itoa(sweep.Points), err
+	case "FREQ:DATA?":
+		if s.data == nil {
+			return "", errSCPINoData
+		}
+		return formatSCPIValues(s.data.Frequencies), nil
+	case "INIT":
+		return "", s.initiate()
+	case "CALC:PAR:DEF":
+		if len(c.Args) == 0 {
+			return "", errSCPIMissingParam
+		}
+		// Keysight принимает CALC:PAR:DEF 'имя',S21, старые анализаторы - CALC:PAR:DEF S21.
+		parameter := govna.SParameter(strings.ToUpper(c.Args[len(c.Args)-1]))
+		switch parameter {
+		case govna.S11, govna.S21, govna.S12, govna.S22:
+		default:
+			return "", scpiErrorf(-224, "Illegal parameter value; unknown S-parameter %s", parameter)
+		}
+		s.trace.Parameter = parameter
+		return "", nil
+	case "CALC:PAR:DEF?":
+		return string(s.trace.Parameter), nil
+	case "CALC:FORM":
+		if len(c.Args) == 0 {
+			return "", errSCPIMissingParam
+		}
+		format, ok := scpiFormats[strings.ToUpper(c.Args[0])]
+		if !ok {
+			return "", scpiErrorf(-224, "Illegal parameter value; unsupported format %s", c.Args[0])
+		}
+		s.trace.Format = format
+		return "", nil
+	case "CALC:FORM?":
+		for name, format := range scpiFormats {
+			if format == s.trace.Format {
+				return name, nil
+			}
+		}
+		return "", scpiErrorf(-224, "Illegal parameter value")
+	case "CALC:DATA?":
+		return s.calculateData(c.Args)
+	case "CALC:MARK":
+		marker, err := s.marker(c)
+		if err != nil {
+			return "", err
+		}
+		if len(c.Args) == 0 {
+			return "", errSCPIMissingParam
+		}
+		on, err := parseSCPIBool(c.Args[0])
+		if err != nil {
+			return "", err
+		}
+		marker.On = on
+		return "", nil
+	case "CALC:MARK?":
+		marker, err := s.marker(c)
+		if err != nil {
+			return "", err
+		}
+		if marker.On {
+			return "1", nil
+		}
+		return "0", nil
+	case "CALC:MARK:AOFF":
+		for i := range s.markers {
+			s.markers[i].On = false
+		}
+		return "", nil
+	case "CALC:MARK:X":
+		marker, err := s.marker(c)
+		if err != nil {
+			return "", err
+		}
+		if len(c.Args) == 0 {
+			return "", errSCPIMissingParam
+		}
+		x, err := s.parseFrequency(c.Args[0])
+		if err != nil {
+			return "", err
+		}
+		marker.On, marker.X = true, x
+		return "", nil
+	case "CALC:MARK:X?":
+		marker, err := s.marker(c)
+		if err != nil {
+			return "", err
+		}
+		return formatSCPIValue(marker.X), nil
+	case "CALC:MARK:Y?":
+		return s.markerValue(c)
+	case "CALC:MARK:FUNC:EXEC":
+		return "", s.markerSearch(c)
+	}
+	return "", errSCPIUndefinedHeader
+}
+
+func (s *scpiSession) identify() (string, error) {
+	vna, port, err := s.device()
+	if err != nil {
+		return "", err
+	}
+	identity, err := vna.Identify()
+	if err != nil {
+		return "", err
+	}
+	// Поля *IDN? разделены запятыми, поэтому запятые внутри полей заменяются пробелами.
+	field := func(value string) string { return strings.ReplaceAll(strings.TrimSpace(value), ",", " ") }
+	return strings.Join([]string{"GoVNA", field(vna.Capabilities().Model), field(port), field(identity)}, ","), nil
+}
+
+// devicePort возвращает выбранное устройство, а без SYST:DEV - первое открытое или доступное.
+func (s *scpiSession) devicePort() (string, error) {
+	if s.port != "" {
+		return s.port, nil
+	}
+	ports := s.pool.Devices()
+	if len(ports) == 0 {
+		available, err := govna.AvailablePorts()
+		if err != nil {
+			return "", scpiErrorf(-240, "Hardware error; %v", err)
+		}
+		ports = available
+	}
+	if len(ports) == 0 {
+		return "", scpiErrorf(-241, "Hardware missing; no VNA connected")
+	}
+	s.port = ports[0]
+	return s.port, nil
+}
+
+func (s *scpiSession) device() (*govna.VNA, string, error) {
+	port, err := s.devicePort()
+	if err != nil {
+		return nil, "", err
+	}
+	vna, err := s.pool.Get(port)
+	if err != nil {
+		return nil, "", scpiErrorf(-240, "Hardware error; %v", err)
+	}
+	return vna, port, nil
+}
+
+// currentSweep возвращает параметры сканирования сеанса, при первом обращении - текущие
+// параметры устройства или defaultSweep.
+func (s *scpiSession) currentSweep() (govna.SweepConfig, error) {
+	if s.sweep.Points != 0 {
+		return s.sweep, nil
+	}
+	vna, _, err := s.device()
+	if err != nil {
+		return govna.SweepConfig{}, err
+	}
+	s.sweep = vna.Sweep()
+	if s.sweep.Points == 0 {
+		s.sweep = defaultSweep
+	}
+	return s.sweep, nil
+}
+
+func (s *scpiSession) capabilities() (govna.DeviceCapabilities, error) {
+	vna, _, err := s.device()
+	if err != nil {
+		return govna.DeviceCapabilities{}, err
+	}
+	return vna.Capabilities(), nil
+}
+
+// parseFrequency разбирает частоту с необязательными единицами HZ, KHZ, MHZ, GHZ
+// и ключевыми словами MIN/MAX (границы диапазона устройства).
+func (s *scpiSession) parseFrequency(arg string) (float64, error) {
+	value := strings.ToUpper(strings.TrimSpace(arg))
+	switch value {
+	case "MIN", "MINIMUM", "MAX", "MAXIMUM":
+		caps, err := s.capabilities()
+		if err != nil {
+			return 0, err
+		}
+		if strings.HasPrefix(value, "MIN") {
+			return caps.MinFrequency, nil
+		}
+		return caps.MaxFrequency, nil
+	}
+	multiplier := 1.0
+	value = strings.TrimSuffix(value, "HZ")
+	switch {
+	case strings.HasSuffix(value, "K"):
+		multiplier, value = 1e3, strings.TrimSuffix(value, "K")
+	case strings.HasSuffix(value, "M"):
+		multiplier, value = 1e6, strings.TrimSuffix(value, "M")
+	case strings.HasSuffix(value, "G"):
+		multiplier, value = 1e9, strings.TrimSuffix(value, "G")
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, scpiErrorf(-104, "Data type error; %q is not a frequency", arg)
+	}
+	return f * multiplier, nil
+}
+
+func (s *scpiSession) setFrequency(header string, args []string) error {
+	if len(args) == 0 {
+		return errSCPIMissingParam
+	}
+	value, err := s.parseFrequency(args[0])
+	if err != nil {
+		return err
+	}
+	sweep, err := s.currentSweep()
+	if err != nil {
+		return err
+	}
+	caps, err := s.capabilities()
+	if err != nil {
+		return err
+	}
+	center, span := (sweep.Start+sweep.Stop)/2, sweep.Stop-sweep.Start
+	switch header {
+	case "FREQ:STAR":
+		sweep.Start = value
+	case "FREQ:STOP":
+		sweep.Stop = value
+	case "FREQ:CENT":
+		sweep.Start, sweep.Stop = value-span/2, value+span/2
+	case "FREQ:SPAN":
+		sweep.Start, sweep.Stop = center-value/2, center+value/2
+	}
+	if sweep.Start < caps.MinFrequency || sweep.Stop > caps.MaxFrequency || sweep.Start < 0 || sweep.Stop < 0 {
+		return scpiErrorf(-222, "Data out of range; frequency range is %g..%g Hz", caps.MinFrequency, caps.MaxFrequency)
+	}
+	s.sweep = sweep
+	return nil
+}
+
+func (s *scpiSession) frequency(header string) (string, error) {
+	sweep, err := s.currentSweep()
+	if err != nil {
+		return "", err
+	}
+	values := map[string]float64{
+		"FREQ:STAR": sweep.Start,
+		"FREQ:STOP": sweep.Stop,
+		"FREQ:CENT": (sweep.Start + sweep.Stop) / 2,
+		"FREQ:SPAN": sweep.Stop - sweep.Start,
+	}
+	return formatSCPIValue(values[header]), nil
+}
+
+func (s *scpiSession) setPoints(args []string) error {
+	if len(args) == 0 {
+		return errSCPIMissingParam
+	}
+	caps, err := s.capabilities()
+	if err != nil {
+		return err
+	}
+	var points int
+	switch value := strings.ToUpper(args[0]); value {
+	case "MIN", "MINIMUM":
+		points = caps.MinPoints
+	case "MAX", "MAXIMUM":
+		points = caps.MaxPoints
+	default:
+		// Число точек может прийти в экспоненциальной записи, например 2.01E+02.
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f != math.Trunc(f) {
+			return scpiErrorf(-104, "Data type error; %q is not an integer", args[0])
+		}
+		points = int(f)
+	}
+	if points < caps.MinPoints || points > caps.MaxPoints {
+		return scpiErrorf(-222, "Data out of range; points must be %d..%d", caps.MinPoints, caps.MaxPoints)
+	}
+	sweep, err := s.currentSweep()
+	if err != nil {
+		return err
+	}
+	sweep.Points = points
+	s.sweep = sweep
+	return nil
+}
+
+// initiate передает устройству параметры сканирования сеанса и выполняет один скан.
+// Если устройство арендовано, INIT разрешен только с токеном аренды (SYST:LEAS).
+func (s *scpiSession) initiate() error {
+	vna, port, err := s.device()
+	if err != nil {
+		return err
+	}
+	if holder, ok := leases.Check(port, s.token); !ok {
+		return scpiErrorf(-221, "Settings conflict; device leased by %s until %s", holder.Owner,
+			holder.Expires.Format(time.RFC3339))
+	}
+	sweep, err := s.currentSweep()
+	if err != nil {
+		return err
+	}
+	var data govna.VNAData
+	var meta govna.ScanMetadata
+	if err := runLeasedJob(s.ctx, port, s.client, s.token, govna.PriorityNormal, func() {
+		data, meta, err = vna.Measure(s.ctx, govna.MeasureRequest{Sweep: sweep, Calibration: vna.Calibration()})
+	}); err != nil {
+		var denied *leasedError
+		if errors.As(err, &denied) {
+			return scpiErrorf(-221, "Settings conflict; device leased by %s until %s", denied.holder.Owner,
+				denied.holder.Expires.Format(time.RFC3339))
+		}
+		return scpiErrorf(-213, "Init ignored; %v", err)
+	}
+	if err != nil {
+		var sweepErr *govna.SweepError
+		if errors.As(err, &sweepErr) {
+			return scpiErrorf(-222, "Data out of range; %v", err)
+		}
+		return err
+	}
+	scanDuration.WithLabelValues(port).Observe(meta.Duration.Seconds())
+	s.data = &data
+	return nil
+}
+
+// calculateData отвечает на CALC:DATA? SDATA (пары re,im выбранного параметра) и
+// CALC:DATA? FDATA (пары значение,0 в формате CALC:FORM, как у анализаторов Keysight).
+func (s *scpiSession) calculateData(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", errSCPIMissingParam
+	}
+	if s.data == nil {
+		return "", errSCPINoData
+	}
+	switch strings.ToUpper(args[0]) {
+	case "SDATA", "SDAT":
+		values, err := s.data.Parameter(s.trace.Parameter)
+		if err != nil {
+			return "", scpiErrorf(-230, "Data corrupt or stale; %v", err)
+		}
+		out := make([]float64, 0, 2*len(values))
+		for _, value := range values {
+			out = append(out, real(value), imag(value))
+		}
+		return formatSCPIValues(out), nil
+	case "FDATA", "FDAT":
+		trace, err := s.data.Trace(s.trace)
+		if err != nil {
+			return "", scpiErrorf(-230, "Data corrupt or stale; %v", err)
+		}
+		out := make([]float64, 0, 2*len(trace))
+		for _, value := range trace {
+			out = append(out, value, 0)
+		}
+		return formatSCPIValues(out), nil
+	}
+	return "", scpiErrorf(-224, "Illegal parameter value; expected SDATA or FDATA")
+}
+
+func (s *scpiSession) marker(c scpiCommand) (*scpiMarker, error) {
+	n := c.suffix("MARK")
+	if n < 1 || n > scpiMarkers {
+		return nil, scpiErrorf(-114, "Header suffix out of range; markers are 1..%d", scpiMarkers)
+	}
+	return &s.markers[n], nil
+}
+
+// markerValue отвечает на CALC:MARK<n>:Y? значением трассы на частоте маркера в формате
+// "значение,0".
+func (s *scpiSession) markerValue(c scpiCommand) (string, error) {
+	marker, err := s.marker(c)
+	if err != nil {
+		return "", err
+	}
+	if !marker.On {
+		return "", scpiErrorf(-221, "Settings conflict; marker is off")
+	}
+	if s.data == nil {
+		return "", errSCPINoData
+	}
+	reading, err := s.data.MarkerAt(s.trace, marker.X)
+	if err != nil {
+		return "", scpiErrorf(-222, "Data out of range; %v", err)
+	}
+	return formatSCPIValues([]float64{reading.Value, 0}), nil
+}
+
+// markerSearch выполняет CALC:MARK<n>:FUNC:EXEC MAX|MIN по последнему скану.
+func (s *scpiSession) markerSearch(c scpiCommand) error {
+	marker, err := s.marker(c)
+	if err != nil {
+		return err
+	}
+	if len(c.Args) == 0 {
+		return errSCPIMissingParam
+	}
+	if s.data == nil {
+		return errSCPINoData
+	}
+	var reading govna.MarkerReading
+	switch strings.ToUpper(c.Args[0]) {
+	case "MAX", "MAXIMUM":
+		reading, err = s.data.SearchMax(s.trace)
+	case "MIN", "MINIMUM":
+		reading, err = s.data.SearchMin(s.trace)
+	default:
+		return scpiErrorf(-224, "Illegal parameter value; expected MAX or MIN")
+	}
+	if err != nil {
+		return scpiErrorf(-230, "Data corrupt or stale; %v", err)
+	}
+	marker.On, marker.X = true, reading.Frequency
+	return nil
+}
+
+func parseSCPIBool(arg string) (bool, error) {
+	switch strings.ToUpper(arg) {
+	case "1", "ON":
+		return true, nil
+	case "0", "OFF":
+		return false, nil
+	}
+	return false, scpiErrorf(-224, "Illegal parameter value; expected ON or OFF")
+}
+
+// formatSCPIValue форматирует число как анализаторы Keysight: +1.00000000000E+06.
+func formatSCPIValue(value float64) string {
+	return fmt.Sprintf("%+.11E", value)
+}
+
+func formatSCPIValues(values []float64) string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = formatSCPIValue(value)
+	}
+	return strings.Join(out, ",")
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scpiValues разбирает ответ со списком чисел через запятую.
+func scpiValues(t *testing.T, response string) []float64 {
+	t.Helper()
+	var values []float64
+	for _, field := range strings.Split(response, ",") {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			t.Fatalf("response %q: %v", response, err)
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// nextError возвращает ответ SYST:ERR?.
+func nextError(t *testing.T, session *scpiSession) string {
+	t.Helper()
+	responses := session.execute("SYST:ERR?")
+	if len(responses) != 1 {
+		t.Fatalf("expected one response, got %q", responses)
+	}
+	return responses[0]
+}
+
+func TestSCPISession_Commands(t *testing.T) {
+	const port = "/dev/test-scpi-commands"
+	pool, driver := newTestDevice(t, port)
+	session := newSCPISession(context.Background(), pool, "scpi-client")
+
+	if responses := session.execute(`SYST:DEV "` + port + `";*IDN?`); len(responses) != 1 ||
+		responses[0] != "GoVNA,Test VNA,"+port+",test" {
+		t.Fatalf("unexpected *IDN? response %q", responses)
+	}
+	// STOP продолжает путь SENS:FREQ предыдущей команды.
+	session.execute("SENS:FREQ:STAR 1 MHZ;STOP 10MHZ;:SENS:SWE:POIN 11")
+	responses := session.execute("FREQ:STAR?;STOP?;:SWE:POIN?")
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 responses, got %q", responses)
+	}
+	if got := scpiValues(t, responses[0]+","+responses[1]); got[0] != 1e6 || got[1] != 10e6 || responses[2] != "11" {
+		t.Fatalf("unexpected sweep %q", responses)
+	}
+
+	session.execute("INIT")
+	if driver.Scans() != 1 {
+		t.Fatalf("expected one scan, got %d", driver.Scans())
+	}
+	responses = session.execute("CALC:DATA? SDATA;:FREQ:DATA?")
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %q", responses)
+	}
+	data, frequencies := scpiValues(t, responses[0]), scpiValues(t, responses[1])
+	if len(data) != 22 || data[0] != 0.5 || data[1] != 0 {
+		t.Fatalf("unexpected SDATA %v", data)
+	}
+	if len(frequencies) != 11 || frequencies[10] != 10e6 {
+		t.Fatalf("unexpected frequencies %v", frequencies)
+	}
+	if got := nextError(t, session); got != `+0,"No error"` {
+		t.Fatalf("unexpected error %s", got)
+	}
+
+	// Общие команды не сбрасывают путь: STOP продолжает SENS:FREQ.
+	responses = session.execute("SENS:FREQ:STAR 2e6;*OPC?;STOP 20e6;*OPC?;:FREQ:STOP?")
+	if len(responses) != 3 || responses[0] != "1" || scpiValues(t, responses[2])[0] != 20e6 {
+		t.Fatalf("unexpected responses %q", responses)
+	}
+	if got := nextError(t, session); got != `+0,"No error"` {
+		t.Fatalf("unexpected error %s", got)
+	}
+
+	// Некорректное значение не выключает маркер.
+	session.execute("CALC:MARK1 ON;MARK1 MAYBE")
+	if responses := session.execute("CALC:MARK1?"); len(responses) != 1 || responses[0] != "1" {
+		t.Fatalf("expected marker to stay on, got %q", responses)
+	}
+	if got := nextError(t, session); !strings.HasPrefix(got, "-224,") {
+		t.Fatalf("expected illegal parameter error, got %s", got)
+	}
+}
+
+func TestSCPISession_ErrorQueue(t *testing.T) {
+	const port = "/dev/test-scpi-errors"
+	pool, _ := newTestDevice(t, port)
+	session := newSCPISession(context.Background(), pool, "scpi-client")
+	session.execute(`SYST:DEV "` + port + `"`)
+
+	session.execute("FOO:BAR;CALC:DATA? SDATA;:SWE:POIN 1000;:SWE:POIN")
+	for _, want := range []string{"-113,", "-230,", "-222,", "-109,"} {
+		if got := nextError(t, session); !strings.HasPrefix(got, want) {
+			t.Fatalf("expected error %s..., got %s", want, got)
+		}
+	}
+	if got := nextError(t, session); got != `+0,"No error"` {
+		t.Fatalf("expected empty queue, got %s", got)
+	}
+
+	for i := 0; i < scpiErrorQueueSize+4; i++ {
+		session.execute("FOO")
+	}
+	for i := 0; i < scpiErrorQueueSize-1; i++ {
+		if got := nextError(t, session); !strings.HasPrefix(got, "-113,") {
+			t.Fatalf("error %d: expected -113, got %s", i, got)
+		}
+	}
+	if got := nextError(t, session); got != `-350,"Queue overflow"` {
+		t.Fatalf("expected overflow, got %s", got)
+	}
+
+	session.execute("FOO;*CLS")
+	if got := nextError(t, session); got != `+0,"No error"` {
+		t.Fatalf("*CLS must clear the queue, got %s", got)
+	}
+}
+
+func TestSCPISession_InitLease(t *testing.T) {
+	const port = "/dev/test-scpi-lease"
+	pool, driver := newTestDevice(t, port)
+	session := newSCPISession(context.Background(), pool, "scpi-client")
+	session.execute(`SYST:DEV "` + port + `"`)
+	holder, ok := leases.Acquire(port, "bench", time.Minute)
+	if !ok {
+		t.Fatalf("Acquire failed")
+	}
+	defer leases.Release(port, holder.Token)
+
+	session.execute("INIT")
+	if got := nextError(t, session); !strings.HasPrefix(got, "-221,") || !strings.Contains(got, "bench") {
+		t.Fatalf("expected settings conflict, got %s", got)
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("leased device must not be scanned, got %d scans", driver.Scans())
+	}
+
+	session.execute(`SYST:LEAS "` + holder.Token + `";:INIT`)
+	if got := nextError(t, session); got != `+0,"No error"` {
+		t.Fatalf("INIT with lease token failed: %s", got)
+	}
+	if driver.Scans() != 1 {
+		t.Fatalf("expected one scan, got %d", driver.Scans())
+	}
+}
+
+func TestSCPISession_InitCancelled(t *testing.T) {
+	const port = "/dev/test-scpi-cancel"
+	pool, driver := newTestDevice(t, port)
+	occupyDevice(t, port, "holder")
+	ctx, cancel := context.WithCancel(context.Background())
+	session := newSCPISession(ctx, pool, "scpi-client")
+	session.execute(`SYST:DEV "` + port + `"`)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		session.execute("INIT")
+	}()
+	waitQueued(t, port, 1)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("INIT was not cancelled with the connection context")
+	}
+	if got := nextError(t, session); !strings.HasPrefix(got, "-213,") {
+		t.Fatalf("expected init ignored, got %s", got)
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("cancelled INIT must not scan, got %d scans", driver.Scans())
+	}
+}
+
+func TestSCPIServer_CloseCancelsInit(t *testing.T) {
+	const port = "/dev/test-scpi-close"
+	pool, driver := newTestDevice(t, port)
+	occupyDevice(t, port, "holder")
+	server := newSCPIServer(pool)
+	client, conn := net.Pipe()
+	defer client.Close()
+
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		server.serveConn(conn)
+	}()
+	writer := bufio.NewWriter(client)
+	writer.WriteString(`SYST:DEV "` + port + `";:INIT` + "\n")
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	waitQueued(t, port, 1)
+
+	// Остановка сервера отменяет контекст подключения: задание INIT покидает очередь.
+	server.Close()
+	waitQueued(t, port, 0)
+	client.Close()
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("serveConn did not exit")
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("cancelled INIT must not scan, got %d scans", driver.Scans())
+	}
+}
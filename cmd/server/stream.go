@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/momentics/govna/pkg/govna"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// streamWriteWait - предельное время отправки одного кадра клиенту.
+	streamWriteWait = 10 * time.Second
+	// streamPingPeriod - период ping; клиент, не ответивший за streamPongWait, отключается.
+	streamPingPeriod = 30 * time.Second
+	streamPongWait   = 2 * streamPingPeriod
+	// streamClientID - клиент очереди устройства, от имени которого выполняются сканы потока.
+	streamClientID = "govna-stream"
+)
+
+var (
+	streamSubscribers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "govna_stream_subscribers",
+			Help: "Number of connected live sweep stream subscribers",
+		},
+		[]string{"port"},
+	)
+	streamDroppedFrames = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "govna_stream_dropped_frames_total",
+			Help: "Number of live sweep frames dropped for slow stream subscribers",
+		},
+		[]string{"port"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(streamSubscribers, streamDroppedFrames)
+}
+
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 64 * 1024}
+
+// streamHandler подключает клиента WebSocket к непрерывному сканированию устройства.
+// Параметры: port, format=json|csv|binary|touchstone (по умолчанию json), decimate=N - каждый
+// N-й скан, traces, averaging и average - как в /api/v1/scan (среднее клиента общее для его
+// запросов и потоков; в него входят и прореженные сканы). Кадры binary отправляются двоичными сообщениями,
+// остальные - текстовыми; ошибки устройства - текстовыми сообщениями в формате apiError.
+// Сканирование не перенастраивается: используются параметры последнего /api/v1/scan на момент
+// запуска потока. Сканы потока выполняются в очереди устройства (см. streamScheduler), а сканы
+// других клиентов с другой сеткой чередуются с кадрами и не меняют их сетку. Аренда проверяется
+// перед каждым кадром: если она истекла или перешла к другому клиенту, клиент получает ошибку
+// device_leased, и поток закрывается.
+func streamHandler(pool *govna.VNAPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		port := query.Get("port")
+		if port == "" {
+			writeError(w, missingParameter("port"))
+			return
+		}
+		format := query.Get("format")
+		if format == "" {
+			format = formatJSON
+		}
+		if _, ok := formatContentTypes[format]; !ok {
+			writeError(w, &apiError{Status: http.StatusBadRequest, Code: "unsupported_value", Field: "format",
+				Message: fmt.Sprintf("Формат %q не поддерживается; допустимы touchstone, json, csv, binary", format)})
+			return
+		}
+		decimation := 1
+		if value := query.Get("decimate"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				writeError(w, invalidParameter("decimate", "Параметр 'decimate' должен быть положительным целым числом"))
+				return
+			}
+			decimation = n
+		}
+		traces, apiErr := parseTraces(query)
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		averager, apiErr := parseAveraging(query, port, clientID(r))
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		// Непрерывное сканирование занимает устройство, поэтому при аренде доступно только владельцу.
+		token := r.Header.Get(leaseTokenHeader)
+		if !requireLease(w, r, port) {
+			return
+		}
+
+		vna, err := pool.Get(port)
+		if err != nil {
+			writeError(w, deviceError(err))
+			return
+		}
+		if vna.Sweep().Points == 0 {
+			if err := vna.SetSweep(defaultSweep); err != nil {
+				writeError(w, deviceError(err))
+				return
+			}
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade уже отправил клиенту ответ с ошибкой.
+			return
+		}
+		defer conn.Close()
+
+		vna.SetStreamScheduler(streamScheduler(port))
+		subscription := vna.Subscribe(govna.StreamOptions{Decimation: decimation, Averaging: averager})
+		defer subscription.Close()
+		streamSubscribers.WithLabelValues(port).Inc()
+		defer streamSubscribers.WithLabelValues(port).Dec()
+
+		// Чтение нужно для обработки pong и закрытия соединения клиентом.
+		closed := make(chan struct{})
+		conn.SetReadLimit(1024)
+		conn.SetReadDeadline(time.Now().Add(streamPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+		})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(streamPingPeriod)
+		defer ping.Stop()
+		var dropped uint64
+		for {
+			select {
+			case <-closed:
+				return
+			case <-ping.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+					return
+				}
+			case frame, ok := <-subscription.C:
+				if !ok {
+					conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseGoingAway, "device closed"), time.Now().Add(streamWriteWait))
+					return
+				}
+				if holder, ok := leases.Check(port, token); !ok {
+					conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+					conn.WriteJSON(deviceLeasedError(holder))
+					conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "device leased"), time.Now().Add(streamWriteWait))
+					return
+				}
+				if total := subscription.Dropped(); total > dropped {
+					streamDroppedFrames.WithLabelValues(port).Add(float64(total - dropped))
+					dropped = total
+				}
+				if err := writeStreamFrame(conn, format, frame, port, traces); err != nil {
+					log.Printf("Ошибка отправки кадра (%s): %v", port, err)
+					return
+				}
+			}
+		}
+	}
+}
+
+// streamScheduler ставит каждый скан непрерывного сканирования в очередь устройства с низким
+// приоритетом: запросы клиентов выполняются между кадрами потока, а ожидание учитывается
+// в метриках очереди. Все потоки устройства используют один цикл сканирования и одного клиента очереди.
+func streamScheduler(port string) govna.ScanScheduler {
+	return func(ctx context.Context, scan func()) error {
+		return runJob(ctx, port, streamClientID, govna.PriorityLow, scan)
+	}
+}
+
+func writeStreamFrame(conn *websocket.Conn, format string, frame govna.StreamFrame, port string, traces []govna.TraceSpec) error {
+	conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	if frame.Err != nil {
+		return conn.WriteJSON(scanError(frame.Err))
+	}
+	frame.Metadata.Port = port
+	body, err := encodeScan(format, &frame.Data, frame.Metadata, "", traces)
+	if err != nil {
+		return conn.WriteJSON(encodingError(err))
+	}
+	messageType := websocket.TextMessage
+	if format == formatBinary {
+		messageType = websocket.BinaryMessage
+	}
+	return conn.WriteMessage(messageType, body)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/momentics/govna/pkg/govna"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// counterValue возвращает текущее значение счетчика Prometheus.
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := counter.Write(&metric); err != nil {
+		t.Fatalf("reading counter failed: %v", err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+// dialStream подключается к streamHandler тестового сервера с параметрами query.
+func dialStream(t *testing.T, pool *govna.VNAPool, query string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	server := httptest.NewServer(streamHandler(pool))
+	t.Cleanup(server.Close)
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/stream?"+query, header)
+	if err == nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+type streamFrame struct {
+	Metadata    govna.ScanMetadata `json:"metadata"`
+	Frequencies []float64          `json:"frequencies"`
+}
+
+func readStreamFrame(t *testing.T, conn *websocket.Conn) streamFrame {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	messageType, body, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	if messageType != websocket.TextMessage {
+		t.Fatalf("expected text frame, got message type %d", messageType)
+	}
+	var frame streamFrame
+	if err := json.Unmarshal(body, &frame); err != nil {
+		t.Fatalf("frame is not JSON: %v: %s", err, body)
+	}
+	return frame
+}
+
+func TestStreamHandler_RejectsParameters(t *testing.T) {
+	const port = "/dev/test-stream-params"
+	pool, driver := newTestDevice(t, port)
+
+	for _, query := range []string{"format=xml", "decimate=0", "decimate=x", "traces=S11:bogus"} {
+		rec := httptest.NewRecorder()
+		streamHandler(pool)(rec, httptest.NewRequest(http.MethodGet, "/api/v1/stream?port="+port+"&"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", query, rec.Code, rec.Body)
+		}
+	}
+
+	acquireLease(t, pool, port, "bench")
+	_, resp, err := dialStream(t, pool, "port="+port, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusLocked {
+		t.Fatalf("expected handshake to fail with 423 for a leased device, got %v, %v", resp, err)
+	}
+	if driver.Scans() != 0 {
+		t.Fatalf("rejected streams must not scan, got %d scans", driver.Scans())
+	}
+}
+
+func TestStreamHandler_Decimation(t *testing.T) {
+	const port = "/dev/test-stream-decimate"
+	pool, _ := newTestDevice(t, port)
+
+	conn, _, err := dialStream(t, pool, "port="+port+"&decimate=3", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	// Устройство сканирует только для этого потока, поэтому номер скана совпадает с номером кадра
+	// подписки; медленное чтение может отбросить кадры, но не нарушает прореживание.
+	for i := 0; i < 4; i++ {
+		frame := readStreamFrame(t, conn)
+		if frame.Metadata.Port != port || len(frame.Frequencies) != defaultSweep.Points {
+			t.Fatalf("unexpected frame %+v", frame.Metadata)
+		}
+		if (frame.Metadata.Sequence-1)%3 != 0 {
+			t.Fatalf("expected every third sweep, got sequence %d", frame.Metadata.Sequence)
+		}
+	}
+}
+
+func TestStreamHandler_DroppedFrames(t *testing.T) {
+	const port = "/dev/test-stream-dropped"
+	pool, _ := newTestDevice(t, port)
+	dropped := streamDroppedFrames.WithLabelValues(port)
+	before := counterValue(t, dropped)
+	vna, _ := pool.Get(port)
+	if err := vna.SetSweep(govna.SweepConfig{Start: 1e6, Stop: 1e9, Points: 201}); err != nil {
+		t.Fatalf("SetSweep failed: %v", err)
+	}
+
+	conn, _, err := dialStream(t, pool, "port="+port, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	// Клиент не читает кадры, пока буферы соединения не заполнятся и подписка не начнет
+	// отбрасывать кадры; счетчик обновляется при отправке следующего кадра.
+	time.Sleep(500 * time.Millisecond)
+	deadline := time.Now().Add(10 * time.Second)
+	for counterValue(t, dropped) == before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected dropped frames to be counted for a slow subscriber")
+		}
+		readStreamFrame(t, conn)
+	}
+}
+
+func TestStreamHandler_ClosesOnLeaseChange(t *testing.T) {
+	const port = "/dev/test-stream-lease"
+	pool, _ := newTestDevice(t, port)
+
+	conn, _, err := dialStream(t, pool, "port="+port, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	readStreamFrame(t, conn)
+	acquireLease(t, pool, port, "bench")
+
+	// Кадры, отправленные до аренды, могут еще находиться в буфере соединения.
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, body, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("expected device_leased error before the stream closes, got %v", err)
+		}
+		var apiErr apiError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Code != "" {
+			if apiErr.Code != "device_leased" {
+				t.Fatalf("expected device_leased, got %+v", apiErr)
+			}
+			break
+		}
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("expected policy violation close, got %v", err)
+	}
+}
@@ -0,0 +1,359 @@
+// Этот файл содержит усреднение между свипами, сглаживание по точкам и математику с трассой памяти.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type AveragingMode string
+
+const (
+	// AveragingRunning - скользящее среднее по последним Count свипам.
+	AveragingRunning AveragingMode = "running"
+	// AveragingExponential - экспоненциальное усреднение с коэффициентом 1/Count
+	// (первые Count свипов усредняются равновзвешенно, как в коммерческих анализаторах).
+	AveragingExponential AveragingMode = "exponential"
+)
+
+type AveragingConfig struct {
+	Mode  AveragingMode
+	Count int
+}
+
+func (c AveragingConfig) Validate() error {
+	if c.Mode != AveragingRunning && c.Mode != AveragingExponential {
+		return fmt.Errorf("неизвестный режим усреднения %q", c.Mode)
+	}
+	if c.Count < 1 {
+		return errors.New("число усреднений должно быть положительным")
+	}
+	return nil
+}
+
+type MemoryOperation string
+
+const (
+	MemoryOff      MemoryOperation = "off"
+	MemoryDivide   MemoryOperation = "div"
+	MemorySubtract MemoryOperation = "sub"
+	MemoryAdd      MemoryOperation = "add"
+)
+
+// averager накапливает комплексные (векторные) средние по свипам.
+type averager struct {
+	config  AveragingConfig
+	history []VNAData
+	average VNAData
+	count   int
+}
+
+func (a *averager) reset() {
+	a.history = nil
+	a.average = VNAData{}
+	a.count = 0
+}
+
+func (a *averager) add(data VNAData) VNAData {
+	if a.count > 0 && !sameShape(a.average, data) {
+		a.reset()
+	}
+	a.count++
+	switch a.config.Mode {
+	case AveragingRunning:
+		a.history = append(a.history, data.clone())
+		if len(a.history) > a.config.Count {
+			a.history = a.history[1:]
+		}
+		sum := a.history[0].clone()
+		for _, sweep := range a.history[1:] {
+			combineData(&sum, sweep, func(x, y complex128) complex128 { return x + y })
+		}
+		scale := complex(1/float64(len(a.history)), 0)
+		combineData(&sum, sum, func(x, _ complex128) complex128 { return x * scale })
+		a.average = sum
+	default:
+		if a.count == 1 {
+			a.average = data.clone()
+			break
+		}
+		k := a.count
+		if k > a.config.Count {
+			k = a.config.Count
+		}
+		weight := complex(1/float64(k), 0)
+		combineData(&a.average, data, func(avg, x complex128) complex128 { return avg + (x-avg)*weight })
+	}
+	return a.average.clone()
+}
+
+// Averager - усреднение между свипами, состояние которого хранит вызывающая сторона, например
+// сервер для каждого клиента устройства. Averager передается в MeasureRequest.Averaging и
+// StreamOptions.Averaging: среднее продолжается от вызова к вызову и от кадра к кадру и
+// сбрасывается при смене сетки частот, калибровки, расширения портов или опорного импеданса.
+// Averager безопасен для одновременного использования.
+type Averager struct {
+	mu       sync.Mutex
+	averager averager
+	key      averagingKey
+}
+
+// averagingKey - настройки, при которых накоплено среднее Averager.
+type averagingKey struct {
+	vna         *VNA
+	corrections uint64
+	calibration *CalibrationProfile
+	sweep       SweepConfig
+}
+
+func NewAverager(config AveragingConfig) (*Averager, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &Averager{averager: averager{config: config}}, nil
+}
+
+// Config возвращает режим и число усреднений.
+func (a *Averager) Config() AveragingConfig {
+	return a.averager.config
+}
+
+// Sweeps возвращает число свипов в текущем среднем.
+func (a *Averager) Sweeps() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.averager.sweeps()
+}
+
+// Reset начинает накопление среднего заново.
+func (a *Averager) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.averager.reset()
+}
+
+// add добавляет свип, полученный с настройками key, и возвращает среднее.
+func (a *Averager) add(key averagingKey, data VNAData) VNAData {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if key != a.key {
+		a.averager.reset()
+		a.key = key
+	}
+	return a.averager.add(data)
+}
+
+// averagingKeyLocked описывает текущие настройки VNA для Averager. Вызывается с захваченным v.mu.
+func (v *VNA) averagingKeyLocked(calibration *CalibrationProfile) averagingKey {
+	return averagingKey{vna: v, corrections: v.corrections, calibration: calibration, sweep: v.sweep}
+}
+
+// Sweeps возвращает число свипов, вошедших в текущее среднее.
+func (a *averager) sweeps() int {
+	if a.config.Mode == AveragingRunning {
+		return len(a.history)
+	}
+	if a.count > a.config.Count {
+		return a.config.Count
+	}
+	return a.count
+}
+
+func sameShape(a, b VNAData) bool {
+	return frequenciesMatch(a.Frequencies, b.Frequencies) &&
+		len(a.S11) == len(b.S11) && len(a.S21) == len(b.S21) &&
+		len(a.S12) == len(b.S12) && len(a.S22) == len(b.S22)
+}
+
+// combineData поэлементно применяет op к S-параметрам dst и src, результат записывается в dst.
+func combineData(dst *VNAData, src VNAData, op func(x, y complex128) complex128) {
+	pairs := [][2][]complex128{{dst.S11, src.S11}, {dst.S21, src.S21}, {dst.S12, src.S12}, {dst.S22, src.S22}}
+	for _, pair := range pairs {
+		for i := range pair[0] {
+			if i < len(pair[1]) {
+				pair[0][i] = op(pair[0][i], pair[1][i])
+			}
+		}
+	}
+}
+
+// Smooth сглаживает комплексные S-параметры скользящим средним по aperture соседним точкам.
+// Апертура округляется до нечетного числа; на краях окно укорачивается симметрично.
+func (d *VNAData) Smooth(aperture int) (VNAData, error) {
+	if aperture < 1 {
+		return VNAData{}, errors.New("апертура сглаживания должна быть положительной")
+	}
+	result := d.clone()
+	for _, pair := range [][2][]complex128{{result.S11, d.S11}, {result.S21, d.S21}, {result.S12, d.S12}, {result.S22, d.S22}} {
+		smoothComplex(pair[0], pair[1], aperture/2)
+	}
+	return result, nil
+}
+
+func smoothComplex(dst, src []complex128, half int) {
+	for i := range src {
+		h := half
+		if i < h {
+			h = i
+		}
+		if len(src)-1-i < h {
+			h = len(src) - 1 - i
+		}
+		var sum complex128
+		for j := i - h; j <= i+h; j++ {
+			sum += src[j]
+		}
+		dst[i] = sum / complex(float64(2*h+1), 0)
+	}
+}
+
+// SmoothTrace сглаживает отформатированную трассу скользящим средним.
+func SmoothTrace(trace []float64, aperture int) []float64 {
+	half := aperture / 2
+	out := make([]float64, len(trace))
+	for i := range trace {
+		h := half
+		if i < h {
+			h = i
+		}
+		if len(trace)-1-i < h {
+			h = len(trace) - 1 - i
+		}
+		sum := 0.0
+		for j := i - h; j <= i+h; j++ {
+			sum += trace[j]
+		}
+		out[i] = sum / float64(2*h+1)
+	}
+	return out
+}
+
+// ApplyMemory выполняет векторную математику текущих данных с трассой памяти.
+func (d *VNAData) ApplyMemory(memory VNAData, op MemoryOperation) (VNAData, error) {
+	if op == MemoryOff || op == "" {
+		return d.clone(), nil
+	}
+	if !sameShape(*d, memory) {
+		return VNAData{}, errors.New("трасса памяти не совпадает с данными по частотной сетке или составу параметров")
+	}
+	var fn func(x, y complex128) complex128
+	switch op {
+	case MemoryDivide:
+		fn = func(x, y complex128) complex128 {
+			if y == 0 {
+				return 0
+			}
+			return x / y
+		}
+	case MemorySubtract:
+		fn = func(x, y complex128) complex128 { return x - y }
+	case MemoryAdd:
+		fn = func(x, y complex128) complex128 { return x + y }
+	default:
+		return VNAData{}, fmt.Errorf("неизвестная операция с памятью %q", op)
+	}
+	result := d.clone()
+	combineData(&result, memory, fn)
+	return result, nil
+}
+
+// SetAveraging включает усреднение свипов в GetData. Накопленное среднее сбрасывается.
+func (v *VNA) SetAveraging(config AveragingConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.averaging = &averager{config: config}
+	return nil
+}
+
+func (v *VNA) ClearAveraging() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.averaging = nil
+}
+
+// ResetAveraging начинает накопление среднего заново.
+func (v *VNA) ResetAveraging() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.averaging != nil {
+		v.averaging.reset()
+	}
+}
+
+// resetAveragingLocked сбрасывает среднее после смены коррекции данных. Вызывается с захваченным v.mu.
+func (v *VNA) resetAveragingLocked() {
+	v.corrections++
+	if v.averaging != nil {
+		v.averaging.reset()
+	}
+}
+
+// AveragedSweeps возвращает число свипов в текущем среднем (0, если усреднение выключено).
+func (v *VNA) AveragedSweeps() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.averaging == nil {
+		return 0
+	}
+	return v.averaging.sweeps()
+}
+
+// SetSmoothing включает сглаживание по точкам с апертурой aperture; 0 выключает сглаживание.
+func (v *VNA) SetSmoothing(aperture int) error {
+	if aperture < 0 {
+		return errors.New("апертура сглаживания не может быть отрицательной")
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.smoothing = aperture
+	return nil
+}
+
+// StoreMemory сохраняет последний скан (LastScan, до математики с памятью) как трассу памяти.
+func (v *VNA) StoreMemory() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.lastScan == nil {
+		return errors.New("нет данных для сохранения в память: выполните сканирование")
+	}
+	memory := v.lastScan.clone()
+	v.memory = &memory
+	return nil
+}
+
+// SetMemoryMath задает операцию с трассой памяти, применяемую в GetData.
+func (v *VNA) SetMemoryMath(op MemoryOperation) error {
+	switch op {
+	case MemoryOff, MemoryDivide, MemorySubtract, MemoryAdd:
+	default:
+		return fmt.Errorf("неизвестная операция с памятью %q", op)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if op != MemoryOff && v.memory == nil {
+		return errors.New("трасса памяти не сохранена")
+	}
+	v.memoryMath = op
+	return nil
+}
+
+func (v *VNA) ClearMemory() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.memory = nil
+	v.memoryMath = MemoryOff
+}
+
+// Memory возвращает копию трассы памяти.
+func (v *VNA) Memory() (VNAData, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.memory == nil {
+		return VNAData{}, false
+	}
+	return v.memory.clone(), true
+}
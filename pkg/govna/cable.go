@@ -0,0 +1,202 @@
+// Этот файл содержит режим DTF (distance-to-fault) и утилиты для характеризации кабелей.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// DefaultFaultThreshold - порог обнаружения неоднородностей по возвратным потерям, дБ.
+const DefaultFaultThreshold = 25.0
+
+// DTFConfig задает параметры режима DTF. LossPerMeter - затухание кабеля в дБ/м в одну сторону;
+// отклик на расстоянии x компенсируется на 2·x·LossPerMeter.
+type DTFConfig struct {
+	VelocityFactor float64
+	LossPerMeter   float64
+	MaxDistance    float64
+	Points         int
+	Window         WindowType
+	Threshold      float64
+}
+
+// Fault описывает обнаруженную неоднородность линии.
+type Fault struct {
+	Distance   float64
+	ReturnLoss float64
+	Reflection float64
+}
+
+type DTFResult struct {
+	Distance   []float64
+	Reflection []float64
+	ReturnLoss []float64
+	Faults     []Fault
+}
+
+// DistanceToFault преобразует свип S11 в возвратные потери в зависимости от расстояния
+// и возвращает список неоднородностей, превышающих порог, в порядке убывания отражения.
+func (d *VNAData) DistanceToFault(cfg DTFConfig) (DTFResult, error) {
+	if cfg.VelocityFactor == 0 {
+		cfg.VelocityFactor = 1
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = DefaultFaultThreshold
+	}
+	if cfg.LossPerMeter < 0 {
+		return DTFResult{}, errors.New("затухание кабеля не может быть отрицательным")
+	}
+	step, err := uniformStep(d.Frequencies)
+	if err != nil {
+		return DTFResult{}, err
+	}
+	propagation := SpeedOfLight * cfg.VelocityFactor / 2
+	if cfg.MaxDistance == 0 {
+		cfg.MaxDistance = propagation / step
+	}
+
+	td, err := d.TimeDomain(TimeDomainConfig{
+		Parameter:      S11,
+		Mode:           TimeDomainBandPass,
+		Window:         cfg.Window,
+		Stop:           cfg.MaxDistance / propagation,
+		Points:         cfg.Points,
+		VelocityFactor: cfg.VelocityFactor,
+	})
+	if err != nil {
+		return DTFResult{}, err
+	}
+
+	result := DTFResult{
+		Distance:   td.Distance,
+		Reflection: make([]float64, len(td.Distance)),
+		ReturnLoss: make([]float64, len(td.Distance)),
+	}
+	for i, x := range td.Distance {
+		gamma := td.Reflection[i] * math.Pow(10, 2*x*cfg.LossPerMeter/20)
+		result.Reflection[i] = gamma
+		result.ReturnLoss[i] = -20 * math.Log10(gamma)
+	}
+
+	limit := math.Pow(10, -cfg.Threshold/20)
+	for i, gamma := range result.Reflection {
+		if gamma < limit {
+			continue
+		}
+		if i > 0 && result.Reflection[i-1] >= gamma {
+			continue
+		}
+		if i < len(result.Reflection)-1 && result.Reflection[i+1] > gamma {
+			continue
+		}
+		result.Faults = append(result.Faults, Fault{
+			Distance:   result.Distance[i],
+			ReturnLoss: result.ReturnLoss[i],
+			Reflection: gamma,
+		})
+	}
+	sort.Slice(result.Faults, func(i, j int) bool {
+		return result.Faults[i].Reflection > result.Faults[j].Reflection
+	})
+	return result, nil
+}
+
+// PhaseDelay оценивает задержку по наклону развернутой фазы. Для S21 это задержка прохождения,
+// для S11/S22 (кабель с open или short на конце) - задержка в одну сторону.
+func (d *VNAData) PhaseDelay(p SParameter) (float64, error) {
+	values, err := d.Parameter(p)
+	if err != nil {
+		return 0, err
+	}
+	omegas := make([]float64, len(values))
+	for i, f := range d.Frequencies {
+		omegas[i] = 2 * math.Pi * f
+	}
+	slope, _, err := linearFit(omegas, d.unwrappedPhase(values))
+	if err != nil {
+		return 0, err
+	}
+	delay := -slope
+	if p == S11 || p == S22 {
+		delay /= 2
+	}
+	return delay, nil
+}
+
+// ElectricalLength возвращает электрическую длину (в метрах при скорости света) по наклону фазы.
+func (d *VNAData) ElectricalLength(p SParameter) (float64, error) {
+	delay, err := d.PhaseDelay(p)
+	if err != nil {
+		return 0, err
+	}
+	return delay * SpeedOfLight, nil
+}
+
+// VelocityFactor вычисляет коэффициент укорочения кабеля известной физической длины.
+func (d *VNAData) VelocityFactor(p SParameter, physicalLength float64) (float64, error) {
+	if physicalLength <= 0 {
+		return 0, errors.New("физическая длина кабеля должна быть положительной")
+	}
+	electrical, err := d.ElectricalLength(p)
+	if err != nil {
+		return 0, err
+	}
+	if electrical <= 0 {
+		return 0, errors.New("не удалось определить электрическую длину: неположительная задержка")
+	}
+	return physicalLength / electrical, nil
+}
+
+// CableImpedance вычисляет волновое сопротивление кабеля Z0 = sqrt(Zopen·Zshort)
+// по измерениям S11 с разомкнутым и замкнутым дальним концом.
+func CableImpedance(open, short VNAData) ([]complex128, error) {
+	if !frequenciesMatch(open.Frequencies, short.Frequencies) {
+		return nil, errors.New("частотные сетки измерений open и short не совпадают")
+	}
+	zOpen, err := open.Impedance(S11)
+	if err != nil {
+		return nil, fmt.Errorf("измерение open: %w", err)
+	}
+	zShort, err := short.Impedance(S11)
+	if err != nil {
+		return nil, fmt.Errorf("измерение short: %w", err)
+	}
+	z0 := make([]complex128, len(zOpen))
+	for i := range zOpen {
+		z := cmplx.Sqrt(zOpen[i] * zShort[i])
+		if real(z) < 0 {
+			z = -z
+		}
+		z0[i] = z
+	}
+	return z0, nil
+}
+
+// LossPerMeterFromS21 вычисляет затухание кабеля в дБ/м по коэффициенту передачи.
+func (d *VNAData) LossPerMeterFromS21(length float64) ([]float64, error) {
+	return d.lossPerMeter(S21, length, 1)
+}
+
+// LossPerMeterFromOpenS11 вычисляет затухание кабеля в дБ/м по отражению от разомкнутого конца
+// (сигнал проходит кабель дважды).
+func (d *VNAData) LossPerMeterFromOpenS11(length float64) ([]float64, error) {
+	return d.lossPerMeter(S11, length, 2)
+}
+
+func (d *VNAData) lossPerMeter(p SParameter, length, passes float64) ([]float64, error) {
+	if length <= 0 {
+		return nil, errors.New("длина кабеля должна быть положительной")
+	}
+	values, err := d.Parameter(p)
+	if err != nil {
+		return nil, err
+	}
+	loss := make([]float64, len(values))
+	for i, v := range values {
+		loss[i] = -20 * math.Log10(cmplx.Abs(v)) / (passes * length)
+	}
+	return loss, nil
+}
@@ -0,0 +1,106 @@
+package govna
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestVNAData_DistanceToFault(t *testing.T) {
+	const vf = 0.66
+	const distance = 12.0
+	data := delayedReflection(SweepConfig{Start: 2e6, Stop: 1e9, Points: 401}, 0.3, distance/(SpeedOfLight*vf))
+
+	result, err := data.DistanceToFault(DTFConfig{VelocityFactor: vf, MaxDistance: 40, Points: 801})
+	if err != nil {
+		t.Fatalf("DistanceToFault failed: %v", err)
+	}
+	if len(result.Faults) == 0 {
+		t.Fatalf("expected at least one fault")
+	}
+	fault := result.Faults[0]
+	if math.Abs(fault.Distance-distance) > 0.1 {
+		t.Fatalf("expected fault at %g m, got %g m", distance, fault.Distance)
+	}
+	if math.Abs(fault.ReturnLoss+20*math.Log10(0.3)) > 0.3 {
+		t.Fatalf("expected return loss %.2f dB, got %.2f dB", -20*math.Log10(0.3), fault.ReturnLoss)
+	}
+
+	estimated, err := data.VelocityFactor(S11, distance)
+	if err != nil {
+		t.Fatalf("VelocityFactor failed: %v", err)
+	}
+	if math.Abs(estimated-vf) > 1e-6 {
+		t.Fatalf("expected velocity factor %g, got %g", vf, estimated)
+	}
+}
+
+// lossyLine возвращает постоянную распространения γ·l линии длиной length с затуханием lossDB дБ/м.
+func lossyLine(f, length, lossDB, vf float64) complex128 {
+	alpha := lossDB / (20 * math.Log10(math.E))
+	beta := 2 * math.Pi * f / (SpeedOfLight * vf)
+	return complex(alpha*length, beta*length)
+}
+
+func TestCableImpedance(t *testing.T) {
+	const z0 = 75.0
+	const length = 3.0
+	open := VNAData{Frequencies: make([]float64, 101), S11: make([]complex128, 101)}
+	short := VNAData{Frequencies: open.Frequencies, S11: make([]complex128, 101)}
+	for i := range open.Frequencies {
+		f := 1e6 + float64(i)*1e6
+		open.Frequencies[i] = f
+		gl := lossyLine(f, length, 0.2, 0.66)
+		zOpen := z0 / cmplx.Tanh(gl)
+		zShort := z0 * cmplx.Tanh(gl)
+		open.S11[i] = (zOpen - 50) / (zOpen + 50)
+		short.S11[i] = (zShort - 50) / (zShort + 50)
+	}
+
+	got, err := CableImpedance(open, short)
+	if err != nil {
+		t.Fatalf("CableImpedance failed: %v", err)
+	}
+	for i, z := range got {
+		if cmplx.Abs(z-z0) > 1e-6 {
+			t.Fatalf("point %d: expected %g Ohm, got %v", i, z0, z)
+		}
+	}
+
+	short.Frequencies = open.Frequencies[:50]
+	if _, err := CableImpedance(open, short); err == nil {
+		t.Fatalf("expected error for mismatched frequency grids")
+	}
+}
+
+func TestVNAData_LossPerMeter(t *testing.T) {
+	const length = 5.0
+	const lossDB = 0.35
+	data := VNAData{Frequencies: make([]float64, 51), S11: make([]complex128, 51), S21: make([]complex128, 51)}
+	for i := range data.Frequencies {
+		f := 10e6 + float64(i)*10e6
+		data.Frequencies[i] = f
+		gl := lossyLine(f, length, lossDB, 0.8)
+		// Согласованная линия: S21 = e^(-γl), отражение от разомкнутого конца S11 = e^(-2γl).
+		data.S21[i] = cmplx.Exp(-gl)
+		data.S11[i] = cmplx.Exp(-2 * gl)
+	}
+
+	fromS21, err := data.LossPerMeterFromS21(length)
+	if err != nil {
+		t.Fatalf("LossPerMeterFromS21 failed: %v", err)
+	}
+	fromS11, err := data.LossPerMeterFromOpenS11(length)
+	if err != nil {
+		t.Fatalf("LossPerMeterFromOpenS11 failed: %v", err)
+	}
+	for i := range data.Frequencies {
+		if math.Abs(fromS21[i]-lossDB) > 1e-9 || math.Abs(fromS11[i]-lossDB) > 1e-9 {
+			t.Fatalf("point %d: expected %g dB/m, got %g (S21) and %g (S11)", i, lossDB, fromS21[i], fromS11[i])
+		}
+	}
+
+	if _, err := data.LossPerMeterFromS21(0); err == nil {
+		t.Fatalf("expected error for non-positive cable length")
+	}
+}
@@ -2,9 +2,12 @@ package govna
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"sync"
 	"time"
 )
 
@@ -61,6 +64,58 @@ func (v *VNA) AcquireCalibration(ctx context.Context, plan CalibrationPlan, prom
 	if ctx == nil {
 		ctx = v.ctx
 	}
+	session, err := v.StartCalibration(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		step, ok := session.Next()
+		if !ok {
+			break
+		}
+		if prompt != nil {
+			if err := prompt(ctx, step.Standard); err != nil {
+				return nil, err
+			}
+		}
+		if err := session.Measure(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	profile, err := session.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	v.setCalibrationLocked(profile)
+	v.mu.Unlock()
+
+	return profile, nil
+}
+
+// ErrCalibrationBusy возвращается, если эталон сессии калибровки уже измеряется.
+var ErrCalibrationBusy = errors.New("эталон калибровки уже измеряется")
+
+// CalibrationSession - пошаговая калибровка для клиентов, которые не могут передать
+// CalibrationPrompt (например, REST): Next сообщает следующий эталон, Measure измеряет его
+// после подключения, Finish рассчитывает профиль. Сессия не загружает профиль в устройство.
+// Методы можно вызывать из нескольких горутин: Measure сканирует, не блокируя сессию,
+// а повторный Measure или Finish во время измерения возвращает ErrCalibrationBusy.
+type CalibrationSession struct {
+	vna  *VNA
+	plan CalibrationPlan
+
+	mu        sync.Mutex
+	profile   *CalibrationProfile
+	step      int
+	measuring bool
+}
+
+// StartCalibration проверяет план и настраивает сканирование устройства для калибровки.
+func (v *VNA) StartCalibration(plan CalibrationPlan) (*CalibrationSession, error) {
 	if len(plan.Steps) == 0 {
 		return nil, errors.New("план калибровки не содержит шагов")
 	}
@@ -72,54 +127,116 @@ func (v *VNA) AcquireCalibration(ctx context.Context, plan CalibrationPlan, prom
 		return nil, err
 	}
 
-	profile := &CalibrationProfile{
-		Name:      plan.Name,
-		Method:    CalibrationMethodSOL,
-		CreatedAt: time.Now(),
-		Sweep:     plan.Sweep,
-		Standards: make(map[CalibrationStandard]CalibrationMeasurement),
-	}
+	return &CalibrationSession{
+		vna:  v,
+		plan: plan,
+		profile: &CalibrationProfile{
+			Name:      plan.Name,
+			Method:    CalibrationMethodSOL,
+			CreatedAt: time.Now(),
+			Sweep:     plan.Sweep,
+			Standards: make(map[CalibrationStandard]CalibrationMeasurement),
+		},
+	}, nil
+}
 
-	for _, step := range plan.Steps {
-		if prompt != nil {
-			if err := prompt(ctx, step.Standard); err != nil {
-				return nil, err
-			}
-		}
+func (s *CalibrationSession) Plan() CalibrationPlan {
+	return s.plan
+}
 
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
+// Completed возвращает число измеренных шагов плана.
+func (s *CalibrationSession) Completed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.step
+}
 
-		v.mu.Lock()
-		data, err := v.driver.Scan()
-		v.mu.Unlock()
-		if err != nil {
-			return nil, fmt.Errorf("ошибка получения данных для эталона %s: %w", step.Standard, err)
-		}
+// Next возвращает следующий шаг плана; false означает, что все эталоны измерены.
+func (s *CalibrationSession) Next() (CalibrationStep, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextLocked()
+}
 
-		profile.Standards[step.Standard] = CalibrationMeasurement{
-			Frequencies: cloneFloat64Slice(data.Frequencies),
-			S11:         cloneComplexSlice(data.S11),
-			S21:         cloneComplexSlice(data.S21),
-		}
+func (s *CalibrationSession) nextLocked() (CalibrationStep, bool) {
+	if s.step >= len(s.plan.Steps) {
+		return CalibrationStep{}, false
 	}
+	return s.plan.Steps[s.step], true
+}
 
-	if err := profile.computeErrorTerms(); err != nil {
-		return nil, err
+// Measure измеряет эталон следующего шага. Если между шагами параметры сканирования
+// устройства изменили, перед измерением восстанавливаются параметры плана.
+func (s *CalibrationSession) Measure(ctx context.Context) error {
+	s.mu.Lock()
+	step, ok := s.nextLocked()
+	switch {
+	case !ok:
+		s.mu.Unlock()
+		return errors.New("все эталоны плана калибровки уже измерены")
+	case s.measuring:
+		s.mu.Unlock()
+		return ErrCalibrationBusy
 	}
-
-	if err := profile.Validate(); err != nil {
-		return nil, err
+	s.measuring = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.measuring = false
+		s.mu.Unlock()
+	}()
+
+	v := s.vna
+	if ctx == nil {
+		ctx = v.ctx
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
 	}
 
 	v.mu.Lock()
-	v.calibration = profile
+	if !v.sweep.sameGrid(s.plan.Sweep) {
+		if err := v.applySweepLocked(s.plan.Sweep); err != nil {
+			v.mu.Unlock()
+			return err
+		}
+	}
+	data, err := v.driverScanLocked()
 	v.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("ошибка получения данных для эталона %s: %w", step.Standard, err)
+	}
 
-	return profile, nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profile.Standards[step.Standard] = CalibrationMeasurement{
+		Frequencies: cloneFloat64Slice(data.Frequencies),
+		S11:         cloneComplexSlice(data.S11),
+		S21:         cloneComplexSlice(data.S21),
+	}
+	s.step++
+	return nil
+}
+
+// Finish рассчитывает коэффициенты ошибок по измеренным эталонам и возвращает профиль.
+func (s *CalibrationSession) Finish() (*CalibrationProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.measuring {
+		return nil, ErrCalibrationBusy
+	}
+	if step, ok := s.nextLocked(); ok {
+		return nil, fmt.Errorf("эталон %s не измерен", step.Standard)
+	}
+	if err := s.profile.computeErrorTerms(); err != nil {
+		return nil, err
+	}
+	if err := s.profile.Validate(); err != nil {
+		return nil, err
+	}
+	return s.profile, nil
 }
 
 func (p *CalibrationProfile) computeErrorTerms() error {
@@ -210,11 +327,7 @@ func (p *CalibrationProfile) apply(data VNAData) (VNAData, error) {
 		}
 	}
 
-	calibrated := VNAData{
-		Frequencies: cloneFloat64Slice(data.Frequencies),
-		S11:         make([]complex128, len(data.S11)),
-		S21:         cloneComplexSlice(data.S21),
-	}
+	calibrated := data.clone()
 
 	for i, measurement := range data.S11 {
 		e00 := p.ErrorTerms.Directivity[i]
@@ -232,6 +345,120 @@ func (p *CalibrationProfile) apply(data VNAData) (VNAData, error) {
 	return calibrated, nil
 }
 
+type jsonCalibrationMeasurement struct {
+	Frequencies []float64     `json:"frequencies"`
+	S11         []jsonComplex `json:"s11"`
+	S21         []jsonComplex `json:"s21,omitempty"`
+}
+
+type jsonCalibrationErrorTerms struct {
+	Directivity        []jsonComplex `json:"directivity"`
+	SourceMatch        []jsonComplex `json:"source_match"`
+	ReflectionTracking []jsonComplex `json:"reflection_tracking"`
+}
+
+type jsonCalibrationProfile struct {
+	Name        string                                             `json:"name"`
+	Method      CalibrationMethod                                  `json:"method"`
+	CreatedAt   time.Time                                          `json:"created_at"`
+	Sweep       SweepConfig                                        `json:"sweep"`
+	Frequencies []float64                                          `json:"frequencies"`
+	Standards   map[CalibrationStandard]jsonCalibrationMeasurement `json:"standards,omitempty"`
+	ErrorTerms  *jsonCalibrationErrorTerms                         `json:"error_terms,omitempty"`
+}
+
+// WriteJSON записывает профиль вместе с измерениями эталонов и коэффициентами ошибок;
+// комплексные значения записываются объектами {"re", "im"}.
+func (p *CalibrationProfile) WriteJSON(w io.Writer) error {
+	out := jsonCalibrationProfile{
+		Name:        p.Name,
+		Method:      p.Method,
+		CreatedAt:   p.CreatedAt,
+		Sweep:       p.Sweep,
+		Frequencies: p.Frequencies,
+		ErrorTerms: &jsonCalibrationErrorTerms{
+			Directivity:        toJSONComplex(p.ErrorTerms.Directivity),
+			SourceMatch:        toJSONComplex(p.ErrorTerms.SourceMatch),
+			ReflectionTracking: toJSONComplex(p.ErrorTerms.ReflectionTracking),
+		},
+	}
+	if len(p.Standards) > 0 {
+		out.Standards = make(map[CalibrationStandard]jsonCalibrationMeasurement, len(p.Standards))
+		for standard, m := range p.Standards {
+			out.Standards[standard] = jsonCalibrationMeasurement{
+				Frequencies: m.Frequencies,
+				S11:         toJSONComplex(m.S11),
+				S21:         toJSONComplex(m.S21),
+			}
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// LoadCalibrationJSON читает профиль, записанный WriteJSON. Если коэффициенты ошибок не заданы,
+// они рассчитываются по измерениям эталонов.
+func LoadCalibrationJSON(r io.Reader) (*CalibrationProfile, error) {
+	var in jsonCalibrationProfile
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("ошибка разбора калибровки JSON: %w", err)
+	}
+	profile := &CalibrationProfile{
+		Name:        in.Name,
+		Method:      in.Method,
+		CreatedAt:   in.CreatedAt,
+		Sweep:       in.Sweep,
+		Frequencies: in.Frequencies,
+		Standards:   make(map[CalibrationStandard]CalibrationMeasurement, len(in.Standards)),
+	}
+	if profile.Method == "" {
+		profile.Method = CalibrationMethodSOL
+	}
+	for standard, m := range in.Standards {
+		profile.Standards[standard] = CalibrationMeasurement{
+			Frequencies: m.Frequencies,
+			S11:         fromJSONComplex(m.S11),
+			S21:         fromJSONComplex(m.S21),
+		}
+	}
+	if in.ErrorTerms != nil {
+		profile.ErrorTerms = CalibrationErrorTerms{
+			Directivity:        fromJSONComplex(in.ErrorTerms.Directivity),
+			SourceMatch:        fromJSONComplex(in.ErrorTerms.SourceMatch),
+			ReflectionTracking: fromJSONComplex(in.ErrorTerms.ReflectionTracking),
+		}
+	} else if err := profile.computeErrorTerms(); err != nil {
+		return nil, err
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func toJSONComplex(values []complex128) []jsonComplex {
+	if values == nil {
+		return nil
+	}
+	out := make([]jsonComplex, len(values))
+	for i, v := range values {
+		out[i] = jsonComplex{real(v), imag(v)}
+	}
+	return out
+}
+
+func fromJSONComplex(values []jsonComplex) []complex128 {
+	if values == nil {
+		return nil
+	}
+	out := make([]complex128, len(values))
+	for i, v := range values {
+		out[i] = complex(v.Re, v.Im)
+	}
+	return out
+}
+
 func cloneFloat64Slice(src []float64) []float64 {
 	if src == nil {
 		return nil
@@ -0,0 +1,110 @@
+// Этот файл содержит описание возможностей устройств и проверку параметров сканирования по ним.
+package govna
+
+import "fmt"
+
+type SweepType string
+
+// SweepLinear - равномерная сетка частот; пустой SweepConfig.Type означает линейное сканирование.
+// Поддерживаемые драйверы задают сетку началом и шагом, поэтому других типов нет, а
+// ValidateSweep отклоняет любые значения, не перечисленные в DeviceCapabilities.SweepTypes.
+const SweepLinear SweepType = "linear"
+
+// DeviceCapabilities описывает допустимые параметры сканирования устройства.
+// Нулевые MaxFrequency и MaxPoints означают отсутствие ограничения.
+type DeviceCapabilities struct {
+	Model        string      `json:"model,omitempty"`
+	MinFrequency float64     `json:"min_frequency"`
+	MaxFrequency float64     `json:"max_frequency,omitempty"`
+	MinPoints    int         `json:"min_points"`
+	MaxPoints    int         `json:"max_points,omitempty"`
+	SweepTypes   []SweepType `json:"sweep_types"`
+}
+
+// CapabilitiesProvider реализуется драйверами, которые знают ограничения своего устройства.
+type CapabilitiesProvider interface {
+	Capabilities() DeviceCapabilities
+}
+
+// DefaultCapabilities используются для драйверов, не реализующих CapabilitiesProvider.
+var DefaultCapabilities = DeviceCapabilities{MinPoints: 1, SweepTypes: []SweepType{SweepLinear}}
+
+// Capabilities возвращает ограничения NanoVNA V1: 50 кГц - 900 МГц, не более 101 точки.
+func (d *V1Driver) Capabilities() DeviceCapabilities {
+	return DeviceCapabilities{
+		Model:        "NanoVNA V1",
+		MinFrequency: 50e3,
+		MaxFrequency: 900e6,
+		MinPoints:    1,
+		MaxPoints:    101,
+		SweepTypes:   []SweepType{SweepLinear},
+	}
+}
+
+// Capabilities возвращает ограничения NanoVNA V2/LiteVNA: 50 кГц - 4.4 ГГц, не более 1024 точек.
+// Протокол V2 задает сетку началом и шагом, поэтому поддерживается только линейное сканирование.
+func (d *V2Driver) Capabilities() DeviceCapabilities {
+	return DeviceCapabilities{
+		Model:        "NanoVNA V2",
+		MinFrequency: 50e3,
+		MaxFrequency: 4.4e9,
+		MinPoints:    1,
+		MaxPoints:    1024,
+		SweepTypes:   []SweepType{SweepLinear},
+	}
+}
+
+// SweepError сообщает, какой параметр сканирования не прошел проверку.
+type SweepError struct {
+	Field   string
+	Message string
+}
+
+func (e *SweepError) Error() string {
+	return fmt.Sprintf("некорректный параметр сканирования %s: %s", e.Field, e.Message)
+}
+
+// ValidateSweep проверяет параметры сканирования по возможностям устройства.
+// Возвращает *SweepError с именем поля: start, stop, points или type.
+func (c DeviceCapabilities) ValidateSweep(config SweepConfig) error {
+	if config.Start >= config.Stop {
+		return &SweepError{Field: "stop", Message: "конечная частота должна быть больше начальной"}
+	}
+	if config.Start < c.MinFrequency {
+		return &SweepError{Field: "start", Message: fmt.Sprintf("частота меньше минимальной %.0f Гц", c.MinFrequency)}
+	}
+	if c.MaxFrequency > 0 && config.Stop > c.MaxFrequency {
+		return &SweepError{Field: "stop", Message: fmt.Sprintf("частота больше максимальной %.0f Гц", c.MaxFrequency)}
+	}
+	if config.Points < max(c.MinPoints, 1) {
+		return &SweepError{Field: "points", Message: fmt.Sprintf("количество точек меньше %d", max(c.MinPoints, 1))}
+	}
+	if c.MaxPoints > 0 && config.Points > c.MaxPoints {
+		return &SweepError{Field: "points", Message: fmt.Sprintf("количество точек больше %d", c.MaxPoints)}
+	}
+	if !c.Supports(config.Type) {
+		return &SweepError{Field: "type", Message: fmt.Sprintf("тип сканирования %q не поддерживается устройством", config.Type)}
+	}
+	return nil
+}
+
+// Supports сообщает, поддерживает ли устройство тип сканирования.
+func (c DeviceCapabilities) Supports(sweepType SweepType) bool {
+	if sweepType == "" {
+		sweepType = SweepLinear
+	}
+	for _, t := range c.SweepTypes {
+		if t == sweepType {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities возвращает ограничения подключенного устройства.
+func (v *VNA) Capabilities() DeviceCapabilities {
+	if provider, ok := v.driver.(CapabilitiesProvider); ok {
+		return provider.Capabilities()
+	}
+	return DefaultCapabilities
+}
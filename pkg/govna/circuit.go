@@ -0,0 +1,351 @@
+// Этот файл содержит аппроксимацию однопортовой нагрузки эквивалентными схемами на сосредоточенных элементах.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+type CircuitTopology string
+
+const (
+	CircuitSeriesRLC   CircuitTopology = "series_rlc"
+	CircuitParallelRLC CircuitTopology = "parallel_rlc"
+	CircuitSeriesRL    CircuitTopology = "series_rl"
+	CircuitSeriesRC    CircuitTopology = "series_rc"
+	CircuitParallelRL  CircuitTopology = "parallel_rl"
+	CircuitParallelRC  CircuitTopology = "parallel_rc"
+	// Двухэлементные лестничные схемы, нагруженные на R; первым указан элемент со стороны порта.
+	CircuitLadderSeriesLShuntC CircuitTopology = "ladder_series_l_shunt_c"
+	CircuitLadderSeriesCShuntL CircuitTopology = "ladder_series_c_shunt_l"
+	CircuitLadderShuntCSeriesL CircuitTopology = "ladder_shunt_c_series_l"
+	CircuitLadderShuntLSeriesC CircuitTopology = "ladder_shunt_l_series_c"
+)
+
+// circuitElements перечисляет элементы каждой топологии в порядке параметров аппроксимации.
+var circuitElements = map[CircuitTopology]string{
+	CircuitSeriesRLC:           "RLC",
+	CircuitParallelRLC:         "RLC",
+	CircuitSeriesRL:            "RL",
+	CircuitSeriesRC:            "RC",
+	CircuitParallelRL:          "RL",
+	CircuitParallelRC:          "RC",
+	CircuitLadderSeriesLShuntC: "RLC",
+	CircuitLadderSeriesCShuntL: "RLC",
+	CircuitLadderShuntCSeriesL: "RLC",
+	CircuitLadderShuntLSeriesC: "RLC",
+}
+
+// Допустимые диапазоны номиналов при аппроксимации по умолчанию.
+var circuitBounds = map[byte][2]float64{
+	'R': {1e-4, 1e7},
+	'L': {1e-15, 1},
+	'C': {1e-18, 1},
+}
+
+// ElementBounds - допустимый диапазон номинала элемента. Нулевая граница заменяется границей
+// по умолчанию из circuitBounds.
+type ElementBounds struct {
+	Min, Max float64
+}
+
+// CircuitFitConfig задает границы номиналов R (Ом), L (Гн) и C (Ф) при аппроксимации.
+// Нулевое значение - диапазоны по умолчанию.
+type CircuitFitConfig struct {
+	R, L, C ElementBounds
+}
+
+func (c CircuitFitConfig) Validate() error {
+	for element, bounds := range c.bounds() {
+		if !(bounds[0] > 0) || !(bounds[1] >= bounds[0]) || math.IsInf(bounds[1], 0) {
+			return fmt.Errorf("некорректные границы номинала %c: [%g, %g]", element, bounds[0], bounds[1])
+		}
+	}
+	return nil
+}
+
+// bounds возвращает границы номиналов с подставленными значениями по умолчанию.
+func (c CircuitFitConfig) bounds() map[byte][2]float64 {
+	result := make(map[byte][2]float64, len(circuitBounds))
+	for element, custom := range map[byte]ElementBounds{'R': c.R, 'L': c.L, 'C': c.C} {
+		bounds := circuitBounds[element]
+		if custom.Min != 0 {
+			bounds[0] = custom.Min
+		}
+		if custom.Max != 0 {
+			bounds[1] = custom.Max
+		}
+		result[element] = bounds
+	}
+	return result
+}
+
+// EquivalentCircuit - результат аппроксимации. Неиспользуемые в топологии элементы равны нулю.
+// Residuals - разность измеренного и модельного S11 на частотах Frequencies.
+type EquivalentCircuit struct {
+	Topology    CircuitTopology
+	R, L, C     float64
+	Z0          complex128
+	Frequencies []float64
+	Residuals   []complex128
+	Quality     FitQuality
+}
+
+// Impedance вычисляет импеданс схемы на частоте freq.
+func (c EquivalentCircuit) Impedance(freq float64) complex128 {
+	return circuitImpedance(c.Topology, 2*math.Pi*freq, c.R, c.L, c.C)
+}
+
+// Evaluate рассчитывает S11 схемы на произвольной сетке частот относительно Z0 аппроксимации.
+// S21 заполняется нулями: модель однопортовая.
+func (c EquivalentCircuit) Evaluate(frequencies []float64) VNAData {
+	z0 := c.Z0
+	if z0 == 0 {
+		z0 = DefaultReferenceImpedance
+	}
+	data := VNAData{
+		Frequencies: cloneFloat64Slice(frequencies),
+		S11:         make([]complex128, len(frequencies)),
+		S21:         make([]complex128, len(frequencies)),
+	}
+	if z0 != DefaultReferenceImpedance {
+		data.Z0 = []complex128{z0}
+	}
+	for i, freq := range frequencies {
+		data.S11[i] = impedanceToReflection(c.Impedance(freq), z0)
+	}
+	return data
+}
+
+func circuitImpedance(topology CircuitTopology, omega, r, l, c float64) complex128 {
+	jw := complex(0, omega)
+	zr := complex(r, 0)
+	zl := jw * complex(l, 0)
+	zc := 1 / (jw * complex(c, 0))
+	parallel := func(a, b complex128) complex128 { return a * b / (a + b) }
+	switch topology {
+	case CircuitSeriesRLC:
+		return zr + zl + zc
+	case CircuitParallelRLC:
+		return 1 / (1/zr + 1/zl + 1/zc)
+	case CircuitSeriesRL:
+		return zr + zl
+	case CircuitSeriesRC:
+		return zr + zc
+	case CircuitParallelRL:
+		return parallel(zr, zl)
+	case CircuitParallelRC:
+		return parallel(zr, zc)
+	case CircuitLadderSeriesLShuntC:
+		return zl + parallel(zr, zc)
+	case CircuitLadderSeriesCShuntL:
+		return zc + parallel(zr, zl)
+	case CircuitLadderShuntCSeriesL:
+		return parallel(zc, zr+zl)
+	case CircuitLadderShuntLSeriesC:
+		return parallel(zl, zr+zc)
+	}
+	return cmplx.NaN()
+}
+
+// FitEquivalentCircuit аппроксимирует S11 выбранной топологией методом Левенберга-Марквардта.
+// Номиналы ищутся в логарифмическом масштабе в пределах границ config; для снижения риска
+// локального минимума аппроксимация запускается из нескольких начальных приближений.
+func (d *VNAData) FitEquivalentCircuit(topology CircuitTopology, config CircuitFitConfig) (EquivalentCircuit, error) {
+	elements, ok := circuitElements[topology]
+	if !ok {
+		return EquivalentCircuit{}, fmt.Errorf("неизвестная топология эквивалентной схемы %q", topology)
+	}
+	if err := config.Validate(); err != nil {
+		return EquivalentCircuit{}, err
+	}
+	bounds := config.bounds()
+	if len(d.S11) != len(d.Frequencies) {
+		return EquivalentCircuit{}, errors.New("размер S11 не совпадает с числом частот")
+	}
+	if len(d.S11) < 2*len(elements) {
+		return EquivalentCircuit{}, fmt.Errorf("для аппроксимации требуется не менее %d точек", 2*len(elements))
+	}
+	for _, freq := range d.Frequencies {
+		if freq <= 0 {
+			return EquivalentCircuit{}, errors.New("частоты должны быть положительными")
+		}
+	}
+
+	z0 := d.ReferenceImpedance(1)
+	values := func(params []float64) (r, l, c float64) {
+		for i, element := range elements {
+			switch element {
+			case 'R':
+				r = math.Exp(params[i])
+			case 'L':
+				l = math.Exp(params[i])
+			case 'C':
+				c = math.Exp(params[i])
+			}
+		}
+		return r, l, c
+	}
+	residual := func(params []float64) []float64 {
+		r, l, c := values(params)
+		out := make([]float64, 0, 2*len(d.S11))
+		for i, freq := range d.Frequencies {
+			model := impedanceToReflection(circuitImpedance(topology, 2*math.Pi*freq, r, l, c), z0)
+			diff := d.S11[i] - model
+			out = append(out, real(diff), imag(diff))
+		}
+		return out
+	}
+
+	lower := make([]float64, len(elements))
+	upper := make([]float64, len(elements))
+	for i := range elements {
+		lower[i] = math.Log(bounds[elements[i]][0])
+		upper[i] = math.Log(bounds[elements[i]][1])
+	}
+
+	var best fitResult
+	found := false
+	for _, start := range d.circuitStarts(topology, elements, z0, bounds) {
+		fit, err := levenbergMarquardt(residual, start, lower, upper)
+		if err != nil {
+			continue
+		}
+		if !found || fit.quality.RMSError < best.quality.RMSError {
+			best, found = fit, true
+		}
+	}
+	if !found {
+		return EquivalentCircuit{}, errors.New("аппроксимация эквивалентной схемой не удалась")
+	}
+
+	result := EquivalentCircuit{
+		Topology:    topology,
+		Z0:          z0,
+		Frequencies: cloneFloat64Slice(d.Frequencies),
+		Residuals:   make([]complex128, len(d.S11)),
+		Quality:     best.quality,
+	}
+	result.R, result.L, result.C = values(best.params)
+	for i := range result.Residuals {
+		result.Residuals[i] = complex(best.residuals[2*i], best.residuals[2*i+1])
+	}
+	return result, nil
+}
+
+// circuitStarts строит начальные приближения (логарифмы номиналов): оценку по измеренному
+// импедансу и ее вариации на декаду вверх и вниз для реактивных элементов, ограниченные bounds.
+func (d *VNAData) circuitStarts(topology CircuitTopology, elements string, z0 complex128, bounds map[byte][2]float64) [][]float64 {
+	n := len(d.S11)
+	omega := make([]float64, n)
+	z := make([]complex128, n)
+	y := make([]complex128, n)
+	for i := range d.S11 {
+		omega[i] = 2 * math.Pi * d.Frequencies[i]
+		z[i] = reflectionToImpedance(d.S11[i], z0)
+		y[i] = 1 / z[i]
+	}
+	omegaC := math.Sqrt(omega[0] * omega[n-1])
+	scale := math.Max(real(z0), 1)
+
+	// Реактивная часть аппроксимируется линейно: X = ω·a - b/ω (для параллельных схем - по проводимости).
+	series := topology == CircuitSeriesRLC || topology == CircuitSeriesRL || topology == CircuitSeriesRC
+	reactive := make([]float64, n)
+	resistive := make([]float64, n)
+	for i := range z {
+		if series {
+			reactive[i], resistive[i] = imag(z[i]), real(z[i])
+		} else {
+			reactive[i], resistive[i] = imag(y[i]), real(y[i])
+		}
+	}
+	a, b := reactanceFit(omega, reactive, elements)
+
+	r := median(resistive)
+	l, c := 0.0, 0.0
+	switch topology {
+	case CircuitSeriesRLC, CircuitSeriesRL, CircuitSeriesRC:
+		l, c = a, 1/b
+	case CircuitParallelRLC, CircuitParallelRL, CircuitParallelRC:
+		r, l, c = 1/r, 1/b, a
+	case CircuitLadderSeriesLShuntC, CircuitLadderSeriesCShuntL:
+		r = 0
+		for i := range z {
+			r = math.Max(r, real(z[i]))
+		}
+	default:
+		r = 0
+		for i := range y {
+			r = math.Max(r, real(y[i]))
+		}
+		r = 1 / r
+	}
+	if !(r > 0) || math.IsInf(r, 0) {
+		r = scale
+	}
+	if !(l > 0) || math.IsInf(l, 0) {
+		l = scale / omegaC
+	}
+	if !(c > 0) || math.IsInf(c, 0) {
+		c = 1 / (omegaC * scale)
+	}
+
+	base := map[byte]float64{'R': r, 'L': l, 'C': c}
+	clamp := func(element byte, value float64) float64 {
+		return math.Log(math.Min(math.Max(value, bounds[element][0]), bounds[element][1]))
+	}
+	var starts [][]float64
+	factors := []float64{1, 0.1, 10}
+	var build func(i int, current []float64)
+	build = func(i int, current []float64) {
+		if i == len(elements) {
+			starts = append(starts, append([]float64(nil), current...))
+			return
+		}
+		element := elements[i]
+		if element == 'R' {
+			build(i+1, append(current, clamp(element, base[element])))
+			return
+		}
+		for _, factor := range factors {
+			build(i+1, append(current, clamp(element, base[element]*factor)))
+		}
+	}
+	build(0, make([]float64, 0, len(elements)))
+	return starts
+}
+
+// reactanceFit находит методом наименьших квадратов коэффициенты x = ω·a - b/ω.
+// Для схем без конденсатора (или индуктивности) соответствующий коэффициент не используется.
+func reactanceFit(omega, x []float64, elements string) (a, b float64) {
+	var saa, sab, sbb, sxa, sxb float64
+	for i, w := range omega {
+		fa, fb := w, -1/w
+		saa += fa * fa
+		sab += fa * fb
+		sbb += fb * fb
+		sxa += x[i] * fa
+		sxb += x[i] * fb
+	}
+	if len(elements) == 3 {
+		solution, err := solveLinear([][]float64{{saa, sab}, {sab, sbb}}, []float64{sxa, sxb})
+		if err != nil {
+			return 0, 0
+		}
+		return solution[0], solution[1]
+	}
+	// В двухэлементных схемах коэффициенты оцениваются независимо, вызывающий использует только нужный.
+	return sxa / saa, sxb / sbb
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[len(sorted)/2]
+}
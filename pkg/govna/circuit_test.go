@@ -0,0 +1,67 @@
+package govna
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVNAData_FitEquivalentCircuit(t *testing.T) {
+	freq := make([]float64, 201)
+	for i := range freq {
+		freq[i] = 10e6 + float64(i)*0.5e6
+	}
+	cases := []EquivalentCircuit{
+		{Topology: CircuitSeriesRLC, R: 12, L: 220e-9, C: 47e-12},
+		{Topology: CircuitParallelRLC, R: 330, L: 180e-9, C: 33e-12},
+		{Topology: CircuitSeriesRC, R: 20, C: 100e-12},
+		{Topology: CircuitParallelRL, R: 150, L: 400e-9},
+		{Topology: CircuitLadderSeriesLShuntC, R: 200, L: 330e-9, C: 22e-12},
+		{Topology: CircuitLadderShuntLSeriesC, R: 10, L: 500e-9, C: 68e-12},
+	}
+	for _, want := range cases {
+		data := want.Evaluate(freq)
+		got, err := data.FitEquivalentCircuit(want.Topology, CircuitFitConfig{})
+		if err != nil {
+			t.Fatalf("%s: fit failed: %v", want.Topology, err)
+		}
+		for _, pair := range [][2]float64{{got.R, want.R}, {got.L, want.L}, {got.C, want.C}} {
+			if math.Abs(pair[0]-pair[1]) > 1e-3*math.Abs(pair[1]) {
+				t.Fatalf("%s: expected %+v, got R=%g L=%g C=%g", want.Topology, want, got.R, got.L, got.C)
+			}
+		}
+		if got.Quality.RMSError > 1e-6 || len(got.Residuals) != len(freq) {
+			t.Fatalf("%s: unexpected fit quality %+v", want.Topology, got.Quality)
+		}
+	}
+}
+
+func TestVNAData_FitEquivalentCircuitBounds(t *testing.T) {
+	freq := make([]float64, 101)
+	for i := range freq {
+		freq[i] = 10e6 + float64(i)*1e6
+	}
+	want := EquivalentCircuit{Topology: CircuitSeriesRLC, R: 12, L: 220e-9, C: 47e-12}
+	data := want.Evaluate(freq)
+
+	got, err := data.FitEquivalentCircuit(CircuitSeriesRLC, CircuitFitConfig{R: ElementBounds{Max: 5}, L: ElementBounds{Min: 100e-9, Max: 1e-6}})
+	if err != nil {
+		t.Fatalf("bounded fit failed: %v", err)
+	}
+	if got.R > 5*(1+1e-9) || math.Abs(got.R-5) > 1e-3 {
+		t.Fatalf("expected R to stop at the upper bound 5, got %g", got.R)
+	}
+	if got.L < 100e-9 || got.L > 1e-6 {
+		t.Fatalf("expected L within [100n, 1u], got %g", got.L)
+	}
+
+	for _, config := range []CircuitFitConfig{
+		{R: ElementBounds{Min: -1}},
+		{L: ElementBounds{Min: 1e-6, Max: 1e-9}},
+		{C: ElementBounds{Max: math.Inf(1)}},
+		{R: ElementBounds{Min: math.NaN()}},
+	} {
+		if _, err := data.FitEquivalentCircuit(CircuitSeriesRLC, config); err == nil {
+			t.Fatalf("expected error for bounds %+v", config)
+		}
+	}
+}
@@ -5,7 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"go.bug.st/serial"
+	"io"
+	"os"
+	"sort"
 	"sync"
+	"syscall"
 
 	"github.com/momentics/govna/internal/util"
 )
@@ -37,13 +41,35 @@ func driverFactory(port util.SerialPortInterface) (Driver, error) {
 	return nil, errors.New("не удалось идентифицировать устройство или устройство не поддерживается")
 }
 
+// isDisconnectError сообщает, означает ли ошибка драйвера потерю связи с устройством: порт закрыт
+// или исчез (USB-устройство отключено), либо чтение или запись завершились ошибкой ввода-вывода.
+// Таймауты и ошибки разбора ответа связь не прерывают.
+func isDisconnectError(err error) bool {
+	var portErr *serial.PortError
+	if errors.As(err, &portErr) {
+		switch portErr.Code() {
+		case serial.PortClosed, serial.PortNotFound, serial.InvalidSerialPort:
+			return true
+		}
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, os.ErrClosed) ||
+		errors.Is(err, syscall.EIO) || errors.Is(err, syscall.ENXIO) || errors.Is(err, syscall.ENODEV)
+}
+
 // VNAPool управляет пулом VNA устройств для многопоточного доступа.
+// Устройство, потерявшее связь, закрывается и удаляется из пула; следующий Get откроет порт заново.
 type VNAPool struct {
 	devices map[string]*VNA
 	mu      sync.RWMutex
+	events  *EventBus
 }
 
-func NewVNAPool() *VNAPool { return &VNAPool{devices: make(map[string]*VNA)} }
+func NewVNAPool() *VNAPool {
+	return &VNAPool{devices: make(map[string]*VNA), events: NewEventBus()}
+}
+
+// Events возвращает шину событий, в которую публикуют пул и его устройства.
+func (p *VNAPool) Events() *EventBus { return p.events }
 
 func (p *VNAPool) Get(portPath string) (*VNA, error) {
 	p.mu.RLock()
@@ -63,18 +89,81 @@ func (p *VNAPool) Get(portPath string) (*VNA, error) {
 	mode := &serial.Mode{BaudRate: 115200}
 	port, err := util.OpenPort(portPath, mode)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка открытия порта %s: %w", portPath, err)
+		err = fmt.Errorf("ошибка открытия порта %s: %w", portPath, err)
+		p.events.Publish(Event{Type: EventDeviceError, Device: portPath, Message: err.Error()})
+		return nil, err
 	}
 
 	driver, err := driverFactory(port)
 	if err != nil {
 		port.Close()
-		return nil, fmt.Errorf("ошибка фабрики драйверов для %s: %w", portPath, err)
+		err = fmt.Errorf("ошибка фабрики драйверов для %s: %w", portPath, err)
+		p.events.Publish(Event{Type: EventDeviceError, Device: portPath, Message: err.Error()})
+		return nil, err
 	}
 
+	return p.addLocked(portPath, driver), nil
+}
+
+// addLocked создает устройство пула и публикует его подключение. Вызывается с захваченным p.mu.
+func (p *VNAPool) addLocked(portPath string, driver Driver) *VNA {
 	newVNA := NewVNA(driver)
+	newVNA.SetEventBus(p.events, portPath)
+	newVNA.onDisconnect = func() { p.remove(portPath, newVNA) }
 	p.devices[portPath] = newVNA
-	return newVNA, nil
+	p.events.Publish(Event{Type: EventDeviceConnected, Device: portPath, Message: "устройство подключено", Data: newVNA.Capabilities()})
+	return newVNA
+}
+
+// remove закрывает потерявшее связь устройство и удаляет его из пула, если путь еще не занят
+// заново открытым устройством.
+func (p *VNAPool) remove(portPath string, vna *VNA) {
+	p.mu.Lock()
+	if p.devices[portPath] == vna {
+		delete(p.devices, portPath)
+	}
+	p.mu.Unlock()
+	vna.Close()
+}
+
+// Add добавляет в пул устройство с уже созданным драйвером, например, подключенное не через
+// последовательный порт. Если устройство с таким путем уже открыто, возвращает ошибку.
+func (p *VNAPool) Add(portPath string, driver Driver) (*VNA, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, exists := p.devices[portPath]; exists {
+		return nil, fmt.Errorf("устройство %s уже открыто", portPath)
+	}
+	return p.addLocked(portPath, driver), nil
+}
+
+// Close закрывает устройство и удаляет его из пула; следующий Get откроет порт заново.
+func (p *VNAPool) Close(portPath string) error {
+	p.mu.Lock()
+	vna, exists := p.devices[portPath]
+	delete(p.devices, portPath)
+	p.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("устройство %s не открыто", portPath)
+	}
+	return vna.Close()
+}
+
+// Devices возвращает пути открытых устройств пула.
+func (p *VNAPool) Devices() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	ports := make([]string, 0, len(p.devices))
+	for port := range p.devices {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+	return ports
+}
+
+// AvailablePorts возвращает последовательные порты системы, к которым можно подключить устройство.
+func AvailablePorts() ([]string, error) {
+	return serial.GetPortsList()
 }
 
 func (p *VNAPool) CloseAll() {
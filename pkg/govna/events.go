@@ -0,0 +1,158 @@
+// Этот файл содержит шину событий устройств: подключение, калибровка, ошибки сканирования, проверки масок.
+package govna
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventDeviceConnected    EventType = "device.connected"
+	EventDeviceDisconnected EventType = "device.disconnected"
+	// EventDeviceError - не удалось открыть или опознать устройство.
+	EventDeviceError        EventType = "device.error"
+	EventCalibrationChanged EventType = "calibration.changed"
+	EventSweepError         EventType = "sweep.error"
+	EventLimitFailed        EventType = "limit.failed"
+)
+
+// DefaultEventBuffer - глубина очереди подписчика шины событий по умолчанию.
+const DefaultEventBuffer = 64
+
+// Event - событие устройства. Device - путь к порту устройства, Data - подробности,
+// зависящие от типа события (например, имя калибровки).
+type Event struct {
+	Type    EventType `json:"type"`
+	Device  string    `json:"device,omitempty"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message,omitempty"`
+	Data    any       `json:"data,omitempty"`
+}
+
+// EventFilter отбирает события по устройствам и типам; пустой список означает "все".
+type EventFilter struct {
+	Devices []string
+	Types   []EventType
+}
+
+func (f EventFilter) match(e Event) bool {
+	return (len(f.Devices) == 0 || contains(f.Devices, e.Device)) &&
+		(len(f.Types) == 0 || contains(f.Types, e.Type))
+}
+
+func contains[T comparable](list []T, value T) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// EventBus раздает события подписчикам. Publish не блокируется: если подписчик не успевает
+// читать, старейшее событие в его очереди отбрасывается.
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[*EventSubscription]struct{}
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[*EventSubscription]struct{})}
+}
+
+// EventSubscription - подписка на события. C закрывается вызовом Close.
+type EventSubscription struct {
+	C       <-chan Event
+	ch      chan Event
+	bus     *EventBus
+	filter  EventFilter
+	dropped atomic.Uint64
+}
+
+// Subscribe создает подписку на события, проходящие фильтр. buffer <= 0 означает DefaultEventBuffer.
+func (b *EventBus) Subscribe(filter EventFilter, buffer int) *EventSubscription {
+	if buffer <= 0 {
+		buffer = DefaultEventBuffer
+	}
+	ch := make(chan Event, buffer)
+	s := &EventSubscription{C: ch, ch: ch, bus: b, filter: filter}
+	b.mu.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+// Publish отправляет событие подписчикам; нулевое время заменяется текущим.
+func (b *EventBus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subscribers {
+		if s.filter.match(e) {
+			s.deliver(e)
+		}
+	}
+}
+
+// deliver отправляет событие без блокировки. Вызывается с захваченным EventBus.mu.
+func (s *EventSubscription) deliver(e Event) {
+	for {
+		select {
+		case s.ch <- e:
+			return
+		default:
+		}
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// Dropped возвращает число событий, отброшенных из-за медленного чтения.
+func (s *EventSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *EventSubscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subscribers[s]; ok {
+		delete(s.bus.subscribers, s)
+		close(s.ch)
+	}
+}
+
+// SetEventBus подключает устройство к шине событий; device - имя устройства в событиях.
+// VNAPool вызывает его для каждого открытого устройства.
+func (v *VNA) SetEventBus(bus *EventBus, device string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.events = bus
+	v.device = device
+}
+
+// publishLocked публикует событие устройства. Вызывается с захваченным v.mu.
+func (v *VNA) publishLocked(eventType EventType, message string, data any) {
+	if v.events != nil {
+		v.events.Publish(Event{Type: eventType, Device: v.device, Message: message, Data: data})
+	}
+}
+
+// calibrationEvent описывает новый калибровочный профиль; nil означает, что калибровка сброшена.
+func calibrationEvent(profile *CalibrationProfile) (string, any) {
+	if profile == nil {
+		return "калибровка сброшена", nil
+	}
+	return "загружена калибровка " + profile.Name, map[string]any{
+		"name":   profile.Name,
+		"method": profile.Method,
+		"sweep":  profile.Sweep,
+	}
+}
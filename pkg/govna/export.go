@@ -0,0 +1,449 @@
+// Этот файл содержит экспорт данных сканирования в форматы JSON, CSV и компактный двоичный формат.
+package govna
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScanMetadata описывает происхождение данных сканирования. Sequence - порядковый номер скана
+// данного устройства, позволяющий клиентам обнаруживать пропущенные сканы.
+type ScanMetadata struct {
+	Device      string        `json:"device,omitempty"`
+	Port        string        `json:"port,omitempty"`
+	Calibration string        `json:"calibration,omitempty"`
+	Sweep       SweepConfig   `json:"sweep"`
+	Started     time.Time     `json:"started"`
+	Duration    time.Duration `json:"duration_ns"`
+	Sequence    uint64        `json:"sequence"`
+}
+
+// DefaultExportTraces - производные трассы, добавляемые в JSON и CSV, если трассы не заданы явно.
+// Трассы отсутствующих параметров пропускаются.
+var DefaultExportTraces = []TraceSpec{
+	{Parameter: S11, Format: FormatLogMag},
+	{Parameter: S11, Format: FormatPhase},
+	{Parameter: S11, Format: FormatVSWR},
+	{Parameter: S21, Format: FormatLogMag},
+	{Parameter: S21, Format: FormatPhase},
+}
+
+// jsonFloat кодирует нечисловые значения (например, -Inf дБ для нулевой амплитуды) как null.
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+		return []byte("null"), nil
+	}
+	return strconv.AppendFloat(nil, float64(f), 'g', -1, 64), nil
+}
+
+type exportTrace struct {
+	TraceSpec
+	Values []jsonFloat `json:"values"`
+}
+
+type exportJSON struct {
+	Metadata    ScanMetadata   `json:"metadata"`
+	Frequencies []float64      `json:"frequencies"`
+	S11         [][2]jsonFloat `json:"s11,omitempty"`
+	S21         [][2]jsonFloat `json:"s21,omitempty"`
+	S12         [][2]jsonFloat `json:"s12,omitempty"`
+	S22         [][2]jsonFloat `json:"s22,omitempty"`
+	Z0          [][2]float64   `json:"z0,omitempty"`
+	Traces      []exportTrace  `json:"traces,omitempty"`
+}
+
+// WriteJSON записывает метаданные, сетку частот, S-параметры в виде пар [re, im] и производные трассы.
+// Без явно заданных трасс используются DefaultExportTraces.
+func (d *VNAData) WriteJSON(w io.Writer, meta ScanMetadata, traces ...TraceSpec) error {
+	computed, err := d.exportTraces(traces)
+	if err != nil {
+		return err
+	}
+	out := exportJSON{
+		Metadata:    meta,
+		Frequencies: d.Frequencies,
+		S11:         complexPairs(d.S11),
+		S21:         complexPairs(d.S21),
+		S12:         complexPairs(d.S12),
+		S22:         complexPairs(d.S22),
+		Traces:      computed,
+	}
+	for _, z := range d.Z0 {
+		out.Z0 = append(out.Z0, [2]float64{real(z), imag(z)})
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+func complexPairs(values []complex128) [][2]jsonFloat {
+	if len(values) == 0 {
+		return nil
+	}
+	pairs := make([][2]jsonFloat, len(values))
+	for i, v := range values {
+		pairs[i] = [2]jsonFloat{jsonFloat(real(v)), jsonFloat(imag(v))}
+	}
+	return pairs
+}
+
+// WriteCSV записывает данные в CSV: строки комментариев "# ключ: значение" с метаданными, заголовок
+// и по строке на точку с частотой, действительными и мнимыми частями S-параметров и производными трассами.
+func (d *VNAData) WriteCSV(w io.Writer, meta ScanMetadata, traces ...TraceSpec) error {
+	computed, err := d.exportTraces(traces)
+	if err != nil {
+		return err
+	}
+
+	var started string
+	if !meta.Started.IsZero() {
+		started = meta.Started.Format(time.RFC3339Nano)
+	}
+	buffered := bufio.NewWriter(w)
+	for _, line := range [][2]string{
+		{"device", meta.Device},
+		{"port", meta.Port},
+		{"calibration", meta.Calibration},
+		{"started", started},
+		{"duration_ns", strconv.FormatInt(int64(meta.Duration), 10)},
+		{"sequence", strconv.FormatUint(meta.Sequence, 10)},
+	} {
+		if line[1] != "" {
+			fmt.Fprintf(buffered, "# %s: %s\n", line[0], line[1])
+		}
+	}
+
+	parameters := d.presentParameters()
+	header := []string{"frequency"}
+	for _, p := range parameters {
+		header = append(header, string(p)+"_re", string(p)+"_im")
+	}
+	for _, trace := range computed {
+		header = append(header, string(trace.Parameter)+"_"+string(trace.Format))
+	}
+
+	writer := csv.NewWriter(buffered)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(header))
+	for i, freq := range d.Frequencies {
+		record = record[:0]
+		record = append(record, formatCSVFloat(freq))
+		for _, p := range parameters {
+			v, _ := d.Parameter(p)
+			record = append(record, formatCSVFloat(real(v[i])), formatCSVFloat(imag(v[i])))
+		}
+		for _, trace := range computed {
+			record = append(record, formatCSVFloat(float64(trace.Values[i])))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// exportTraces вычисляет производные трассы. Явно заданная трасса отсутствующего параметра - ошибка,
+// трассы по умолчанию для отсутствующих параметров пропускаются.
+func (d *VNAData) exportTraces(specs []TraceSpec) ([]exportTrace, error) {
+	explicit := len(specs) > 0
+	if !explicit {
+		specs = DefaultExportTraces
+	}
+	var out []exportTrace
+	for _, spec := range specs {
+		spec = spec.withDefaults()
+		if values, _ := d.Parameter(spec.Parameter); !explicit && len(values) != len(d.Frequencies) {
+			continue
+		}
+		values, err := d.Trace(spec)
+		if err != nil {
+			return nil, fmt.Errorf("трасса %s %s: %w", spec.Parameter, spec.Format, err)
+		}
+		trace := exportTrace{TraceSpec: spec, Values: make([]jsonFloat, len(values))}
+		for i, v := range values {
+			trace.Values[i] = jsonFloat(v)
+		}
+		out = append(out, trace)
+	}
+	return out, nil
+}
+
+// presentParameters возвращает измеренные параметры в порядке S11, S21, S12, S22.
+func (d *VNAData) presentParameters() []SParameter {
+	var present []SParameter
+	for _, p := range []SParameter{S11, S21, S12, S22} {
+		if values, err := d.Parameter(p); err == nil && len(values) == len(d.Frequencies) {
+			present = append(present, p)
+		}
+	}
+	return present
+}
+
+// binaryMagic открывает двоичный формат скана.
+var binaryMagic = [4]byte{'G', 'V', 'N', 'A'}
+
+const binaryVersion = 1
+
+// WriteBinary записывает скан в компактном двоичном формате (little-endian):
+//
+//	magic "GVNA", version uint8, flags uint8 (биты 0-3: присутствуют S11, S21, S12, S22),
+//	points uint32, sequence uint64, started int64 (нс Unix), duration int64 (нс),
+//	Z0 порта 1 и 2 как 4×float64 (re, im), device и calibration как uint16 длина + UTF-8,
+//	затем для каждой точки: частота float64 и пары re, im float32 для каждого присутствующего параметра.
+func (d *VNAData) WriteBinary(w io.Writer, meta ScanMetadata) error {
+	if len(meta.Device) > math.MaxUint16 || len(meta.Calibration) > math.MaxUint16 {
+		return errors.New("слишком длинные метаданные для двоичного формата")
+	}
+	parameters := d.presentParameters()
+	var flags uint8
+	for _, p := range parameters {
+		flags |= parameterFlag(p)
+	}
+
+	buffered := bufio.NewWriter(w)
+	z1, z2 := d.ReferenceImpedance(1), d.ReferenceImpedance(2)
+	header := []any{
+		binaryMagic, uint8(binaryVersion), flags, uint32(len(d.Frequencies)), meta.Sequence,
+		meta.Started.UnixNano(), int64(meta.Duration),
+		[4]float64{real(z1), imag(z1), real(z2), imag(z2)},
+		uint16(len(meta.Device)), []byte(meta.Device),
+		uint16(len(meta.Calibration)), []byte(meta.Calibration),
+	}
+	for _, field := range header {
+		if err := binary.Write(buffered, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+
+	record := make([]byte, 8+8*len(parameters))
+	for i, freq := range d.Frequencies {
+		binary.LittleEndian.PutUint64(record, math.Float64bits(freq))
+		for j, p := range parameters {
+			v, _ := d.Parameter(p)
+			binary.LittleEndian.PutUint32(record[8+8*j:], math.Float32bits(float32(real(v[i]))))
+			binary.LittleEndian.PutUint32(record[12+8*j:], math.Float32bits(float32(imag(v[i]))))
+		}
+		if _, err := buffered.Write(record); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
+
+// ReadBinary читает скан, записанный WriteBinary. Сетка сканирования в метаданных
+// восстанавливается по частотам данных.
+func ReadBinary(r io.Reader) (VNAData, ScanMetadata, error) {
+	var header struct {
+		Magic    [4]byte
+		Version  uint8
+		Flags    uint8
+		Points   uint32
+		Sequence uint64
+		Started  int64
+		Duration int64
+		Z0       [4]float64
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return VNAData{}, ScanMetadata{}, fmt.Errorf("ошибка чтения заголовка: %w", err)
+	}
+	if header.Magic != binaryMagic {
+		return VNAData{}, ScanMetadata{}, errors.New("неверная сигнатура двоичного формата")
+	}
+	if header.Version != binaryVersion {
+		return VNAData{}, ScanMetadata{}, fmt.Errorf("неподдерживаемая версия двоичного формата %d", header.Version)
+	}
+	device, err := readBinaryString(r)
+	if err != nil {
+		return VNAData{}, ScanMetadata{}, err
+	}
+	calibration, err := readBinaryString(r)
+	if err != nil {
+		return VNAData{}, ScanMetadata{}, err
+	}
+
+	var parameters []SParameter
+	for _, p := range []SParameter{S11, S21, S12, S22} {
+		if header.Flags&parameterFlag(p) != 0 {
+			parameters = append(parameters, p)
+		}
+	}
+	n := int(header.Points)
+	data := VNAData{Frequencies: make([]float64, n)}
+	columns := make([][]complex128, len(parameters))
+	for j := range columns {
+		columns[j] = make([]complex128, n)
+	}
+	record := make([]byte, 8+8*len(parameters))
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(r, record); err != nil {
+			return VNAData{}, ScanMetadata{}, fmt.Errorf("ошибка чтения точки %d: %w", i, err)
+		}
+		data.Frequencies[i] = math.Float64frombits(binary.LittleEndian.Uint64(record))
+		for j := range parameters {
+			re := math.Float32frombits(binary.LittleEndian.Uint32(record[8+8*j:]))
+			im := math.Float32frombits(binary.LittleEndian.Uint32(record[12+8*j:]))
+			columns[j][i] = complex(float64(re), float64(im))
+		}
+	}
+	for j, p := range parameters {
+		switch p {
+		case S11:
+			data.S11 = columns[j]
+		case S21:
+			data.S21 = columns[j]
+		case S12:
+			data.S12 = columns[j]
+		case S22:
+			data.S22 = columns[j]
+		}
+	}
+	z1 := complex(header.Z0[0], header.Z0[1])
+	z2 := complex(header.Z0[2], header.Z0[3])
+	if z1 != DefaultReferenceImpedance || z2 != DefaultReferenceImpedance {
+		data.Z0 = []complex128{z1, z2}
+	}
+
+	meta := ScanMetadata{
+		Device:      device,
+		Calibration: calibration,
+		Started:     time.Unix(0, header.Started),
+		Duration:    time.Duration(header.Duration),
+		Sequence:    header.Sequence,
+	}
+	if n > 0 {
+		meta.Sweep = SweepConfig{Start: data.Frequencies[0], Stop: data.Frequencies[n-1], Points: n}
+	}
+	return data, meta, nil
+}
+
+// ReadTouchstone читает файл Touchstone 1.x с одним или двумя портами: строки данных
+// из 3 (f S11), 5 (f S11 S21) или 9 (f S11 S21 S12 S22) чисел. Поддерживаются форматы
+// RI, MA и DB и единицы частоты Hz, kHz, MHz и GHz; опорный импеданс берется из строки параметров.
+func ReadTouchstone(r io.Reader) (VNAData, error) {
+	var data VNAData
+	scale, format, z0 := 1e9, "MA", complex(DefaultReferenceImpedance, 0)
+	columns := 0
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '!'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if strings.HasPrefix(fields[0], "#") {
+			options := strings.Fields(strings.ToUpper(strings.Join(fields, " ")[1:]))
+			for i := 0; i < len(options); i++ {
+				switch option := options[i]; option {
+				case "HZ", "KHZ", "MHZ", "GHZ":
+					scale = map[string]float64{"HZ": 1, "KHZ": 1e3, "MHZ": 1e6, "GHZ": 1e9}[option]
+				case "RI", "MA", "DB":
+					format = option
+				case "S":
+				case "R":
+					if i+1 >= len(options) {
+						return VNAData{}, fmt.Errorf("строка %d: не указан опорный импеданс", line)
+					}
+					value, err := strconv.ParseFloat(options[i+1], 64)
+					if err != nil || value <= 0 {
+						return VNAData{}, fmt.Errorf("строка %d: некорректный опорный импеданс %q", line, options[i+1])
+					}
+					z0 = complex(value, 0)
+					i++
+				default:
+					return VNAData{}, fmt.Errorf("строка %d: неподдерживаемый параметр %q", line, option)
+				}
+			}
+			continue
+		}
+		if columns == 0 {
+			columns = len(fields)
+			if columns != 3 && columns != 5 && columns != 9 {
+				return VNAData{}, fmt.Errorf("строка %d: ожидалось 3, 5 или 9 чисел, получено %d", line, columns)
+			}
+		} else if len(fields) != columns {
+			return VNAData{}, fmt.Errorf("строка %d: ожидалось %d чисел, получено %d", line, columns, len(fields))
+		}
+		values := make([]float64, len(fields))
+		for i, field := range fields {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return VNAData{}, fmt.Errorf("строка %d: некорректное число %q", line, field)
+			}
+			values[i] = value
+		}
+		data.Frequencies = append(data.Frequencies, values[0]*scale)
+		parameters := []*[]complex128{&data.S11, &data.S21, &data.S12, &data.S22}
+		for j := 0; 1+2*j < columns; j++ {
+			*parameters[j] = append(*parameters[j], touchstoneValue(format, values[1+2*j], values[2+2*j]))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return VNAData{}, fmt.Errorf("ошибка чтения Touchstone: %w", err)
+	}
+	if len(data.Frequencies) == 0 {
+		return VNAData{}, errors.New("файл Touchstone не содержит данных")
+	}
+	if z0 != DefaultReferenceImpedance {
+		data.Z0 = []complex128{z0, z0}
+	}
+	return data, nil
+}
+
+func touchstoneValue(format string, a, b float64) complex128 {
+	switch format {
+	case "RI":
+		return complex(a, b)
+	case "DB":
+		return cmplx.Rect(math.Pow(10, a/20), b*math.Pi/180)
+	}
+	return cmplx.Rect(a, b*math.Pi/180)
+}
+
+func readBinaryString(r io.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return "", fmt.Errorf("ошибка чтения метаданных: %w", err)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", fmt.Errorf("ошибка чтения метаданных: %w", err)
+	}
+	return string(buf), nil
+}
+
+func parameterFlag(p SParameter) uint8 {
+	switch p {
+	case S11:
+		return 1 << 0
+	case S21:
+		return 1 << 1
+	case S12:
+		return 1 << 2
+	case S22:
+		return 1 << 3
+	}
+	return 0
+}
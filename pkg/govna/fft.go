@@ -0,0 +1,87 @@
+// Этот файл содержит БПФ и chirp-Z преобразование, используемые во временной области.
+package govna
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// fft выполняет БПФ по основанию 2 на месте. Длина x должна быть степенью двойки.
+// При inverse=true вычисляется обратное преобразование без нормировки на длину.
+func fft(x []complex128, inverse bool) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u := x[start+k]
+				v := x[start+k+size/2] * w
+				x[start+k] = u + v
+				x[start+k+size/2] = u - v
+				w *= step
+			}
+		}
+	}
+}
+
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// chirpZ вычисляет X[k] = Σ x[n]·A^(-n)·W^(n·k) для k = 0..m-1 алгоритмом Блюстейна.
+// Параметры задаются углами: A = exp(j·a), W = exp(j·w).
+func chirpZ(x []complex128, m int, a, w float64) []complex128 {
+	n := len(x)
+	size := nextPowerOfTwo(n + m - 1)
+
+	// W^(n·k) = W^(n²/2)·W^(k²/2)·W^(-(k-n)²/2)
+	chirp := func(k int) complex128 {
+		kk := float64(k)
+		return cmplx.Rect(1, w*kk*kk/2)
+	}
+
+	y := make([]complex128, size)
+	for i := 0; i < n; i++ {
+		y[i] = x[i] * cmplx.Rect(1, -a*float64(i)) * chirp(i)
+	}
+	v := make([]complex128, size)
+	for k := 0; k < m; k++ {
+		v[k] = cmplx.Conj(chirp(k))
+	}
+	for k := 1; k < n; k++ {
+		v[size-k] = cmplx.Conj(chirp(k))
+	}
+
+	fft(y, false)
+	fft(v, false)
+	for i := range y {
+		y[i] *= v[i]
+	}
+	fft(y, true)
+
+	out := make([]complex128, m)
+	scale := complex(1/float64(size), 0)
+	for k := 0; k < m; k++ {
+		out[k] = y[k] * scale * chirp(k)
+	}
+	return out
+}
@@ -0,0 +1,290 @@
+// Этот файл содержит нелинейный метод наименьших квадратов (Левенберг-Марквардт) с ограничениями параметров.
+package govna
+
+import (
+	"errors"
+	"math"
+)
+
+// FitQuality описывает качество аппроксимации: нормированные среднеквадратичная и максимальная невязки.
+type FitQuality struct {
+	RMSError   float64
+	MaxError   float64
+	Iterations int
+	Converged  bool
+}
+
+type fitResult struct {
+	params    []float64
+	residuals []float64
+	quality   FitQuality
+}
+
+const (
+	fitMaxIterations = 200
+	fitTolerance     = 1e-12
+)
+
+// levenbergMarquardt минимизирует сумму квадратов невязок residual(p) при lower ≤ p ≤ upper.
+// Якобиан вычисляется численно; шаги, выходящие за границы, проецируются на допустимую область.
+func levenbergMarquardt(residual func([]float64) []float64, initial, lower, upper []float64) (fitResult, error) {
+	n := len(initial)
+	if len(lower) != n || len(upper) != n {
+		return fitResult{}, errors.New("границы параметров не совпадают по размеру с начальным приближением")
+	}
+	params := make([]float64, n)
+	for i := range initial {
+		params[i] = math.Min(math.Max(initial[i], lower[i]), upper[i])
+	}
+	r := residual(params)
+	cost := sumSquares(r)
+	if math.IsNaN(cost) || math.IsInf(cost, 0) {
+		return fitResult{}, errors.New("невязка в начальной точке не определена")
+	}
+
+	lambda := 1e-3
+	quality := FitQuality{}
+	for iter := 1; iter <= fitMaxIterations; iter++ {
+		quality.Iterations = iter
+		jac := numericJacobian(residual, params, r, lower, upper)
+
+		jtj := make([][]float64, n)
+		jtr := make([]float64, n)
+		for a := 0; a < n; a++ {
+			jtj[a] = make([]float64, n)
+			for b := 0; b < n; b++ {
+				for k := range r {
+					jtj[a][b] += jac[k][a] * jac[k][b]
+				}
+			}
+			for k := range r {
+				jtr[a] -= jac[k][a] * r[k]
+			}
+		}
+
+		improved := false
+		for attempt := 0; attempt < 20; attempt++ {
+			damped := make([][]float64, n)
+			for a := range jtj {
+				damped[a] = append([]float64(nil), jtj[a]...)
+				damped[a][a] += lambda * math.Max(jtj[a][a], 1e-12)
+			}
+			step, err := solveLinear(damped, jtr)
+			if err != nil {
+				lambda *= 10
+				continue
+			}
+			candidate := make([]float64, n)
+			for i := range params {
+				candidate[i] = math.Min(math.Max(params[i]+step[i], lower[i]), upper[i])
+			}
+			cr := residual(candidate)
+			candidateCost := sumSquares(cr)
+			if candidateCost < cost && !math.IsNaN(candidateCost) {
+				relative := (cost - candidateCost) / math.Max(cost, 1e-300)
+				params, r, cost = candidate, cr, candidateCost
+				lambda = math.Max(lambda/10, 1e-12)
+				improved = true
+				if relative < fitTolerance {
+					quality.Converged = true
+				}
+				break
+			}
+			lambda *= 10
+		}
+		if !improved {
+			// Дальнейшее уменьшение невязки невозможно - достигнут локальный минимум.
+			quality.Converged = true
+		}
+		if quality.Converged {
+			break
+		}
+	}
+
+	quality.RMSError = math.Sqrt(cost / float64(len(r)))
+	for _, v := range r {
+		quality.MaxError = math.Max(quality.MaxError, math.Abs(v))
+	}
+	return fitResult{params: params, residuals: r, quality: quality}, nil
+}
+
+func numericJacobian(residual func([]float64) []float64, params, r, lower, upper []float64) [][]float64 {
+	jac := make([][]float64, len(r))
+	for k := range jac {
+		jac[k] = make([]float64, len(params))
+	}
+	probe := append([]float64(nil), params...)
+	for j := range params {
+		h := 1e-7 * math.Max(math.Abs(params[j]), 1e-3)
+		if params[j]+h > upper[j] {
+			h = -h
+		}
+		probe[j] = params[j] + h
+		rp := residual(probe)
+		for k := range r {
+			jac[k][j] = (rp[k] - r[k]) / h
+		}
+		probe[j] = params[j]
+	}
+	return jac
+}
+
+func sumSquares(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v * v
+	}
+	return sum
+}
+
+// solveLinear решает систему a·x = b методом Гаусса с выбором ведущего элемента.
+func solveLinear(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = append(append([]float64(nil), a[i]...), b[i])
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-300 {
+			return nil, errors.New("система линейных уравнений вырождена")
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for row := col + 1; row < n; row++ {
+			factor := m[row][col] / m[col][col]
+			for j := col; j <= n; j++ {
+				m[row][j] -= factor * m[col][j]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := m[i][n]
+		for j := i + 1; j < n; j++ {
+			sum -= m[i][j] * x[j]
+		}
+		x[i] = sum / m[i][i]
+	}
+	return x, nil
+}
+
+// leastSquares решает переопределенную систему a·x ≈ b методом наименьших квадратов через
+// QR-разложение Хаусхолдера с выбором ведущего столбца. Столбцы предварительно нормируются;
+// при неполном ранге компоненты решения вне найденного ранга полагаются нулевыми.
+func leastSquares(a [][]float64, b []float64) ([]float64, error) {
+	rows := len(a)
+	if rows == 0 || rows != len(b) {
+		return nil, errors.New("некорректный размер системы наименьших квадратов")
+	}
+	cols := len(a[0])
+	m := make([][]float64, rows)
+	for i := range a {
+		m[i] = append([]float64(nil), a[i]...)
+	}
+	rhs := append([]float64(nil), b...)
+	scale := make([]float64, cols)
+	norms := make([]float64, cols)
+	perm := make([]int, cols)
+	for j := 0; j < cols; j++ {
+		perm[j] = j
+		norm := 0.0
+		for i := range m {
+			norm += m[i][j] * m[i][j]
+		}
+		scale[j] = math.Sqrt(norm)
+		if scale[j] == 0 {
+			scale[j] = 1
+		}
+		for i := range m {
+			m[i][j] /= scale[j]
+		}
+		norms[j] = norm / (scale[j] * scale[j])
+	}
+
+	steps := cols
+	if rows < steps {
+		steps = rows
+	}
+	rank := 0
+	first := 0.0
+	for k := 0; k < steps; k++ {
+		pivot := k
+		for j := k + 1; j < cols; j++ {
+			if norms[j] > norms[pivot] {
+				pivot = j
+			}
+		}
+		if pivot != k {
+			for i := range m {
+				m[i][k], m[i][pivot] = m[i][pivot], m[i][k]
+			}
+			norms[k], norms[pivot] = norms[pivot], norms[k]
+			perm[k], perm[pivot] = perm[pivot], perm[k]
+		}
+
+		norm := 0.0
+		for i := k; i < rows; i++ {
+			norm += m[i][k] * m[i][k]
+		}
+		norm = math.Sqrt(norm)
+		if k == 0 {
+			first = norm
+		}
+		if norm <= 1e-12*first || norm == 0 {
+			break
+		}
+		if m[k][k] > 0 {
+			norm = -norm
+		}
+		v := make([]float64, rows-k)
+		for i := k; i < rows; i++ {
+			v[i-k] = m[i][k]
+		}
+		v[0] -= norm
+		vnorm := sumSquares(v)
+		for j := k; j < cols; j++ {
+			dot := 0.0
+			for i := k; i < rows; i++ {
+				dot += v[i-k] * m[i][j]
+			}
+			dot *= 2 / vnorm
+			for i := k; i < rows; i++ {
+				m[i][j] -= dot * v[i-k]
+			}
+		}
+		dot := 0.0
+		for i := k; i < rows; i++ {
+			dot += v[i-k] * rhs[i]
+		}
+		dot *= 2 / vnorm
+		for i := k; i < rows; i++ {
+			rhs[i] -= dot * v[i-k]
+		}
+		for j := k + 1; j < cols; j++ {
+			norms[j] -= m[k][j] * m[k][j]
+		}
+		rank++
+	}
+	if rank == 0 {
+		return nil, errors.New("система наименьших квадратов вырождена")
+	}
+
+	y := make([]float64, cols)
+	for i := rank - 1; i >= 0; i-- {
+		sum := rhs[i]
+		for j := i + 1; j < rank; j++ {
+			sum -= m[i][j] * y[j]
+		}
+		y[i] = sum / m[i][i]
+	}
+	x := make([]float64, cols)
+	for k := range y {
+		x[perm[k]] = y[k] / scale[perm[k]]
+	}
+	return x, nil
+}
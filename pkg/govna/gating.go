@@ -0,0 +1,175 @@
+// Этот файл содержит стробирование во временной области (time gating) с обратным переходом в частотную область.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+type GateType string
+
+const (
+	// GateBandPass оставляет отклик внутри строба.
+	GateBandPass GateType = "bandpass"
+	// GateNotch удаляет отклик внутри строба.
+	GateNotch GateType = "notch"
+)
+
+type GateShape string
+
+const (
+	GateShapeMinimum GateShape = "minimum"
+	GateShapeNormal  GateShape = "normal"
+	GateShapeWide    GateShape = "wide"
+	GateShapeMaximum GateShape = "maximum"
+)
+
+// gateEdgeFactors задают длительность фронта строба в единицах 1/span частотного диапазона.
+var gateEdgeFactors = map[GateShape]float64{
+	GateShapeMinimum: 1.4,
+	GateShapeNormal:  2.8,
+	GateShapeWide:    5.6,
+	GateShapeMaximum: 11.2,
+}
+
+// TimeGate задает строб во временной области. Если Span > 0, границы задаются через Center/Span,
+// иначе через Start/Stop (в секундах, время прохождения "туда и обратно" для отражения).
+type TimeGate struct {
+	Parameter    SParameter
+	Type         GateType
+	Shape        GateShape
+	Start, Stop  float64
+	Center, Span float64
+	Window       WindowType
+	KaiserBeta   float64
+}
+
+func (g TimeGate) bounds() (float64, float64) {
+	if g.Span > 0 {
+		return g.Center - g.Span/2, g.Center + g.Span/2
+	}
+	return g.Start, g.Stop
+}
+
+// gateValue возвращает коэффициент передачи строба с косинусными фронтами длительностью edge.
+func gateValue(t, start, stop, edge float64) float64 {
+	switch {
+	case t <= start-edge/2 || t >= stop+edge/2:
+		return 0
+	case t >= start+edge/2 && t <= stop-edge/2:
+		return 1
+	case t < start+edge/2:
+		return 0.5 - 0.5*math.Cos(math.Pi*(t-start+edge/2)/edge)
+	default:
+		return 0.5 - 0.5*math.Cos(math.Pi*(stop+edge/2-t)/edge)
+	}
+}
+
+// Gate применяет строб к параметру и возвращает данные в частотной области.
+// Окно преобразования снимается после обратного перехода, а спад на краях диапазона
+// компенсируется нормировкой на стробированный отклик идеального импульса в центре строба.
+func (d *VNAData) Gate(gate TimeGate) (VNAData, error) {
+	if gate.Parameter == "" {
+		gate.Parameter = S11
+	}
+	if gate.Type == "" {
+		gate.Type = GateBandPass
+	}
+	if gate.Shape == "" {
+		gate.Shape = GateShapeNormal
+	}
+	if gate.Type != GateBandPass && gate.Type != GateNotch {
+		return VNAData{}, fmt.Errorf("неизвестный тип строба %q", gate.Type)
+	}
+	factor, ok := gateEdgeFactors[gate.Shape]
+	if !ok {
+		return VNAData{}, fmt.Errorf("неизвестная форма строба %q", gate.Shape)
+	}
+	values, err := d.Parameter(gate.Parameter)
+	if err != nil {
+		return VNAData{}, err
+	}
+	step, err := uniformStep(d.Frequencies)
+	if err != nil {
+		return VNAData{}, err
+	}
+
+	start, stop := gate.bounds()
+	span := d.Frequencies[len(d.Frequencies)-1] - d.Frequencies[0]
+	edge := factor / span
+	if stop-start <= edge {
+		return VNAData{}, fmt.Errorf("ширина строба %.3g с меньше минимальной %.3g с для формы %s", stop-start, edge, gate.Shape)
+	}
+	period := 1 / step
+	if start < -period/2 || stop > period/2 {
+		return VNAData{}, fmt.Errorf("строб выходит за однозначный диапазон ±%.3g с", period/2)
+	}
+
+	window, err := makeWindow(gate.Window, gate.KaiserBeta, len(values), false)
+	if err != nil {
+		return VNAData{}, err
+	}
+
+	n := len(values)
+	m := nextPowerOfTwo(8 * n)
+	if m < 1024 {
+		m = 1024
+	}
+	shape := make([]float64, m)
+	for k := range shape {
+		t := float64(k) * period / float64(m)
+		if k >= m/2 {
+			t -= period
+		}
+		shape[k] = gateValue(t, start, stop, edge)
+	}
+
+	center := (start + stop) / 2
+	weighted := make([]complex128, n)
+	reference := make([]complex128, n)
+	for i, v := range values {
+		weighted[i] = v * complex(window[i], 0)
+		reference[i] = complex(window[i], 0) * complexExp(-2*math.Pi*float64(i)*step*center)
+	}
+	gated := applyGate(weighted, shape)
+	normalization := applyGate(reference, shape)
+
+	result := d.clone()
+	target, _ := result.Parameter(gate.Parameter)
+	for i := range values {
+		if normalization[i] == 0 {
+			return VNAData{}, errors.New("стробирование невозможно: нулевая нормировка на краю диапазона")
+		}
+		bandPass := gated[i] / normalization[i] * complexExp(-2*math.Pi*float64(i)*step*center)
+		if gate.Type == GateNotch {
+			target[i] = values[i] - bandPass
+		} else {
+			target[i] = bandPass
+		}
+	}
+	return result, nil
+}
+
+// applyGate переводит спектр во временную область на сетке из len(shape) точек периода 1/Δf,
+// умножает на строб и возвращает первые len(spectrum) отсчетов спектра.
+func applyGate(spectrum []complex128, shape []float64) []complex128 {
+	m := len(shape)
+	buf := make([]complex128, m)
+	copy(buf, spectrum)
+	fft(buf, true)
+	for k := range buf {
+		buf[k] *= complex(shape[k], 0)
+	}
+	fft(buf, false)
+	out := make([]complex128, len(spectrum))
+	scale := complex(1/float64(m), 0)
+	for i := range out {
+		out[i] = buf[i] * scale
+	}
+	return out
+}
+
+func complexExp(phase float64) complex128 {
+	return complex(math.Cos(phase), math.Sin(phase))
+}
@@ -0,0 +1,39 @@
+package govna
+
+import (
+	"math/cmplx"
+	"testing"
+)
+
+func TestVNAData_GateSeparatesReflections(t *testing.T) {
+	cfg := SweepConfig{Start: 100e6, Stop: 3e9, Points: 301}
+	connector := delayedReflection(cfg, 0.3, 1e-9)
+	load := delayedReflection(cfg, 0.2, 6e-9)
+	data := VNAData{Frequencies: connector.Frequencies, S11: make([]complex128, cfg.Points)}
+	for i := range data.S11 {
+		data.S11[i] = connector.S11[i] + load.S11[i]
+	}
+
+	bandPass, err := data.Gate(TimeGate{Type: GateBandPass, Center: 12e-9, Span: 6e-9})
+	if err != nil {
+		t.Fatalf("Gate failed: %v", err)
+	}
+	notch, err := data.Gate(TimeGate{Type: GateNotch, Start: -2e-9, Stop: 5e-9})
+	if err != nil {
+		t.Fatalf("notch Gate failed: %v", err)
+	}
+
+	// Проверяем середину диапазона и точки у краев, где работает компенсация краевых эффектов.
+	for _, i := range []int{15, 150, 285} {
+		if cmplx.Abs(bandPass.S11[i]-load.S11[i]) > 0.01 {
+			t.Fatalf("bandpass point %d: expected %v, got %v", i, load.S11[i], bandPass.S11[i])
+		}
+		if cmplx.Abs(notch.S11[i]-load.S11[i]) > 0.01 {
+			t.Fatalf("notch point %d: expected %v, got %v", i, load.S11[i], notch.S11[i])
+		}
+	}
+
+	if _, err := data.Gate(TimeGate{Center: 12e-9, Span: 0.5e-9}); err == nil {
+		t.Fatalf("expected error for a gate narrower than its edges")
+	}
+}
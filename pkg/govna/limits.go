@@ -0,0 +1,258 @@
+// Этот файл содержит допусковый контроль (limit lines): кусочно-линейные маски верхних и нижних границ.
+package govna
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type LimitType string
+
+const (
+	LimitUpper LimitType = "upper"
+	LimitLower LimitType = "lower"
+)
+
+// LimitSegment - отрезок маски: граница линейно меняется от StartValue на частоте Start до StopValue на Stop.
+type LimitSegment struct {
+	Type       LimitType `json:"type"`
+	Start      float64   `json:"start"`
+	Stop       float64   `json:"stop"`
+	StartValue float64   `json:"start_value"`
+	StopValue  float64   `json:"stop_value"`
+}
+
+// LimitMask - набор отрезков для одной трассы.
+type LimitMask struct {
+	Name     string         `json:"name"`
+	Trace    TraceSpec      `json:"trace"`
+	Segments []LimitSegment `json:"segments"`
+}
+
+// LimitPoint - результат проверки одной точки. Margin > 0 означает запас, Margin < 0 - нарушение.
+// Value и Margin бесконечны, если трасса в точке уходит в бесконечность (например, logmag нулевого
+// отсчета); в JSON такие значения записываются как null. Undefined означает, что значение трассы
+// в точке не определено (NaN): Margin тогда равен NaN, и точка считается не прошедшей проверку.
+type LimitPoint struct {
+	Frequency float64 `json:"frequency"`
+	Value     float64 `json:"value"`
+	Margin    float64 `json:"margin"`
+	Pass      bool    `json:"pass"`
+	Undefined bool    `json:"undefined,omitempty"`
+}
+
+func (p LimitPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Frequency float64  `json:"frequency"`
+		Value     *float64 `json:"value"`
+		Margin    *float64 `json:"margin"`
+		Pass      bool     `json:"pass"`
+		Undefined bool     `json:"undefined,omitempty"`
+	}{p.Frequency, finiteOrNil(p.Value), finiteOrNil(p.Margin), p.Pass, p.Undefined})
+}
+
+// worseThan сообщает, что точка p хуже other: неопределенная точка хуже любой определенной,
+// остальные сравниваются по запасу.
+func (p LimitPoint) worseThan(other LimitPoint) bool {
+	if p.Undefined != other.Undefined {
+		return p.Undefined
+	}
+	return p.Margin < other.Margin
+}
+
+// finiteOrNil возвращает nil для бесконечных значений и NaN, которые нельзя записать в JSON.
+func finiteOrNil(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+type LimitMaskResult struct {
+	Name   string       `json:"name"`
+	Trace  TraceSpec    `json:"trace"`
+	Pass   bool         `json:"pass"`
+	Worst  *LimitPoint  `json:"worst,omitempty"`
+	Points []LimitPoint `json:"points"`
+}
+
+type LimitResult struct {
+	Pass  bool              `json:"pass"`
+	Worst *LimitPoint       `json:"worst,omitempty"`
+	Masks []LimitMaskResult `json:"masks"`
+}
+
+func (s LimitSegment) valueAt(freq float64) float64 {
+	if s.Stop == s.Start {
+		return s.StartValue
+	}
+	return s.StartValue + (freq-s.Start)/(s.Stop-s.Start)*(s.StopValue-s.StartValue)
+}
+
+func (m LimitMask) Validate() error {
+	if len(m.Segments) == 0 {
+		return fmt.Errorf("маска %q не содержит отрезков", m.Name)
+	}
+	for i, s := range m.Segments {
+		if s.Type != LimitUpper && s.Type != LimitLower {
+			return fmt.Errorf("маска %q, отрезок %d: неизвестный тип границы %q", m.Name, i+1, s.Type)
+		}
+		if s.Stop < s.Start {
+			return fmt.Errorf("маска %q, отрезок %d: конечная частота меньше начальной", m.Name, i+1)
+		}
+	}
+	return nil
+}
+
+// EvaluateLimits проверяет данные по маскам и возвращает запасы по каждой точке,
+// худшую точку и общий результат. Точки вне всех отрезков маски не проверяются.
+func (d *VNAData) EvaluateLimits(masks ...LimitMask) (LimitResult, error) {
+	if len(masks) == 0 {
+		return LimitResult{}, errors.New("не задано ни одной маски")
+	}
+	result := LimitResult{Pass: true}
+	for _, mask := range masks {
+		if err := mask.Validate(); err != nil {
+			return LimitResult{}, err
+		}
+		trace, err := d.Trace(mask.Trace)
+		if err != nil {
+			return LimitResult{}, fmt.Errorf("маска %q: %w", mask.Name, err)
+		}
+		maskResult := LimitMaskResult{Name: mask.Name, Trace: mask.Trace.withDefaults(), Pass: true}
+		for i, freq := range d.Frequencies {
+			margin, checked := mask.margin(freq, trace[i])
+			if !checked {
+				continue
+			}
+			undefined := math.IsNaN(margin)
+			point := LimitPoint{Frequency: freq, Value: trace[i], Margin: margin, Pass: !undefined && margin >= 0, Undefined: undefined}
+			maskResult.Points = append(maskResult.Points, point)
+			if maskResult.Worst == nil || point.worseThan(*maskResult.Worst) {
+				worst := point
+				maskResult.Worst = &worst
+			}
+			if !point.Pass {
+				maskResult.Pass = false
+			}
+		}
+		if !maskResult.Pass {
+			result.Pass = false
+		}
+		if maskResult.Worst != nil && (result.Worst == nil || maskResult.Worst.worseThan(*result.Worst)) {
+			worst := *maskResult.Worst
+			result.Worst = &worst
+		}
+		result.Masks = append(result.Masks, maskResult)
+	}
+	return result, nil
+}
+
+// CheckLimits проверяет данные устройства по маскам, как EvaluateLimits, и публикует
+// EventLimitFailed в шину событий устройства, если хотя бы одна маска не пройдена.
+func (v *VNA) CheckLimits(data *VNAData, masks ...LimitMask) (LimitResult, error) {
+	result, err := data.EvaluateLimits(masks...)
+	if err != nil || result.Pass {
+		return result, err
+	}
+	var failed []string
+	for _, mask := range result.Masks {
+		if !mask.Pass {
+			failed = append(failed, mask.Name)
+		}
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.publishLocked(EventLimitFailed, "не пройдены маски: "+strings.Join(failed, ", "),
+		map[string]any{"masks": failed, "worst": result.Worst})
+	return result, nil
+}
+
+func (m LimitMask) margin(freq, value float64) (float64, bool) {
+	margin := math.Inf(1)
+	checked := false
+	for _, s := range m.Segments {
+		if freq < s.Start || freq > s.Stop {
+			continue
+		}
+		checked = true
+		limit := s.valueAt(freq)
+		if s.Type == LimitUpper {
+			margin = math.Min(margin, limit-value)
+		} else {
+			margin = math.Min(margin, value-limit)
+		}
+	}
+	if math.IsNaN(value) {
+		margin = math.NaN()
+	}
+	return margin, checked
+}
+
+// LoadLimitMasksJSON читает одну маску или массив масок в формате JSON.
+func LoadLimitMasksJSON(r io.Reader) ([]LimitMask, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	var masks []LimitMask
+	if len(raw) > 0 && raw[0] == '[' {
+		err = json.Unmarshal(raw, &masks)
+	} else {
+		var mask LimitMask
+		err = json.Unmarshal(raw, &mask)
+		masks = []LimitMask{mask}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора маски JSON: %w", err)
+	}
+	for _, mask := range masks {
+		if err := mask.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return masks, nil
+}
+
+// LoadLimitMaskCSV читает маску из CSV со столбцами type,start,stop,start_value,stop_value.
+// Строки, начинающиеся с '#', и строка заголовка пропускаются.
+func LoadLimitMaskCSV(r io.Reader, name string, trace TraceSpec) (LimitMask, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return LimitMask{}, fmt.Errorf("ошибка разбора маски CSV: %w", err)
+	}
+	mask := LimitMask{Name: name, Trace: trace}
+	for line, record := range records {
+		if line == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "type") {
+			continue
+		}
+		if len(record) < 5 {
+			return LimitMask{}, fmt.Errorf("строка %d маски CSV содержит %d столбцов, ожидалось 5", line+1, len(record))
+		}
+		segment := LimitSegment{Type: LimitType(strings.ToLower(strings.TrimSpace(record[0])))}
+		fields := []*float64{&segment.Start, &segment.Stop, &segment.StartValue, &segment.StopValue}
+		for i, field := range fields {
+			*field, err = strconv.ParseFloat(strings.TrimSpace(record[i+1]), 64)
+			if err != nil {
+				return LimitMask{}, fmt.Errorf("строка %d маски CSV, столбец %d: %w", line+1, i+2, err)
+			}
+		}
+		mask.Segments = append(mask.Segments, segment)
+	}
+	if err := mask.Validate(); err != nil {
+		return LimitMask{}, err
+	}
+	return mask, nil
+}
@@ -0,0 +1,130 @@
+package govna
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestVNAData_EvaluateLimits(t *testing.T) {
+	data := resonatorSweep(10e6, 50)
+	mask, err := LoadLimitMaskCSV(strings.NewReader(
+		"type,start,stop,start_value,stop_value\n"+
+			"lower,9.95e6,10.05e6,-1,-1\n"+
+			"upper,8e6,9e6,-15,-15\n"+
+			"upper,11e6,12e6,-15,-15\n"), "bandpass", TraceSpec{Parameter: S21})
+	if err != nil {
+		t.Fatalf("LoadLimitMaskCSV failed: %v", err)
+	}
+
+	result, err := data.EvaluateLimits(mask)
+	if err != nil {
+		t.Fatalf("EvaluateLimits failed: %v", err)
+	}
+	if !result.Pass {
+		t.Fatalf("expected pass, worst point %+v", result.Worst)
+	}
+
+	masks, err := LoadLimitMasksJSON(strings.NewReader(`{"name":"tight","trace":{"parameter":"S21","format":"logmag"},
+		"segments":[{"type":"lower","start":9.9e6,"stop":10.1e6,"start_value":-1,"stop_value":-1}]}`))
+	if err != nil {
+		t.Fatalf("LoadLimitMasksJSON failed: %v", err)
+	}
+	result, err = data.EvaluateLimits(masks...)
+	if err != nil {
+		t.Fatalf("EvaluateLimits failed: %v", err)
+	}
+	if result.Pass || result.Worst == nil || result.Worst.Margin >= 0 {
+		t.Fatalf("expected failure with negative margin, got %+v", result.Worst)
+	}
+	if math.Abs(result.Worst.Frequency-9.9e6) > 1e4 && math.Abs(result.Worst.Frequency-10.1e6) > 1e4 {
+		t.Fatalf("expected worst point at the mask edge, got %g", result.Worst.Frequency)
+	}
+}
+
+func TestVNAData_EvaluateLimitsNonFinite(t *testing.T) {
+	data := VNAData{
+		Frequencies: []float64{1e6, 2e6},
+		S11:         []complex128{0, 0},
+		S21:         []complex128{0, 0.5},
+	}
+	mask := LimitMask{Name: "floor", Trace: TraceSpec{Parameter: S21},
+		Segments: []LimitSegment{{Type: LimitLower, Start: 1e6, Stop: 2e6, StartValue: -20, StopValue: -20}}}
+	result, err := data.EvaluateLimits(mask)
+	if err != nil {
+		t.Fatalf("EvaluateLimits failed: %v", err)
+	}
+	if result.Pass || result.Worst == nil || result.Worst.Frequency != 1e6 {
+		t.Fatalf("expected the zero sample to fail, got %+v", result.Worst)
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("expected non-finite values to be encodable, got %v", err)
+	}
+	if !strings.Contains(string(encoded), `{"frequency":1000000,"value":null,"margin":null,"pass":false}`) {
+		t.Fatalf("expected null value and margin for the zero sample, got %s", encoded)
+	}
+	if !strings.Contains(string(encoded), `"frequency":2000000,"value":-6.0`) {
+		t.Fatalf("expected finite values to be kept, got %s", encoded)
+	}
+}
+
+func TestVNAData_EvaluateLimitsUndefined(t *testing.T) {
+	data := VNAData{
+		Frequencies: []float64{1e6, 2e6, 3e6},
+		S11:         []complex128{0, 0, 0},
+		S21:         []complex128{0, complex(math.NaN(), 0), 0.5},
+	}
+	mask := LimitMask{Name: "floor", Trace: TraceSpec{Parameter: S21},
+		Segments: []LimitSegment{{Type: LimitLower, Start: 1e6, Stop: 3e6, StartValue: -20, StopValue: -20}}}
+	result, err := data.EvaluateLimits(mask)
+	if err != nil {
+		t.Fatalf("EvaluateLimits failed: %v", err)
+	}
+	point := result.Masks[0].Points[1]
+	if !point.Undefined || point.Pass || !math.IsNaN(point.Margin) {
+		t.Fatalf("expected the NaN sample to be undefined and failed, got %+v", point)
+	}
+	if result.Pass || result.Worst == nil || !result.Worst.Undefined || result.Worst.Frequency != 2e6 {
+		t.Fatalf("expected the undefined sample to be the worst point, got %+v", result.Worst)
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("expected undefined values to be encodable, got %v", err)
+	}
+	if !strings.Contains(string(encoded), `{"frequency":2000000,"value":null,"margin":null,"pass":false,"undefined":true}`) {
+		t.Fatalf("expected an undefined flag and null margin for the NaN sample, got %s", encoded)
+	}
+}
+
+func TestVNA_CheckLimitsPublishesFailure(t *testing.T) {
+	data := resonatorSweep(10e6, 50)
+	pass := LimitMask{Name: "loose", Trace: TraceSpec{Parameter: S21},
+		Segments: []LimitSegment{{Type: LimitLower, Start: 9.95e6, Stop: 10.05e6, StartValue: -1, StopValue: -1}}}
+	fail := LimitMask{Name: "tight", Trace: TraceSpec{Parameter: S21},
+		Segments: []LimitSegment{{Type: LimitUpper, Start: 9.95e6, Stop: 10.05e6, StartValue: -20, StopValue: -20}}}
+
+	bus := NewEventBus()
+	events := bus.Subscribe(EventFilter{Types: []EventType{EventLimitFailed}}, 0)
+	vna := NewVNA(newStubDriver(nil))
+	vna.SetEventBus(bus, "/dev/test")
+
+	if result, err := vna.CheckLimits(&data, pass); err != nil || !result.Pass {
+		t.Fatalf("expected pass, got %+v, %v", result, err)
+	}
+	result, err := vna.CheckLimits(&data, pass, fail)
+	if err != nil || result.Pass {
+		t.Fatalf("expected failure, got %+v, %v", result, err)
+	}
+	if len(events.C) != 1 {
+		t.Fatalf("expected one limit.failed event, got %d", len(events.C))
+	}
+	event := <-events.C
+	masks, _ := event.Data.(map[string]any)["masks"].([]string)
+	if event.Device != "/dev/test" || len(masks) != 1 || masks[0] != "tight" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
@@ -0,0 +1,472 @@
+// Этот файл содержит маркеры: поиск экстремумов, целевых значений, пиков и полосы пропускания по уровню N дБ.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+type MarkerSearch string
+
+const (
+	MarkerFixed     MarkerSearch = "fixed"
+	MarkerMax       MarkerSearch = "max"
+	MarkerMin       MarkerSearch = "min"
+	MarkerTarget    MarkerSearch = "target"
+	MarkerPeakLeft  MarkerSearch = "peak_left"
+	MarkerPeakRight MarkerSearch = "peak_right"
+)
+
+// DefaultPeakExcursion - минимальное превышение пика над соседними минимумами, в единицах трассы.
+const DefaultPeakExcursion = 3.0
+
+// Marker описывает маркер на трассе. Frequency - положение фиксированного маркера или начальная
+// точка для поиска пика/цели. Reference - имя опорного маркера для дельта-маркера.
+// BandwidthLevel > 0 включает поиск полосы по уровню N дБ вокруг позиции маркера.
+// При Tracking=true поиск выполняется заново на каждом свипе, иначе - только на первом.
+type Marker struct {
+	Name           string
+	Trace          TraceSpec
+	Search         MarkerSearch
+	Frequency      float64
+	Target         float64
+	PeakExcursion  float64
+	Reference      string
+	Tracking       bool
+	BandwidthLevel float64
+	ShapeLevel     float64
+}
+
+// MarkerReading - показание маркера на конкретном свипе.
+type MarkerReading struct {
+	Name           string
+	Frequency      float64
+	Value          float64
+	Index          int
+	Delta          bool
+	DeltaFrequency float64
+	DeltaValue     float64
+	Bandwidth      *BandwidthResult
+}
+
+// BandwidthResult - результат поиска полосы по уровню N дБ.
+// ShapeFactor - отношение полосы по уровню ShapeLevel к полосе по уровню Level.
+type BandwidthResult struct {
+	Level       float64
+	Center      float64
+	Low, High   float64
+	Span        float64
+	Q           float64
+	Loss        float64
+	ShapeFactor float64
+}
+
+// MarkerAt возвращает значение трассы на частоте freq с линейной интерполяцией.
+func (d *VNAData) MarkerAt(spec TraceSpec, freq float64) (MarkerReading, error) {
+	trace, err := d.Trace(spec)
+	if err != nil {
+		return MarkerReading{}, err
+	}
+	return d.readingAt(trace, freq)
+}
+
+func (d *VNAData) readingAt(trace []float64, freq float64) (MarkerReading, error) {
+	freqs := d.Frequencies
+	if freq < freqs[0] || freq > freqs[len(freqs)-1] {
+		return MarkerReading{}, fmt.Errorf("частота %.3f Гц вне диапазона свипа", freq)
+	}
+	i := 0
+	for i < len(freqs)-2 && freqs[i+1] < freq {
+		i++
+	}
+	if len(freqs) == 1 {
+		return MarkerReading{Frequency: freq, Value: trace[0]}, nil
+	}
+	frac := (freq - freqs[i]) / (freqs[i+1] - freqs[i])
+	index := i
+	if frac > 0.5 {
+		index = i + 1
+	}
+	return MarkerReading{
+		Frequency: freq,
+		Value:     trace[i] + frac*(trace[i+1]-trace[i]),
+		Index:     index,
+	}, nil
+}
+
+func (d *VNAData) readingAtIndex(trace []float64, index int) MarkerReading {
+	return MarkerReading{Frequency: d.Frequencies[index], Value: trace[index], Index: index}
+}
+
+// SearchMax находит максимум трассы.
+func (d *VNAData) SearchMax(spec TraceSpec) (MarkerReading, error) {
+	return d.searchExtremum(spec, 1)
+}
+
+// SearchMin находит минимум трассы.
+func (d *VNAData) SearchMin(spec TraceSpec) (MarkerReading, error) {
+	return d.searchExtremum(spec, -1)
+}
+
+func (d *VNAData) searchExtremum(spec TraceSpec, sign float64) (MarkerReading, error) {
+	trace, err := d.Trace(spec)
+	if err != nil {
+		return MarkerReading{}, err
+	}
+	return d.readingAtIndex(trace, extremumIndex(trace, sign)), nil
+}
+
+func extremumIndex(trace []float64, sign float64) int {
+	best := 0
+	for i, v := range trace {
+		if sign*v > sign*trace[best] || math.IsNaN(trace[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// SearchTarget находит ближайшую к частоте from точку пересечения трассы с уровнем target
+// в направлении direction (-1 - влево, +1 - вправо), интерполируя частоту между отсчетами.
+func (d *VNAData) SearchTarget(spec TraceSpec, target, from float64, direction int) (MarkerReading, error) {
+	trace, err := d.Trace(spec)
+	if err != nil {
+		return MarkerReading{}, err
+	}
+	start, err := d.readingAt(trace, from)
+	if err != nil {
+		return MarkerReading{}, err
+	}
+	freq, ok := d.crossing(trace, target, start.Index, direction)
+	if !ok {
+		return MarkerReading{}, fmt.Errorf("трасса не пересекает уровень %g", target)
+	}
+	return d.readingAt(trace, freq)
+}
+
+// crossing ищет пересечение уровня начиная с индекса start в направлении direction.
+func (d *VNAData) crossing(trace []float64, level float64, start, direction int) (float64, bool) {
+	if direction >= 0 {
+		for i := start; i < len(trace)-1; i++ {
+			if f, ok := d.interpolateCrossing(trace, level, i, i+1); ok {
+				return f, true
+			}
+		}
+		return 0, false
+	}
+	for i := start; i > 0; i-- {
+		if f, ok := d.interpolateCrossing(trace, level, i-1, i); ok {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+func (d *VNAData) interpolateCrossing(trace []float64, level float64, a, b int) (float64, bool) {
+	va, vb := trace[a]-level, trace[b]-level
+	if va == 0 {
+		return d.Frequencies[a], true
+	}
+	if vb == 0 {
+		return d.Frequencies[b], true
+	}
+	if (va < 0) == (vb < 0) {
+		return 0, false
+	}
+	frac := va / (va - vb)
+	return d.Frequencies[a] + frac*(d.Frequencies[b]-d.Frequencies[a]), true
+}
+
+// SearchNextPeak находит ближайший пик слева (direction < 0) или справа (direction > 0) от частоты from.
+// Пик должен возвышаться над минимумами с обеих сторон не менее чем на excursion.
+func (d *VNAData) SearchNextPeak(spec TraceSpec, from float64, direction int, excursion float64) (MarkerReading, error) {
+	trace, err := d.Trace(spec)
+	if err != nil {
+		return MarkerReading{}, err
+	}
+	start, err := d.readingAt(trace, from)
+	if err != nil {
+		return MarkerReading{}, err
+	}
+	index, ok := nextPeak(trace, start.Index, direction, excursion)
+	if !ok {
+		return MarkerReading{}, errors.New("пик в заданном направлении не найден")
+	}
+	return d.readingAtIndex(trace, index), nil
+}
+
+func nextPeak(trace []float64, start, direction int, excursion float64) (int, bool) {
+	if excursion <= 0 {
+		excursion = DefaultPeakExcursion
+	}
+	step := 1
+	if direction < 0 {
+		step = -1
+	}
+	for i := start + step; i > 0 && i < len(trace)-1; i += step {
+		if trace[i] < trace[i-1] || trace[i] < trace[i+1] {
+			continue
+		}
+		if peakExcursion(trace, i) >= excursion {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// peakExcursion возвращает меньшее из превышений пика над минимумами слева и справа до более высокой точки.
+func peakExcursion(trace []float64, peak int) float64 {
+	left := trace[peak]
+	for i := peak - 1; i >= 0 && trace[i] <= trace[peak]; i-- {
+		left = math.Min(left, trace[i])
+	}
+	right := trace[peak]
+	for i := peak + 1; i < len(trace) && trace[i] <= trace[peak]; i++ {
+		right = math.Min(right, trace[i])
+	}
+	return trace[peak] - math.Max(left, right)
+}
+
+// Bandwidth ищет полосу по уровню level дБ ниже максимума трассы (или выше минимума для notch=true)
+// вблизи частоты center; при center = 0 используется глобальный экстремум.
+// Если shapeLevel > 0, дополнительно вычисляется коэффициент прямоугольности.
+func (d *VNAData) Bandwidth(spec TraceSpec, center, level, shapeLevel float64, notch bool) (BandwidthResult, error) {
+	trace, err := d.Trace(spec)
+	if err != nil {
+		return BandwidthResult{}, err
+	}
+	return d.bandwidth(trace, center, level, shapeLevel, notch)
+}
+
+func (d *VNAData) bandwidth(trace []float64, center, level, shapeLevel float64, notch bool) (BandwidthResult, error) {
+	if level <= 0 {
+		return BandwidthResult{}, errors.New("уровень поиска полосы должен быть положительным")
+	}
+	sign := 1.0
+	if notch {
+		sign = -1
+	}
+	peak := extremumIndex(trace, sign)
+	if center != 0 {
+		reading, err := d.readingAt(trace, center)
+		if err != nil {
+			return BandwidthResult{}, err
+		}
+		peak = reading.Index
+	}
+
+	low, high, err := d.levelEdges(trace, peak, trace[peak]-sign*level)
+	if err != nil {
+		return BandwidthResult{}, fmt.Errorf("полоса по уровню %g дБ: %w", level, err)
+	}
+	result := BandwidthResult{
+		Level:  level,
+		Low:    low,
+		High:   high,
+		Span:   high - low,
+		Center: math.Sqrt(low * high),
+		Loss:   trace[peak],
+	}
+	if low <= 0 {
+		result.Center = (low + high) / 2
+	}
+	if result.Span > 0 {
+		result.Q = result.Center / result.Span
+	}
+	if shapeLevel > 0 {
+		shapeLow, shapeHigh, err := d.levelEdges(trace, peak, trace[peak]-sign*shapeLevel)
+		if err != nil {
+			return BandwidthResult{}, fmt.Errorf("полоса по уровню %g дБ: %w", shapeLevel, err)
+		}
+		if result.Span > 0 {
+			result.ShapeFactor = (shapeHigh - shapeLow) / result.Span
+		}
+	}
+	return result, nil
+}
+
+func (d *VNAData) levelEdges(trace []float64, peak int, level float64) (float64, float64, error) {
+	low, okLow := d.crossing(trace, level, peak, -1)
+	high, okHigh := d.crossing(trace, level, peak, 1)
+	if !okLow || !okHigh {
+		return 0, 0, errors.New("трасса не пересекает уровень по обе стороны от экстремума")
+	}
+	return low, high, nil
+}
+
+// MarkerSet хранит набор маркеров и их позиции между свипами. Безопасен для конкурентного использования.
+type MarkerSet struct {
+	mu        sync.Mutex
+	markers   []Marker
+	positions map[string]float64
+}
+
+func NewMarkerSet() *MarkerSet {
+	return &MarkerSet{positions: make(map[string]float64)}
+}
+
+func (s *MarkerSet) Add(marker Marker) error {
+	if marker.Name == "" {
+		return errors.New("имя маркера не может быть пустым")
+	}
+	if marker.Search == "" {
+		marker.Search = MarkerFixed
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.markers {
+		if existing.Name == marker.Name {
+			return fmt.Errorf("маркер %s уже существует", marker.Name)
+		}
+	}
+	if marker.Reference != "" && !s.hasMarker(marker.Reference) {
+		return fmt.Errorf("опорный маркер %s не найден", marker.Reference)
+	}
+	s.markers = append(s.markers, marker)
+	return nil
+}
+
+func (s *MarkerSet) hasMarker(name string) bool {
+	for _, m := range s.markers {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove удаляет маркер. Опорный маркер, на который ссылаются дельта-маркеры, удалить нельзя:
+// сначала удалите дельта-маркеры или смените у них опорный маркер.
+func (s *MarkerSet) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.markers {
+		if m.Reference == name {
+			return fmt.Errorf("маркер %s является опорным для дельта-маркера %s", name, m.Name)
+		}
+	}
+	for i, m := range s.markers {
+		if m.Name == name {
+			s.markers = append(s.markers[:i], s.markers[i+1:]...)
+			delete(s.positions, name)
+			return nil
+		}
+	}
+	return fmt.Errorf("маркер %s не найден", name)
+}
+
+// Markers возвращает копию списка маркеров.
+func (s *MarkerSet) Markers() []Marker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Marker(nil), s.markers...)
+}
+
+// Reset сбрасывает найденные позиции, чтобы нетрекающие маркеры выполнили поиск заново.
+func (s *MarkerSet) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.positions = make(map[string]float64)
+}
+
+// Evaluate вычисляет показания всех маркеров на очередном свипе.
+func (s *MarkerSet) Evaluate(data VNAData) ([]MarkerReading, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	readings := make([]MarkerReading, 0, len(s.markers))
+	byName := make(map[string]MarkerReading, len(s.markers))
+	for _, marker := range s.markers {
+		reading, err := s.evaluate(&data, marker)
+		if err != nil {
+			return nil, fmt.Errorf("маркер %s: %w", marker.Name, err)
+		}
+		if ref, ok := byName[marker.Reference]; ok && marker.Reference != "" {
+			reading.Delta = true
+			reading.DeltaFrequency = reading.Frequency - ref.Frequency
+			reading.DeltaValue = reading.Value - ref.Value
+		}
+		byName[marker.Name] = reading
+		readings = append(readings, reading)
+	}
+	return readings, nil
+}
+
+func (s *MarkerSet) evaluate(data *VNAData, marker Marker) (MarkerReading, error) {
+	trace, err := data.Trace(marker.Trace)
+	if err != nil {
+		return MarkerReading{}, err
+	}
+
+	position, found := s.positions[marker.Name]
+	var reading MarkerReading
+	if found && !marker.Tracking {
+		reading, err = data.readingAt(trace, position)
+	} else {
+		reading, err = data.search(trace, marker, position, found)
+	}
+	if err != nil {
+		return MarkerReading{}, err
+	}
+	reading.Name = marker.Name
+	s.positions[marker.Name] = reading.Frequency
+
+	if marker.BandwidthLevel > 0 {
+		notch := marker.Search == MarkerMin
+		bw, err := data.bandwidth(trace, reading.Frequency, marker.BandwidthLevel, marker.ShapeLevel, notch)
+		if err != nil {
+			return MarkerReading{}, err
+		}
+		reading.Bandwidth = &bw
+	}
+	return reading, nil
+}
+
+func (d *VNAData) search(trace []float64, marker Marker, previous float64, hasPrevious bool) (MarkerReading, error) {
+	start := marker.Frequency
+	if start == 0 {
+		start = d.Frequencies[0]
+	}
+	switch marker.Search {
+	case MarkerFixed:
+		return d.readingAt(trace, marker.Frequency)
+	case MarkerMax:
+		return d.readingAtIndex(trace, extremumIndex(trace, 1)), nil
+	case MarkerMin:
+		return d.readingAtIndex(trace, extremumIndex(trace, -1)), nil
+	case MarkerTarget:
+		if hasPrevious {
+			start = previous
+		}
+		from, err := d.readingAt(trace, start)
+		if err != nil {
+			return MarkerReading{}, err
+		}
+		freq, ok := d.crossing(trace, marker.Target, from.Index, 1)
+		if !ok {
+			freq, ok = d.crossing(trace, marker.Target, from.Index, -1)
+		}
+		if !ok {
+			return MarkerReading{}, fmt.Errorf("трасса не пересекает уровень %g", marker.Target)
+		}
+		return d.readingAt(trace, freq)
+	case MarkerPeakLeft, MarkerPeakRight:
+		direction := 1
+		if marker.Search == MarkerPeakLeft {
+			direction = -1
+		}
+		from, err := d.readingAt(trace, start)
+		if err != nil {
+			return MarkerReading{}, err
+		}
+		index, ok := nextPeak(trace, from.Index, direction, marker.PeakExcursion)
+		if !ok {
+			return MarkerReading{}, errors.New("пик в заданном направлении не найден")
+		}
+		return d.readingAtIndex(trace, index), nil
+	default:
+		return MarkerReading{}, fmt.Errorf("неизвестный режим поиска маркера %q", marker.Search)
+	}
+}
@@ -0,0 +1,112 @@
+package govna
+
+import (
+	"math"
+	"testing"
+)
+
+func resonatorSweep(f0, q float64) VNAData {
+	data := VNAData{Frequencies: make([]float64, 801), S11: make([]complex128, 801), S21: make([]complex128, 801)}
+	for i := range data.Frequencies {
+		f := f0*0.8 + float64(i)*f0*0.4/800
+		data.Frequencies[i] = f
+		data.S21[i] = 1 / complex(1, q*(f/f0-f0/f))
+		data.S11[i] = 1 - data.S21[i]
+	}
+	return data
+}
+
+func TestVNAData_BandwidthSearch(t *testing.T) {
+	data := resonatorSweep(10e6, 50)
+	spec := TraceSpec{Parameter: S21, Format: FormatLogMag}
+
+	peak, err := data.SearchMax(spec)
+	if err != nil {
+		t.Fatalf("SearchMax failed: %v", err)
+	}
+	if math.Abs(peak.Frequency-10e6) > 5e3 {
+		t.Fatalf("expected peak at 10 MHz, got %g", peak.Frequency)
+	}
+
+	bw, err := data.Bandwidth(spec, 0, 3.0103, 20, false)
+	if err != nil {
+		t.Fatalf("Bandwidth failed: %v", err)
+	}
+	if math.Abs(bw.Span-200e3) > 1e3 || math.Abs(bw.Q-50) > 0.5 {
+		t.Fatalf("expected 200 kHz bandwidth and Q=50, got %g Hz and Q=%g", bw.Span, bw.Q)
+	}
+	// Для одиночного контура полоса по уровню -20 дБ шире полосы -3 дБ в sqrt(99) раз.
+	if math.Abs(bw.ShapeFactor-math.Sqrt(99)) > 0.1 {
+		t.Fatalf("expected shape factor %g, got %g", math.Sqrt(99), bw.ShapeFactor)
+	}
+
+	low, err := data.SearchTarget(spec, -3.0103, 10e6, -1)
+	if err != nil {
+		t.Fatalf("SearchTarget failed: %v", err)
+	}
+	if math.Abs(low.Frequency-bw.Low) > 1 || math.Abs(low.Value+3.0103) > 1e-3 {
+		t.Fatalf("expected target at %g, got %g (%g)", bw.Low, low.Frequency, low.Value)
+	}
+}
+
+func TestMarkerSet_TrackingAndDelta(t *testing.T) {
+	markers := NewMarkerSet()
+	spec := TraceSpec{Parameter: S21}
+	if err := markers.Add(Marker{Name: "peak", Trace: spec, Search: MarkerMax, Tracking: true, BandwidthLevel: 3}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := markers.Add(Marker{Name: "fixed", Trace: spec, Frequency: 10.5e6, Reference: "peak"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := markers.Add(Marker{Name: "orphan", Reference: "missing"}); err == nil {
+		t.Fatalf("expected error for unknown reference marker")
+	}
+
+	for _, f0 := range []float64{10e6, 10.2e6} {
+		readings, err := markers.Evaluate(resonatorSweep(f0, 50))
+		if err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+		if math.Abs(readings[0].Frequency-f0) > 5e3 {
+			t.Fatalf("expected tracking marker at %g, got %g", f0, readings[0].Frequency)
+		}
+		if readings[0].Bandwidth == nil || math.Abs(readings[0].Bandwidth.Q-50) > 1 {
+			t.Fatalf("expected bandwidth Q near 50, got %+v", readings[0].Bandwidth)
+		}
+		if !readings[1].Delta || math.Abs(readings[1].DeltaFrequency-(10.5e6-readings[0].Frequency)) > 1e-6 {
+			t.Fatalf("unexpected delta reading %+v", readings[1])
+		}
+	}
+}
+
+func TestMarkerSet_RemoveReference(t *testing.T) {
+	markers := NewMarkerSet()
+	spec := TraceSpec{Parameter: S21}
+	if err := markers.Add(Marker{Name: "peak", Trace: spec, Search: MarkerMax}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := markers.Add(Marker{Name: "delta", Trace: spec, Frequency: 10.5e6, Reference: "peak"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if err := markers.Remove("peak"); err == nil {
+		t.Fatalf("expected error when removing a reference marker")
+	}
+	readings, err := markers.Evaluate(resonatorSweep(10e6, 50))
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if len(readings) != 2 || !readings[1].Delta {
+		t.Fatalf("expected the delta marker to stay relative to the reference, got %+v", readings)
+	}
+
+	if err := markers.Remove("delta"); err != nil {
+		t.Fatalf("Remove delta failed: %v", err)
+	}
+	if err := markers.Remove("peak"); err != nil {
+		t.Fatalf("Remove reference failed after its delta marker was removed: %v", err)
+	}
+	if err := markers.Remove("peak"); err == nil {
+		t.Fatalf("expected error when removing an unknown marker")
+	}
+}
@@ -0,0 +1,391 @@
+// Этот файл содержит синтез согласующих цепей (L, π, T) по измеренному импедансу нагрузки.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"strings"
+)
+
+type MatchingTopology string
+
+const (
+	MatchingL  MatchingTopology = "l"
+	MatchingPi MatchingTopology = "pi"
+	MatchingT  MatchingTopology = "t"
+)
+
+// MatchingConfig задает параметры синтеза.
+// Если Frequency не задана, расчет ведется на геометрическом центре полосы BandStart..BandStop
+// (по умолчанию - всего свипа). Q - нагруженная добротность π- и T-цепей; при Q = 0 берется
+// минимально возможная добротность плюс единица, а топологии, для которых Q слишком мала, пропускаются.
+// ESeries - ряд номиналов (6, 12, 24, 48, 96, 192), 0 - идеальные номиналы.
+type MatchingConfig struct {
+	Frequency  float64
+	BandStart  float64
+	BandStop   float64
+	ESeries    int
+	Q          float64
+	Topologies []MatchingTopology
+}
+
+// MatchingElement - компонент цепи: Type "L" или "C", Placement "series" или "shunt".
+type MatchingElement struct {
+	Type      string  `json:"type"`
+	Placement string  `json:"placement"`
+	Value     float64 `json:"value"`
+}
+
+// MatchingSolution - вариант согласующей цепи. Elements перечислены от источника к нагрузке.
+// ReturnLoss - прогноз возвратных потерь согласованной нагрузки на частотах свипа (дБ),
+// BandReturnLoss - худшее значение в полосе, TargetReturnLoss - на расчетной частоте.
+type MatchingSolution struct {
+	Topology         MatchingTopology  `json:"topology"`
+	Layout           string            `json:"layout"`
+	Elements         []MatchingElement `json:"elements"`
+	Frequency        float64           `json:"frequency"`
+	Q                float64           `json:"q,omitempty"`
+	TargetReturnLoss float64           `json:"target_return_loss"`
+	BandReturnLoss   float64           `json:"band_return_loss"`
+	ReturnLoss       []float64         `json:"return_loss"`
+}
+
+// lSection - реактивности L-звена на расчетной частоте. При shuntAtLoad параллельный элемент
+// подключен к нагрузке, а последовательный обращен к источнику; иначе наоборот.
+type lSection struct {
+	x, b        float64
+	shuntAtLoad bool
+}
+
+// lMatch находит все L-звенья, трансформирующие zl в активное сопротивление r0.
+func lMatch(zl complex128, r0 float64) []lSection {
+	rl, xl := real(zl), imag(zl)
+	if rl <= 0 || r0 <= 0 {
+		return nil
+	}
+	var sections []lSection
+	mag2 := rl*rl + xl*xl
+	if mag2 >= r0*rl {
+		root := math.Sqrt(rl/r0) * math.Sqrt(mag2-r0*rl)
+		for _, sign := range []float64{1, -1} {
+			b := (xl + sign*root) / mag2
+			z := 1 / (1/zl + complex(0, b))
+			sections = append(sections, lSection{x: -imag(z), b: b, shuntAtLoad: true})
+			if root == 0 {
+				break
+			}
+		}
+	}
+	if rl <= r0 {
+		root := math.Sqrt(rl * (r0 - rl))
+		for _, sign := range []float64{1, -1} {
+			x := sign*root - xl
+			y := 1 / (zl + complex(0, x))
+			sections = append(sections, lSection{x: x, b: -imag(y), shuntAtLoad: false})
+			if root == 0 {
+				break
+			}
+		}
+	}
+	return sections
+}
+
+// MatchingNetworks предлагает согласующие цепи для нагрузки, измеренной в S11, к опорному сопротивлению порта 1.
+// Решения отсортированы по убыванию худших возвратных потерь в полосе.
+func (d *VNAData) MatchingNetworks(cfg MatchingConfig) ([]MatchingSolution, error) {
+	if len(d.S11) != len(d.Frequencies) || len(d.S11) == 0 {
+		return nil, errors.New("нет данных S11 для синтеза согласования")
+	}
+	if cfg.ESeries != 0 {
+		if _, err := eSeriesValues(cfg.ESeries); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Q < 0 {
+		return nil, errors.New("нагруженная добротность не может быть отрицательной")
+	}
+	bandStart, bandStop := cfg.BandStart, cfg.BandStop
+	if bandStart == 0 && bandStop == 0 {
+		bandStart, bandStop = d.Frequencies[0], d.Frequencies[len(d.Frequencies)-1]
+	}
+	if bandStop < bandStart {
+		return nil, errors.New("конечная частота полосы меньше начальной")
+	}
+	f0 := cfg.Frequency
+	if f0 == 0 {
+		f0 = math.Sqrt(bandStart * bandStop)
+	}
+	if f0 <= 0 {
+		return nil, errors.New("расчетная частота должна быть положительной")
+	}
+
+	loads, err := d.Impedance(S11)
+	if err != nil {
+		return nil, err
+	}
+	zl, err := d.interpolateComplex(loads, f0)
+	if err != nil {
+		return nil, err
+	}
+	z0 := real(d.ReferenceImpedance(1))
+	omega := 2 * math.Pi * f0
+
+	topologies := cfg.Topologies
+	if len(topologies) == 0 {
+		topologies = []MatchingTopology{MatchingL, MatchingPi, MatchingT}
+	}
+	var networks [][]MatchingElement
+	var qs []float64
+	var kinds []MatchingTopology
+	add := func(kind MatchingTopology, q float64, elements []MatchingElement) {
+		networks = append(networks, elements)
+		kinds = append(kinds, kind)
+		qs = append(qs, q)
+	}
+	for _, topology := range topologies {
+		switch topology {
+		case MatchingL:
+			for _, s := range lMatch(zl, z0) {
+				if s.shuntAtLoad {
+					add(MatchingL, 0, reactiveElements(omega, z0, seriesReactance(s.x), shuntSusceptance(s.b)))
+				} else {
+					add(MatchingL, 0, reactiveElements(omega, z0, shuntSusceptance(s.b), seriesReactance(s.x)))
+				}
+			}
+		case MatchingPi:
+			// π-цепь - два L-звена через виртуальное сопротивление Rv, меньшее сопротивлений источника и нагрузки.
+			rlp := cmplx.Abs(zl) * cmplx.Abs(zl) / real(zl)
+			q, ok := matchingQ(cfg.Q, z0, rlp)
+			if !ok {
+				continue
+			}
+			rv := math.Max(z0, rlp) / (1 + q*q)
+			for _, load := range lMatch(zl, rv) {
+				if !load.shuntAtLoad {
+					continue
+				}
+				for _, source := range lMatch(complex(rv, 0), z0) {
+					if source.shuntAtLoad {
+						continue
+					}
+					add(MatchingPi, q, reactiveElements(omega, z0, shuntSusceptance(source.b), seriesReactance(source.x+load.x), shuntSusceptance(load.b)))
+				}
+			}
+		case MatchingT:
+			// T-цепь - два L-звена через виртуальное сопротивление Rv, большее сопротивлений источника и нагрузки.
+			q, ok := matchingQ(cfg.Q, z0, real(zl))
+			if !ok {
+				continue
+			}
+			rv := math.Min(z0, real(zl)) * (1 + q*q)
+			for _, load := range lMatch(zl, rv) {
+				if load.shuntAtLoad {
+					continue
+				}
+				for _, source := range lMatch(complex(rv, 0), z0) {
+					if !source.shuntAtLoad {
+						continue
+					}
+					add(MatchingT, q, reactiveElements(omega, z0, seriesReactance(source.x), shuntSusceptance(source.b+load.b), seriesReactance(load.x)))
+				}
+			}
+		default:
+			return nil, fmt.Errorf("неизвестная топология согласующей цепи %q", topology)
+		}
+	}
+
+	var solutions []MatchingSolution
+	seen := make(map[string]bool)
+	for i, elements := range networks {
+		if cfg.ESeries != 0 {
+			for j := range elements {
+				elements[j].Value = nearestESeries(elements[j].Value, cfg.ESeries)
+			}
+		}
+		solution := MatchingSolution{
+			Topology:  kinds[i],
+			Layout:    matchingLayout(elements),
+			Elements:  elements,
+			Frequency: f0,
+			Q:         qs[i],
+		}
+		key := fmt.Sprintf("%s|%s|%.6g", solution.Topology, solution.Layout, elementValues(elements))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		solution.ReturnLoss = make([]float64, len(loads))
+		solution.BandReturnLoss = math.Inf(1)
+		for j, freq := range d.Frequencies {
+			solution.ReturnLoss[j] = matchedReturnLoss(elements, loads[j], freq, z0)
+			if freq >= bandStart && freq <= bandStop {
+				solution.BandReturnLoss = math.Min(solution.BandReturnLoss, solution.ReturnLoss[j])
+			}
+		}
+		solution.TargetReturnLoss = matchedReturnLoss(elements, zl, f0, z0)
+		if math.IsInf(solution.BandReturnLoss, 1) {
+			solution.BandReturnLoss = solution.TargetReturnLoss
+		}
+		solutions = append(solutions, solution)
+	}
+	if len(solutions) == 0 {
+		return nil, errors.New("не найдено ни одной реализуемой согласующей цепи")
+	}
+	sort.SliceStable(solutions, func(a, b int) bool {
+		return solutions[a].BandReturnLoss > solutions[b].BandReturnLoss
+	})
+	return solutions, nil
+}
+
+// matchingQ возвращает нагруженную добротность π/T-цепи и признак ее реализуемости
+// для трансформации между сопротивлениями r1 и r2.
+func matchingQ(q, r1, r2 float64) (float64, bool) {
+	if r1 <= 0 || r2 <= 0 {
+		return 0, false
+	}
+	qmin := math.Sqrt(math.Max(r1, r2)/math.Min(r1, r2) - 1)
+	if q == 0 {
+		return qmin + 1, true
+	}
+	return q, q >= qmin
+}
+
+type reactance struct {
+	value float64
+	shunt bool
+}
+
+func seriesReactance(x float64) reactance  { return reactance{value: x} }
+func shuntSusceptance(b float64) reactance { return reactance{value: b, shunt: true} }
+
+// reactiveElements переводит реактивности на частоте omega в номиналы L и C.
+// Пренебрежимо малые последовательные реактивности (короткое замыкание) и параллельные
+// проводимости (обрыв) опускаются.
+func reactiveElements(omega, z0 float64, parts ...reactance) []MatchingElement {
+	var elements []MatchingElement
+	for _, p := range parts {
+		if p.shunt {
+			switch {
+			case math.Abs(p.value)*z0 < 1e-9:
+			case p.value > 0:
+				elements = append(elements, MatchingElement{Type: "C", Placement: "shunt", Value: p.value / omega})
+			default:
+				elements = append(elements, MatchingElement{Type: "L", Placement: "shunt", Value: -1 / (omega * p.value)})
+			}
+			continue
+		}
+		switch {
+		case math.Abs(p.value) < 1e-9*z0:
+		case p.value > 0:
+			elements = append(elements, MatchingElement{Type: "L", Placement: "series", Value: p.value / omega})
+		default:
+			elements = append(elements, MatchingElement{Type: "C", Placement: "series", Value: -1 / (omega * p.value)})
+		}
+	}
+	return elements
+}
+
+// matchedReturnLoss вычисляет возвратные потери нагрузки zl, согласованной цепью elements, на частоте freq.
+func matchedReturnLoss(elements []MatchingElement, zl complex128, freq, z0 float64) float64 {
+	omega := 2 * math.Pi * freq
+	z := zl
+	for i := len(elements) - 1; i >= 0; i-- {
+		e := elements[i]
+		var ze complex128
+		if e.Type == "L" {
+			ze = complex(0, omega*e.Value)
+		} else {
+			ze = 1 / complex(0, omega*e.Value)
+		}
+		if e.Placement == "series" {
+			z += ze
+		} else {
+			z = z * ze / (z + ze)
+		}
+	}
+	// Ограничение снизу исключает бесконечные значения при идеальном согласовании.
+	gamma := math.Max(cmplx.Abs(impedanceToReflection(z, complex(z0, 0))), 1e-15)
+	return -20 * math.Log10(gamma)
+}
+
+func matchingLayout(elements []MatchingElement) string {
+	parts := make([]string, len(elements))
+	for i, e := range elements {
+		parts[i] = e.Placement + " " + e.Type
+	}
+	if len(parts) == 0 {
+		return "direct"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func elementValues(elements []MatchingElement) []float64 {
+	values := make([]float64, len(elements))
+	for i, e := range elements {
+		values[i] = e.Value
+	}
+	return values
+}
+
+// interpolateComplex линейно интерполирует комплексную величину на частоте freq.
+func (d *VNAData) interpolateComplex(values []complex128, freq float64) (complex128, error) {
+	freqs := d.Frequencies
+	if freq < freqs[0] || freq > freqs[len(freqs)-1] {
+		return 0, fmt.Errorf("частота %.3f Гц вне диапазона свипа", freq)
+	}
+	if len(freqs) == 1 {
+		return values[0], nil
+	}
+	i := 0
+	for i < len(freqs)-2 && freqs[i+1] < freq {
+		i++
+	}
+	frac := (freq - freqs[i]) / (freqs[i+1] - freqs[i])
+	return values[i] + complex(frac, 0)*(values[i+1]-values[i]), nil
+}
+
+var eSeriesTables = map[int][]float64{
+	6:  {1.0, 1.5, 2.2, 3.3, 4.7, 6.8},
+	12: {1.0, 1.2, 1.5, 1.8, 2.2, 2.7, 3.3, 3.9, 4.7, 5.6, 6.8, 8.2},
+	24: {1.0, 1.1, 1.2, 1.3, 1.5, 1.6, 1.8, 2.0, 2.2, 2.4, 2.7, 3.0, 3.3, 3.6, 3.9, 4.3, 4.7, 5.1, 5.6, 6.2, 6.8, 7.5, 8.2, 9.1},
+}
+
+// eSeriesValues возвращает мантиссы ряда E. Ряды E48-E192 вычисляются по формуле IEC 60063
+// с округлением до трех значащих цифр (с исключением 9.20 в E192).
+func eSeriesValues(n int) ([]float64, error) {
+	if table, ok := eSeriesTables[n]; ok {
+		return table, nil
+	}
+	if n != 48 && n != 96 && n != 192 {
+		return nil, fmt.Errorf("неподдерживаемый ряд номиналов E%d", n)
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.Round(math.Pow(10, float64(i)/float64(n))*100) / 100
+		if n == 192 && values[i] == 9.19 {
+			values[i] = 9.20
+		}
+	}
+	return values, nil
+}
+
+// nearestESeries округляет номинал до ближайшего (в логарифмическом масштабе) значения ряда.
+func nearestESeries(value float64, n int) float64 {
+	values, err := eSeriesValues(n)
+	if err != nil || value <= 0 {
+		return value
+	}
+	decade := math.Pow(10, math.Floor(math.Log10(value)))
+	mantissa := value / decade
+	best := values[0]
+	for _, v := range append(append([]float64(nil), values...), 10) {
+		if math.Abs(math.Log(v/mantissa)) < math.Abs(math.Log(best/mantissa)) {
+			best = v
+		}
+	}
+	return best * decade
+}
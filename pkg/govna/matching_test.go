@@ -0,0 +1,52 @@
+package govna
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVNAData_MatchingNetworks(t *testing.T) {
+	freq := make([]float64, 101)
+	for i := range freq {
+		freq[i] = 90e6 + float64(i)*0.2e6
+	}
+	// Нагрузка 10 - j30 Ом на 100 МГц реализуема всеми четырьмя L-цепями.
+	load := EquivalentCircuit{Topology: CircuitSeriesRC, R: 10, C: 1 / (2 * math.Pi * 100e6 * 30)}
+	data := load.Evaluate(freq)
+
+	solutions, err := data.MatchingNetworks(MatchingConfig{Frequency: 100e6})
+	if err != nil {
+		t.Fatalf("MatchingNetworks failed: %v", err)
+	}
+	count := map[MatchingTopology]int{}
+	for _, s := range solutions {
+		count[s.Topology]++
+		if s.TargetReturnLoss < 60 {
+			t.Fatalf("%s (%s): expected ideal match, got %.1f dB", s.Topology, s.Layout, s.TargetReturnLoss)
+		}
+		if len(s.ReturnLoss) != len(freq) || s.BandReturnLoss > s.TargetReturnLoss {
+			t.Fatalf("%s (%s): inconsistent band return loss %.1f", s.Topology, s.Layout, s.BandReturnLoss)
+		}
+	}
+	if count[MatchingL] != 4 || count[MatchingPi] == 0 || count[MatchingT] == 0 {
+		t.Fatalf("unexpected solution set: %v", count)
+	}
+
+	rounded, err := data.MatchingNetworks(MatchingConfig{Frequency: 100e6, ESeries: 12, Topologies: []MatchingTopology{MatchingL}})
+	if err != nil {
+		t.Fatalf("MatchingNetworks with E12 failed: %v", err)
+	}
+	for _, e := range rounded[0].Elements {
+		mantissa := e.Value / math.Pow(10, math.Floor(math.Log10(e.Value)))
+		found := false
+		for _, v := range eSeriesTables[12] {
+			found = found || math.Abs(mantissa-v) < 1e-9
+		}
+		if !found {
+			t.Fatalf("value %g is not in E12", e.Value)
+		}
+	}
+	if rounded[0].TargetReturnLoss < 15 {
+		t.Fatalf("expected usable E12 match, got %.1f dB", rounded[0].TargetReturnLoss)
+	}
+}
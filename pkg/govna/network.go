@@ -0,0 +1,294 @@
+// Этот файл содержит преобразования S-параметров между опорными импедансами.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// DefaultReferenceImpedance - опорный импеданс NanoVNA, Ом.
+const DefaultReferenceImpedance = 50.0
+
+// ReferenceImpedance возвращает опорный импеданс порта (нумерация с 1).
+// Если Z0 не задан, используется DefaultReferenceImpedance; одно значение относится ко всем портам.
+func (d *VNAData) ReferenceImpedance(port int) complex128 {
+	switch {
+	case len(d.Z0) == 0:
+		return DefaultReferenceImpedance
+	case port >= 1 && port <= len(d.Z0):
+		return d.Z0[port-1]
+	default:
+		return d.Z0[0]
+	}
+}
+
+// Renormalize пересчитывает S-параметры к новым опорным импедансам (power waves по Курокаве).
+// Одно значение z0 применяется ко всем портам, два - к портам 1 и 2 соответственно.
+// Для данных 1.5-порта (S21 без S12/S22) пересчет точен, только если опорный импеданс порта 2
+// не меняется; иначе возвращается ошибка - используйте RenormalizeReciprocal.
+func (d *VNAData) Renormalize(z0 ...complex128) (VNAData, error) {
+	return d.renormalize(z0, false)
+}
+
+// RenormalizeReciprocal делает то же, что Renormalize, но для данных 1.5-порта принимает цепь
+// взаимной и симметричной (S12=S21, S22=S11). Для несимметричной цепи результат неверен.
+func (d *VNAData) RenormalizeReciprocal(z0 ...complex128) (VNAData, error) {
+	return d.renormalize(z0, true)
+}
+
+func (d *VNAData) renormalize(z0 []complex128, reciprocal bool) (VNAData, error) {
+	if len(z0) == 0 || len(z0) > 2 {
+		return VNAData{}, errors.New("требуется один или два опорных импеданса")
+	}
+	target := []complex128{z0[0], z0[len(z0)-1]}
+	for i, z := range target {
+		if real(z) <= 0 {
+			return VNAData{}, fmt.Errorf("опорный импеданс порта %d должен иметь положительную действительную часть", i+1)
+		}
+	}
+
+	result := d.clone()
+	twoPort := len(d.S21) == len(d.S11) && len(d.S21) > 0
+	if !twoPort {
+		source := []complex128{d.ReferenceImpedance(1)}
+		for i := range d.S11 {
+			s, err := RenormalizeS([][]complex128{{d.S11[i]}}, source, target[:1])
+			if err != nil {
+				return VNAData{}, fmt.Errorf("частота %.3f Гц: %w", d.Frequencies[i], err)
+			}
+			result.S11[i] = s[0][0]
+		}
+		result.Z0 = target[:1]
+		return result, nil
+	}
+
+	source := []complex128{d.ReferenceImpedance(1), d.ReferenceImpedance(2)}
+	full := d.fullTwoPort()
+	// Если порт 2 нагружен прежним импедансом, S11 и S21 не зависят от S12 и S22.
+	if !full && !reciprocal && source[1] != target[1] {
+		return VNAData{}, errors.New("для пересчета опорного импеданса порта 2 требуются S12 и S22; " +
+			"для взаимной симметричной цепи используйте RenormalizeReciprocal")
+	}
+	for i := range d.S11 {
+		s12, s22 := d.S21[i], d.S11[i]
+		if full {
+			s12, s22 = d.S12[i], d.S22[i]
+		}
+		s, err := RenormalizeS([][]complex128{
+			{d.S11[i], s12},
+			{d.S21[i], s22},
+		}, source, target)
+		if err != nil {
+			return VNAData{}, fmt.Errorf("частота %.3f Гц: %w", d.Frequencies[i], err)
+		}
+		result.S11[i] = s[0][0]
+		result.S21[i] = s[1][0]
+		if full {
+			result.S12[i] = s[0][1]
+			result.S22[i] = s[1][1]
+		}
+	}
+	result.Z0 = target
+	return result, nil
+}
+
+// fullTwoPort сообщает, содержат ли данные полную матрицу рассеяния двухпортовой цепи.
+func (d *VNAData) fullTwoPort() bool {
+	return len(d.S21) == len(d.S11) && len(d.S12) == len(d.S11) && len(d.S22) == len(d.S11)
+}
+
+// RenormalizeS пересчитывает матрицу рассеяния N-портовой цепи от опорных импедансов from к to.
+// Пересчет выполняется напрямую через волны мощности, поэтому он корректен и для цепей,
+// не имеющих Z- или Y-матрицы (например, последовательный или параллельный элемент).
+func RenormalizeS(s [][]complex128, from, to []complex128) ([][]complex128, error) {
+	n := len(s)
+	if len(from) != n || len(to) != n {
+		return nil, errors.New("число опорных импедансов не совпадает с числом портов")
+	}
+	_, fInv, err := powerWaveScaling(from)
+	if err != nil {
+		return nil, err
+	}
+	fNew, _, err := powerWaveScaling(to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ток порта через падающие волны: I = D(I - S)a, D = (2R)⁻¹F⁻¹.
+	current := cmatSub(cmatIdentity(n), s)
+	for i := 0; i < n; i++ {
+		d := fInv[i][i] / complex(2*real(from[i]), 0)
+		for j := 0; j < n; j++ {
+			current[i][j] *= d
+		}
+	}
+
+	// a' = F'(F⁻¹ + (G' - G)D(I - S))a, b' = F'(F⁻¹ - (G + G'*)D(I - S))a.
+	p := cmatNew(n, n)
+	q := cmatNew(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			p[i][j] = fNew[i][i] * (to[i] - from[i]) * current[i][j]
+			q[i][j] = -fNew[i][i] * (from[i] + cmplx.Conj(to[i])) * current[i][j]
+		}
+		p[i][i] += fNew[i][i] * fInv[i][i]
+		q[i][i] += fNew[i][i] * fInv[i][i]
+	}
+	pInv, err := cmatInverse(p)
+	if err != nil {
+		return nil, errors.New("пересчет к новым опорным импедансам невозможен: вырожденная матрица")
+	}
+	return cmatMul(q, pInv), nil
+}
+
+// SToZ преобразует матрицу рассеяния в матрицу импедансов: Z = F⁻¹(I - S)⁻¹(SG + G*)F.
+func SToZ(s [][]complex128, z0 []complex128) ([][]complex128, error) {
+	n := len(s)
+	if len(z0) != n {
+		return nil, errors.New("число опорных импедансов не совпадает с числом портов")
+	}
+	f, fInv, err := powerWaveScaling(z0)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := cmatInverse(cmatSub(cmatIdentity(n), s))
+	if err != nil {
+		return nil, errors.New("матрица (I - S) вырождена, импеданс бесконечен")
+	}
+	rhs := cmatNew(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			rhs[i][j] = s[i][j] * z0[j]
+		}
+		rhs[i][i] += cmplx.Conj(z0[i])
+	}
+	return cmatMul(cmatMul(fInv, inv), cmatMul(rhs, f)), nil
+}
+
+// ZToS преобразует матрицу импедансов в матрицу рассеяния: S = F(Z - G*)(Z + G)⁻¹F⁻¹.
+func ZToS(z [][]complex128, z0 []complex128) ([][]complex128, error) {
+	n := len(z)
+	if len(z0) != n {
+		return nil, errors.New("число опорных импедансов не совпадает с числом портов")
+	}
+	f, fInv, err := powerWaveScaling(z0)
+	if err != nil {
+		return nil, err
+	}
+	minus := cmatNew(n, n)
+	plus := cmatNew(n, n)
+	for i := 0; i < n; i++ {
+		copy(minus[i], z[i])
+		copy(plus[i], z[i])
+		minus[i][i] -= cmplx.Conj(z0[i])
+		plus[i][i] += z0[i]
+	}
+	inv, err := cmatInverse(plus)
+	if err != nil {
+		return nil, errors.New("матрица (Z + Z0) вырождена")
+	}
+	return cmatMul(cmatMul(f, minus), cmatMul(inv, fInv)), nil
+}
+
+func powerWaveScaling(z0 []complex128) (f, fInv [][]complex128, err error) {
+	n := len(z0)
+	f = cmatNew(n, n)
+	fInv = cmatNew(n, n)
+	for i, z := range z0 {
+		if real(z) <= 0 {
+			return nil, nil, fmt.Errorf("опорный импеданс порта %d должен иметь положительную действительную часть", i+1)
+		}
+		scale := 1 / (2 * math.Sqrt(real(z)))
+		f[i][i] = complex(scale, 0)
+		fInv[i][i] = complex(1/scale, 0)
+	}
+	return f, fInv, nil
+}
+
+func cmatNew(rows, cols int) [][]complex128 {
+	m := make([][]complex128, rows)
+	for i := range m {
+		m[i] = make([]complex128, cols)
+	}
+	return m
+}
+
+func cmatIdentity(n int) [][]complex128 {
+	m := cmatNew(n, n)
+	for i := 0; i < n; i++ {
+		m[i][i] = 1
+	}
+	return m
+}
+
+func cmatSub(a, b [][]complex128) [][]complex128 {
+	m := cmatNew(len(a), len(a[0]))
+	for i := range a {
+		for j := range a[i] {
+			m[i][j] = a[i][j] - b[i][j]
+		}
+	}
+	return m
+}
+
+func cmatMul(a, b [][]complex128) [][]complex128 {
+	m := cmatNew(len(a), len(b[0]))
+	for i := range a {
+		for k := range b {
+			if a[i][k] == 0 {
+				continue
+			}
+			for j := range b[k] {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return m
+}
+
+// cmatInverse обращает квадратную комплексную матрицу методом Гаусса-Жордана с выбором ведущего элемента.
+func cmatInverse(a [][]complex128) ([][]complex128, error) {
+	n := len(a)
+	work := cmatNew(n, 2*n)
+	norm := 0.0
+	for i := 0; i < n; i++ {
+		copy(work[i], a[i])
+		work[i][n+i] = 1
+		for _, v := range a[i] {
+			norm = math.Max(norm, cmplx.Abs(v))
+		}
+	}
+	tolerance := 1e-12 * norm
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if cmplx.Abs(work[row][col]) > cmplx.Abs(work[pivot][col]) {
+				pivot = row
+			}
+		}
+		if cmplx.Abs(work[pivot][col]) <= tolerance {
+			return nil, errors.New("матрица вырождена")
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+		scale := 1 / work[col][col]
+		for j := range work[col] {
+			work[col][j] *= scale
+		}
+		for row := 0; row < n; row++ {
+			if row == col || work[row][col] == 0 {
+				continue
+			}
+			factor := work[row][col]
+			for j := range work[row] {
+				work[row][j] -= factor * work[col][j]
+			}
+		}
+	}
+	inv := cmatNew(n, n)
+	for i := 0; i < n; i++ {
+		copy(inv[i], work[i][n:])
+	}
+	return inv, nil
+}
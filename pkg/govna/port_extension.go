@@ -0,0 +1,197 @@
+// Этот файл содержит расширение порта (перенос опорной плоскости) и коррекцию электрической задержки.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// SpeedOfLight - скорость света в вакууме, м/с.
+const SpeedOfLight = 299792458.0
+
+// PortExtension описывает отрезок линии между опорной плоскостью калибровки и новой плоскостью измерения.
+// Delay задается в одну сторону (в секундах), потери - в дБ, также в одну сторону.
+// Зависимость потерь от частоты моделируется как LossDC + (Loss-LossDC)*sqrt(f/LossFrequency).
+// Физическая длина в расширении не хранится: она пересчитывается через коэффициент укорочения
+// функциями PortExtensionFromLength и Length.
+type PortExtension struct {
+	Delay         float64
+	LossDC        float64
+	Loss          float64
+	LossFrequency float64
+}
+
+// PortExtensionSettings задает расширения для обоих портов анализатора.
+// Port1 учитывается в S11 дважды (прямой и обратный путь) и однократно в S21, Port2 - однократно в S21.
+type PortExtensionSettings struct {
+	Port1 PortExtension
+	Port2 PortExtension
+}
+
+// PortExtensionFromLength строит расширение порта по физической длине линии и коэффициенту укорочения.
+func PortExtensionFromLength(length, velocityFactor float64) (PortExtension, error) {
+	if velocityFactor <= 0 || velocityFactor > 1 {
+		return PortExtension{}, errors.New("коэффициент укорочения должен лежать в диапазоне (0, 1]")
+	}
+	return PortExtension{Delay: length / (SpeedOfLight * velocityFactor)}, nil
+}
+
+// Length возвращает физическую длину линии с коэффициентом укорочения velocityFactor,
+// задержка которой равна задержке расширения. Коэффициент вне (0, 1] считается равным 1.
+func (p PortExtension) Length(velocityFactor float64) float64 {
+	if velocityFactor <= 0 || velocityFactor > 1 {
+		velocityFactor = 1
+	}
+	return p.Delay * SpeedOfLight * velocityFactor
+}
+
+func (p PortExtension) Validate() error {
+	if math.IsNaN(p.Delay) || math.IsInf(p.Delay, 0) {
+		return errors.New("некорректная задержка расширения порта")
+	}
+	if p.Loss != 0 && p.LossFrequency <= 0 {
+		return errors.New("для потерь расширения порта требуется положительная опорная частота")
+	}
+	return nil
+}
+
+// lossAt возвращает потери расширения в дБ на частоте freq.
+func (p PortExtension) lossAt(freq float64) float64 {
+	if p.LossFrequency <= 0 || freq <= 0 {
+		return p.LossDC
+	}
+	return p.LossDC + (p.Loss-p.LossDC)*math.Sqrt(freq/p.LossFrequency)
+}
+
+// correction возвращает множитель, компенсирующий однократное прохождение расширения на частоте freq.
+func (p PortExtension) correction(freq float64) complex128 {
+	phase := 2 * math.Pi * freq * p.Delay
+	gain := math.Pow(10, p.lossAt(freq)/20)
+	return cmplx.Rect(gain, phase)
+}
+
+func (s PortExtensionSettings) Validate() error {
+	if err := s.Port1.Validate(); err != nil {
+		return fmt.Errorf("порт 1: %w", err)
+	}
+	if err := s.Port2.Validate(); err != nil {
+		return fmt.Errorf("порт 2: %w", err)
+	}
+	return nil
+}
+
+// Apply переносит опорную плоскость данных на концы расширений портов.
+func (s PortExtensionSettings) Apply(data VNAData) VNAData {
+	corrected := data.clone()
+	for i, freq := range data.Frequencies {
+		c1, c2 := s.Port1.correction(freq), s.Port2.correction(freq)
+		if i < len(corrected.S11) {
+			corrected.S11[i] *= c1 * c1
+		}
+		if i < len(corrected.S21) {
+			corrected.S21[i] *= c1 * c2
+		}
+		if i < len(corrected.S12) {
+			corrected.S12[i] *= c1 * c2
+		}
+		if i < len(corrected.S22) {
+			corrected.S22[i] *= c2 * c2
+		}
+	}
+	return corrected
+}
+
+// AutoPortExtension подбирает задержку и потери расширения порта 1 по измерению S11
+// эталона open или short, подключенного в новой опорной плоскости.
+// Потери аппроксимируются моделью a + b*sqrt(f), опорная частота - центр диапазона.
+func AutoPortExtension(data VNAData, standard CalibrationStandard) (PortExtension, error) {
+	var ideal complex128
+	switch standard {
+	case CalibrationStandardOpen:
+		ideal = 1
+	case CalibrationStandardShort:
+		ideal = -1
+	default:
+		return PortExtension{}, fmt.Errorf("автоматическое расширение порта поддерживает только open и short, получен %s", standard)
+	}
+	if len(data.Frequencies) < 2 || len(data.S11) != len(data.Frequencies) {
+		return PortExtension{}, errors.New("для автоматического расширения порта требуется не менее двух точек S11")
+	}
+
+	phases := make([]float64, len(data.S11))
+	omegas := make([]float64, len(data.S11))
+	sqrtF := make([]float64, len(data.S11))
+	oneWayLoss := make([]float64, len(data.S11))
+	for i, s11 := range data.S11 {
+		normalized := s11 / ideal
+		if normalized == 0 {
+			return PortExtension{}, fmt.Errorf("нулевой отклик на частоте %.3f Гц", data.Frequencies[i])
+		}
+		phases[i] = cmplx.Phase(normalized)
+		omegas[i] = 2 * math.Pi * data.Frequencies[i]
+		sqrtF[i] = math.Sqrt(data.Frequencies[i])
+		oneWayLoss[i] = -10 * math.Log10(cmplx.Abs(normalized))
+	}
+	phases = unwrapPhase(phases)
+
+	slope, _, err := linearFit(omegas, phases)
+	if err != nil {
+		return PortExtension{}, err
+	}
+	lossSlope, lossDC, err := linearFit(sqrtF, oneWayLoss)
+	if err != nil {
+		return PortExtension{}, err
+	}
+
+	center := (data.Frequencies[0] + data.Frequencies[len(data.Frequencies)-1]) / 2
+	return PortExtension{
+		Delay:         -slope / 2,
+		LossDC:        lossDC,
+		Loss:          lossDC + lossSlope*math.Sqrt(center),
+		LossFrequency: center,
+	}, nil
+}
+
+// unwrapPhase устраняет скачки фазы на 2π между соседними точками.
+func unwrapPhase(phases []float64) []float64 {
+	unwrapped := make([]float64, len(phases))
+	if len(phases) == 0 {
+		return unwrapped
+	}
+	unwrapped[0] = phases[0]
+	offset := 0.0
+	for i := 1; i < len(phases); i++ {
+		delta := phases[i] - phases[i-1]
+		if delta > math.Pi {
+			offset -= 2 * math.Pi * math.Round(delta/(2*math.Pi))
+		} else if delta < -math.Pi {
+			offset += 2 * math.Pi * math.Round(-delta/(2*math.Pi))
+		}
+		unwrapped[i] = phases[i] + offset
+	}
+	return unwrapped
+}
+
+// linearFit возвращает наклон и смещение прямой y = slope*x + intercept методом наименьших квадратов.
+func linearFit(x, y []float64) (slope, intercept float64, err error) {
+	if len(x) != len(y) || len(x) < 2 {
+		return 0, 0, errors.New("для линейной аппроксимации требуется не менее двух точек")
+	}
+	n := float64(len(x))
+	var sx, sy, sxx, sxy float64
+	for i := range x {
+		sx += x[i]
+		sy += y[i]
+		sxx += x[i] * x[i]
+		sxy += x[i] * y[i]
+	}
+	denom := n*sxx - sx*sx
+	if denom == 0 {
+		return 0, 0, errors.New("вырожденные данные для линейной аппроксимации")
+	}
+	slope = (n*sxy - sx*sy) / denom
+	intercept = (sy - slope*sx) / n
+	return slope, intercept, nil
+}
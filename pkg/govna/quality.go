@@ -0,0 +1,223 @@
+// Этот файл содержит проверку физической достоверности измерений: пассивность, взаимность и причинность.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+type QualityCheck string
+
+const (
+	CheckPassivity   QualityCheck = "passivity"
+	CheckReciprocity QualityCheck = "reciprocity"
+	CheckCausality   QualityCheck = "causality"
+)
+
+const (
+	// DefaultPassivityTolerance - допустимое превышение единицы наибольшим сингулярным числом S (≈0.09 дБ).
+	DefaultPassivityTolerance = 0.01
+	// DefaultReciprocityTolerance - допустимое относительное расхождение S21 и S12.
+	DefaultReciprocityTolerance = 0.05
+	// DefaultCausalityTolerance - допустимый уровень отклика при отрицательном времени относительно пика (-20 дБ).
+	DefaultCausalityTolerance = 0.1
+	// reciprocityFloor - абсолютный порог (-60 дБ), ниже которого расхождение считается шумом.
+	reciprocityFloor = 1e-3
+	// causalityGuard - защитный интервал вокруг нуля времени в единицах 1/полоса (главный лепесток окна Кайзера).
+	causalityGuard = 5
+)
+
+// QualityConfig задает допуски проверок; нулевые значения заменяются значениями по умолчанию.
+type QualityConfig struct {
+	PassivityTolerance   float64
+	ReciprocityTolerance float64
+	CausalityTolerance   float64
+}
+
+// QualityIssue - точка, в которой проверка не пройдена. Для пассивности и взаимности указывается
+// частота, для причинности - время пика отклика при t < 0.
+type QualityIssue struct {
+	Parameter SParameter `json:"parameter,omitempty"`
+	Frequency float64    `json:"frequency,omitempty"`
+	Time      float64    `json:"time,omitempty"`
+	Value     float64    `json:"value"`
+}
+
+type QualityCheckResult struct {
+	Check   QualityCheck   `json:"check"`
+	Pass    bool           `json:"pass"`
+	Skipped bool           `json:"skipped,omitempty"`
+	Message string         `json:"message,omitempty"`
+	Worst   float64        `json:"worst"`
+	Issues  []QualityIssue `json:"issues,omitempty"`
+}
+
+// QualityReport - результат анализа. Warnings содержит краткие описания непройденных проверок.
+type QualityReport struct {
+	Pass     bool                 `json:"pass"`
+	Checks   []QualityCheckResult `json:"checks"`
+	Warnings []string             `json:"warnings,omitempty"`
+}
+
+// CheckQuality проверяет данные на физическую достоверность. Пропущенные проверки (нет S12,
+// неравномерная сетка частот) не считаются непройденными.
+func (d *VNAData) CheckQuality(cfg QualityConfig) (QualityReport, error) {
+	if len(d.S11) != len(d.Frequencies) || len(d.S11) == 0 {
+		return QualityReport{}, errors.New("нет данных для проверки качества")
+	}
+	if cfg.PassivityTolerance == 0 {
+		cfg.PassivityTolerance = DefaultPassivityTolerance
+	}
+	if cfg.ReciprocityTolerance == 0 {
+		cfg.ReciprocityTolerance = DefaultReciprocityTolerance
+	}
+	if cfg.CausalityTolerance == 0 {
+		cfg.CausalityTolerance = DefaultCausalityTolerance
+	}
+
+	report := QualityReport{Pass: true}
+	for _, result := range []QualityCheckResult{
+		d.checkPassivity(cfg.PassivityTolerance),
+		d.checkReciprocity(cfg.ReciprocityTolerance),
+		d.checkCausality(cfg.CausalityTolerance),
+	} {
+		if !result.Pass {
+			report.Pass = false
+			report.Warnings = append(report.Warnings, result.Message)
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report, nil
+}
+
+// checkPassivity проверяет, что цепь не отдает мощности больше, чем получает: наибольшее сингулярное
+// число S не превышает единицы. Без S12/S22 проверяется баланс мощности при возбуждении порта 1.
+func (d *VNAData) checkPassivity(tolerance float64) QualityCheckResult {
+	result := QualityCheckResult{Check: CheckPassivity, Pass: true}
+	hasS21 := len(d.S21) == len(d.S11)
+	full := hasS21 && len(d.S12) == len(d.S11) && len(d.S22) == len(d.S11)
+	for i, freq := range d.Frequencies {
+		var gain float64
+		switch {
+		case full:
+			gain, _, _ = largestSingular([][]complex128{{d.S11[i], d.S12[i]}, {d.S21[i], d.S22[i]}})
+		case hasS21:
+			gain = math.Hypot(cmplx.Abs(d.S11[i]), cmplx.Abs(d.S21[i]))
+		default:
+			gain = cmplx.Abs(d.S11[i])
+		}
+		result.Worst = math.Max(result.Worst, gain)
+		if gain > 1+tolerance {
+			result.Issues = append(result.Issues, QualityIssue{Frequency: freq, Value: gain})
+		}
+	}
+	if len(result.Issues) > 0 {
+		result.Pass = false
+		worst := result.worstIssue()
+		result.Message = fmt.Sprintf("нарушена пассивность на %d частотах: усиление до %.3f (%.2f дБ) на %.3f МГц",
+			len(result.Issues), worst.Value, 20*math.Log10(worst.Value), worst.Frequency/1e6)
+	}
+	return result
+}
+
+// checkReciprocity сравнивает S21 и S12; для пассивных цепей без ферритов и активных элементов они равны.
+func (d *VNAData) checkReciprocity(tolerance float64) QualityCheckResult {
+	result := QualityCheckResult{Check: CheckReciprocity, Pass: true}
+	if len(d.S21) != len(d.S11) || len(d.S12) != len(d.S11) {
+		result.Skipped = true
+		result.Message = "S12 не измерен"
+		return result
+	}
+	for i, freq := range d.Frequencies {
+		diff := cmplx.Abs(d.S21[i] - d.S12[i])
+		scale := math.Max(cmplx.Abs(d.S21[i]), cmplx.Abs(d.S12[i]))
+		relative := diff / math.Max(scale, reciprocityFloor)
+		result.Worst = math.Max(result.Worst, relative)
+		if diff > tolerance*scale+reciprocityFloor {
+			result.Issues = append(result.Issues, QualityIssue{Frequency: freq, Value: relative})
+		}
+	}
+	if len(result.Issues) > 0 {
+		result.Pass = false
+		worst := result.worstIssue()
+		result.Message = fmt.Sprintf("нарушена взаимность на %d частотах: расхождение S21 и S12 до %.1f%% на %.3f МГц",
+			len(result.Issues), 100*worst.Value, worst.Frequency/1e6)
+	}
+	return result
+}
+
+// checkCausality выполняет временной тест: обратное преобразование Фурье измеренных данных
+// с окном Кайзера не должно иметь заметного отклика при отрицательном времени. Отклики с задержкой
+// больше половины диапазона однозначности 1/Δf проявляются как отрицательное время (наложение).
+func (d *VNAData) checkCausality(tolerance float64) QualityCheckResult {
+	result := QualityCheckResult{Check: CheckCausality, Pass: true}
+	step, err := uniformStep(d.Frequencies)
+	if err != nil {
+		result.Skipped = true
+		result.Message = err.Error()
+		return result
+	}
+	window, err := makeWindow(WindowKaiser, DefaultKaiserBeta, len(d.Frequencies), false)
+	if err != nil {
+		result.Skipped = true
+		result.Message = err.Error()
+		return result
+	}
+	size := nextPowerOfTwo(4 * len(d.Frequencies))
+	period := 1 / step
+	guard := causalityGuard / (step * float64(len(d.Frequencies)))
+
+	var failed []SParameter
+	for _, p := range []SParameter{S11, S21, S12, S22} {
+		values, err := d.Parameter(p)
+		if err != nil {
+			continue
+		}
+		x := make([]complex128, size)
+		for i, v := range values {
+			x[i] = v * complex(window[i], 0)
+		}
+		fft(x, true)
+		peak := 0.0
+		for _, v := range x {
+			peak = math.Max(peak, cmplx.Abs(v))
+		}
+		if peak == 0 {
+			continue
+		}
+		worst := QualityIssue{Parameter: p}
+		for n := size / 2; n < size; n++ {
+			t := float64(n-size) * period / float64(size)
+			if t > -guard {
+				break
+			}
+			if ratio := cmplx.Abs(x[n]) / peak; ratio > worst.Value {
+				worst.Value, worst.Time = ratio, t
+			}
+		}
+		result.Worst = math.Max(result.Worst, worst.Value)
+		if worst.Value > tolerance {
+			result.Issues = append(result.Issues, worst)
+			failed = append(failed, p)
+		}
+	}
+	if len(result.Issues) > 0 {
+		result.Pass = false
+		worst := result.worstIssue()
+		result.Message = fmt.Sprintf("нарушена причинность %v: отклик %.1f дБ относительно пика при t = %.3f нс",
+			failed, 20*math.Log10(worst.Value), worst.Time*1e9)
+	}
+	return result
+}
+
+func (r QualityCheckResult) worstIssue() QualityIssue {
+	worst := r.Issues[0]
+	for _, issue := range r.Issues[1:] {
+		if issue.Value > worst.Value {
+			worst = issue
+		}
+	}
+	return worst
+}
@@ -0,0 +1,53 @@
+package govna
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestVNAData_CheckQuality(t *testing.T) {
+	cfg := SweepConfig{Start: 10e6, Stop: 1e9, Points: 201}
+	good := delayedReflection(cfg, 0.5, 3e-9)
+	good.S21 = make([]complex128, len(good.S11))
+	good.S12 = make([]complex128, len(good.S11))
+	for i, f := range good.Frequencies {
+		good.S21[i] = cmplx.Rect(0.8, -2*math.Pi*f*3e-9)
+		good.S12[i] = good.S21[i]
+	}
+	report, err := good.CheckQuality(QualityConfig{})
+	if err != nil {
+		t.Fatalf("CheckQuality failed: %v", err)
+	}
+	if !report.Pass || len(report.Warnings) != 0 {
+		t.Fatalf("expected clean report, got %+v", report)
+	}
+
+	bad := good.clone()
+	for i := range bad.S11 {
+		// Усиление на части диапазона, невзаимный S12 и опережение фазы S11 (отклик до возбуждения).
+		if i > 100 {
+			bad.S21[i] *= 1.5
+		}
+		bad.S12[i] *= 0.5
+		bad.S11[i] = cmplx.Conj(bad.S11[i])
+	}
+	report, err = bad.CheckQuality(QualityConfig{})
+	if err != nil {
+		t.Fatalf("CheckQuality failed: %v", err)
+	}
+	if report.Pass || len(report.Warnings) != 3 {
+		t.Fatalf("expected all three checks to fail, got %+v", report.Warnings)
+	}
+	for _, check := range report.Checks {
+		if check.Pass || len(check.Issues) == 0 {
+			t.Fatalf("%s: expected failure with issues", check.Check)
+		}
+	}
+	if first := report.Checks[0].Issues[0].Frequency; first <= bad.Frequencies[100] {
+		t.Fatalf("passivity issue reported at %g Hz, before the gain step", first)
+	}
+	if causality := report.Checks[2].Issues[0]; causality.Parameter != S11 || math.Abs(causality.Time+6e-9) > 0.5e-9 {
+		t.Fatalf("unexpected causality issue %+v", causality)
+	}
+}
@@ -0,0 +1,164 @@
+// Этот файл содержит анализ резонаторов: добротность и параметры эквивалентной схемы кварца (модель Баттерворта-Ван Дайка).
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// CrystalParameters - параметры модели BVD: последовательная (motional) ветвь Rm-Lm-Cm, параллельная емкость C0.
+// LoadedQ учитывает сопротивление измерительной оснастки: 2·Z0 для S21 и Z0 для S11.
+type CrystalParameters struct {
+	Fs, Fp    float64
+	Rm        float64
+	Lm        float64
+	Cm        float64
+	C0        float64
+	LoadedQ   float64
+	UnloadedQ float64
+	Quality   FitQuality
+}
+
+// ResonatorQ - добротность резонатора по полосе пропускания S21.
+type ResonatorQ struct {
+	Frequency     float64
+	InsertionLoss float64
+	Bandwidth     float64
+	LoadedQ       float64
+	UnloadedQ     float64
+}
+
+// Impedance вычисляет импеданс модели BVD на частоте freq.
+func (c CrystalParameters) Impedance(freq float64) complex128 {
+	return crystalImpedance(2*math.Pi*freq, c.Rm, c.Lm, c.Cm, c.C0)
+}
+
+func crystalImpedance(omega, rm, lm, cm, c0 float64) complex128 {
+	motional := complex(rm, omega*lm-1/(omega*cm))
+	y := 1/motional + complex(0, omega*c0)
+	return 1 / y
+}
+
+// ExtractCrystal аппроксимирует импеданс, полученный из S21 (последовательное включение) или S11,
+// моделью BVD. Свип должен охватывать последовательный и параллельный резонансы.
+func (d *VNAData) ExtractCrystal(p SParameter) (CrystalParameters, error) {
+	z, err := d.Impedance(p)
+	if err != nil {
+		return CrystalParameters{}, err
+	}
+	if len(z) < 8 {
+		return CrystalParameters{}, errors.New("для аппроксимации кварца требуется не менее 8 точек")
+	}
+
+	minIdx, maxIdx := 0, 0
+	for i := range z {
+		if cmplx.Abs(z[i]) < cmplx.Abs(z[minIdx]) {
+			minIdx = i
+		}
+		if cmplx.Abs(z[i]) > cmplx.Abs(z[maxIdx]) {
+			maxIdx = i
+		}
+	}
+	if maxIdx <= minIdx || maxIdx == len(z)-1 || minIdx == 0 {
+		return CrystalParameters{}, errors.New("свип не охватывает последовательный и параллельный резонансы")
+	}
+
+	fs, fp := d.Frequencies[minIdx], d.Frequencies[maxIdx]
+	ratio := (fp/fs)*(fp/fs) - 1
+	rm := math.Max(real(z[minIdx]), 1e-3)
+
+	// Емкость C0 оценивается на краю свипа с учетом вклада последовательной ветви.
+	edge := 0
+	if fs-d.Frequencies[0] < d.Frequencies[len(z)-1]-fp {
+		edge = len(z) - 1
+	}
+	omega := 2 * math.Pi * d.Frequencies[edge]
+	detune := 1 - (d.Frequencies[edge]/fs)*(d.Frequencies[edge]/fs)
+	c0 := imag(1/z[edge]) / (omega * (1 + ratio/detune))
+	if c0 <= 0 || math.IsNaN(c0) {
+		return CrystalParameters{}, errors.New("не удалось оценить параллельную емкость C0")
+	}
+	cm := ratio * c0
+	lm := 1 / ((2 * math.Pi * fs) * (2 * math.Pi * fs) * cm)
+
+	measured := make([]complex128, len(z))
+	ymax := 0.0
+	for i := range z {
+		measured[i] = 1 / z[i]
+		ymax = math.Max(ymax, cmplx.Abs(measured[i]))
+	}
+	// Параметры нормированы к начальному приближению: логарифмы отношений для Rm, Cm, C0
+	// и отстройка fs в единицах полосы резонанса, что устраняет сильную корреляцию Lm и Cm.
+	q0 := 2 * math.Pi * fs * lm / rm
+	unpack := func(params []float64) (rmFit, fsFit, cmFit, c0Fit float64) {
+		return rm * math.Exp(params[0]), fs * (1 + params[1]/q0), cm * math.Exp(params[2]), c0 * math.Exp(params[3])
+	}
+	residual := func(params []float64) []float64 {
+		rmFit, fsFit, cmFit, c0Fit := unpack(params)
+		omegaS := 2 * math.Pi * fsFit
+		lmFit := 1 / (omegaS * omegaS * cmFit)
+		out := make([]float64, 0, 2*len(measured))
+		for i, y := range measured {
+			omega := 2 * math.Pi * d.Frequencies[i]
+			model := 1 / crystalImpedance(omega, rmFit, lmFit, cmFit, c0Fit)
+			scale := math.Max(cmplx.Abs(y), 1e-3*ymax)
+			diff := (model - y) / complex(scale, 0)
+			out = append(out, real(diff), imag(diff))
+		}
+		return out
+	}
+	initial := make([]float64, 4)
+	lower := []float64{-5, -0.01 * q0, -5, -5}
+	upper := []float64{5, 0.01 * q0, 5, 5}
+	fit, err := levenbergMarquardt(residual, initial, lower, upper)
+	if err != nil {
+		return CrystalParameters{}, fmt.Errorf("аппроксимация модели BVD: %w", err)
+	}
+
+	rmFit, fsFit, cmFit, c0Fit := unpack(fit.params)
+	result := CrystalParameters{
+		Rm:      rmFit,
+		Lm:      1 / ((2 * math.Pi * fsFit) * (2 * math.Pi * fsFit) * cmFit),
+		Cm:      cmFit,
+		C0:      c0Fit,
+		Quality: fit.quality,
+	}
+	result.Fs = 1 / (2 * math.Pi * math.Sqrt(result.Lm*result.Cm))
+	result.Fp = result.Fs * math.Sqrt(1+result.Cm/result.C0)
+	omegaS := 2 * math.Pi * result.Fs
+	result.UnloadedQ = omegaS * result.Lm / result.Rm
+
+	source := real(d.ReferenceImpedance(1))
+	if p == S21 || p == S12 {
+		source += real(d.ReferenceImpedance(2))
+	}
+	result.LoadedQ = omegaS * result.Lm / (result.Rm + source)
+	return result, nil
+}
+
+// ResonatorQ определяет нагруженную добротность по полосе S21 на уровне -3 дБ относительно пика
+// и ненагруженную по формуле Qu = QL / (1 - |S21(f0)|) для симметрично связанного резонатора.
+func (d *VNAData) ResonatorQ() (ResonatorQ, error) {
+	spec := TraceSpec{Parameter: S21, Format: FormatLogMag}
+	bw, err := d.Bandwidth(spec, 0, 3.0103, 0, false)
+	if err != nil {
+		return ResonatorQ{}, err
+	}
+	peak, err := d.SearchMax(spec)
+	if err != nil {
+		return ResonatorQ{}, err
+	}
+	transmission := math.Pow(10, peak.Value/20)
+	if transmission >= 1 {
+		return ResonatorQ{}, errors.New("коэффициент передачи на резонансе не меньше единицы: ненагруженная добротность не определена")
+	}
+	return ResonatorQ{
+		Frequency:     peak.Frequency,
+		InsertionLoss: -peak.Value,
+		Bandwidth:     bw.Span,
+		LoadedQ:       bw.Q,
+		UnloadedQ:     bw.Q / (1 - transmission),
+	}, nil
+}
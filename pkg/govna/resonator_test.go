@@ -0,0 +1,69 @@
+package govna
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVNAData_ExtractCrystal(t *testing.T) {
+	truth := CrystalParameters{Rm: 12, Cm: 20e-15, C0: 4.5e-12}
+	fs := 10e6
+	truth.Lm = 1 / ((2 * math.Pi * fs) * (2 * math.Pi * fs) * truth.Cm)
+
+	data := VNAData{Frequencies: make([]float64, 401), S21: make([]complex128, 401)}
+	for i := range data.Frequencies {
+		f := fs - 10e3 + float64(i)*50e3/400
+		data.Frequencies[i] = f
+		data.S21[i] = 100 / (100 + truth.Impedance(f))
+	}
+	data.S11 = make([]complex128, len(data.S21))
+
+	got, err := data.ExtractCrystal(S21)
+	if err != nil {
+		t.Fatalf("ExtractCrystal failed: %v", err)
+	}
+	check := func(name string, want, have float64) {
+		if math.Abs(have-want)/want > 1e-3 {
+			t.Fatalf("%s: expected %g, got %g", name, want, have)
+		}
+	}
+	check("Rm", truth.Rm, got.Rm)
+	check("Lm", truth.Lm, got.Lm)
+	check("Cm", truth.Cm, got.Cm)
+	check("C0", truth.C0, got.C0)
+	check("Fs", fs, got.Fs)
+	if got.Quality.RMSError > 1e-6 {
+		t.Fatalf("expected near-perfect fit, got RMS error %g", got.Quality.RMSError)
+	}
+	check("LoadedQ", 2*math.Pi*fs*truth.Lm/(truth.Rm+100), got.LoadedQ)
+}
+
+func TestVNAData_ResonatorQ(t *testing.T) {
+	// Последовательный RLC в последовательном включении: S21 = 2·Z0 / (2·Z0 + Z).
+	circuit := EquivalentCircuit{Topology: CircuitSeriesRLC, R: 20, L: 10e-6}
+	f0 := 10e6
+	omega0 := 2 * math.Pi * f0
+	circuit.C = 1 / (omega0 * omega0 * circuit.L)
+
+	data := VNAData{Frequencies: make([]float64, 4001), S21: make([]complex128, 4001)}
+	for i := range data.Frequencies {
+		f := 5e6 + float64(i)*10e6/4000
+		data.Frequencies[i] = f
+		data.S21[i] = 100 / (100 + circuit.Impedance(f))
+	}
+	data.S11 = make([]complex128, len(data.S21))
+
+	got, err := data.ResonatorQ()
+	if err != nil {
+		t.Fatalf("ResonatorQ failed: %v", err)
+	}
+	check := func(name string, want, have float64) {
+		if math.Abs(have-want)/want > 1e-3 {
+			t.Fatalf("%s: expected %g, got %g", name, want, have)
+		}
+	}
+	check("Frequency", f0, got.Frequency)
+	check("InsertionLoss", 20*math.Log10((circuit.R+100)/100), got.InsertionLoss)
+	check("LoadedQ", omega0*circuit.L/(circuit.R+100), got.LoadedQ)
+	check("UnloadedQ", omega0*circuit.L/circuit.R, got.UnloadedQ)
+}
@@ -0,0 +1,236 @@
+// Этот файл содержит очередь заданий устройства: приоритеты, ограничение глубины и справедливое чередование клиентов.
+package govna
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type JobPriority int
+
+const (
+	PriorityLow JobPriority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+func (p JobPriority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	}
+	return "normal"
+}
+
+// DefaultMaxQueue - глубина очереди заданий по умолчанию.
+const DefaultMaxQueue = 32
+
+var (
+	// ErrQueueFull - очередь устройства заполнена; устройство перегружено.
+	ErrQueueFull = errors.New("очередь заданий устройства заполнена")
+	// ErrClientQueueFull - у клиента уже MaxPerClient заданий в очереди.
+	ErrClientQueueFull = errors.New("превышено число заданий клиента в очереди")
+	// ErrQueueTimeout - задание не дождалось выполнения за MaxWait.
+	ErrQueueTimeout = errors.New("истекло время ожидания в очереди заданий")
+)
+
+// SchedulerOptions задает ограничения очереди. MaxQueue - число ожидающих заданий
+// (0 - DefaultMaxQueue), MaxPerClient - число заданий одного клиента в очереди и в работе
+// (0 - без ограничения), MaxWait - наибольшее время ожидания (0 - без ограничения).
+// OnQueueChange, если задан, вызывается с числом ожидающих заданий при каждом его изменении;
+// он вызывается под блокировкой планировщика и не должен обращаться к нему.
+type SchedulerOptions struct {
+	MaxQueue      int
+	MaxPerClient  int
+	MaxWait       time.Duration
+	OnQueueChange func(queued int)
+}
+
+// Scheduler выполняет задания устройства по одному. Порядок: сначала более высокий приоритет,
+// внутри приоритета клиенты чередуются (клиент с десятью заданиями не задерживает клиента
+// с одним), при равенстве - порядок поступления.
+type Scheduler struct {
+	opts SchedulerOptions
+
+	mu      sync.Mutex
+	queue   jobQueue
+	pending map[string]int
+	rounds  map[string]uint64
+	round   uint64
+	seq     uint64
+	busy    bool
+}
+
+func NewScheduler(opts SchedulerOptions) *Scheduler {
+	if opts.MaxQueue <= 0 {
+		opts.MaxQueue = DefaultMaxQueue
+	}
+	return &Scheduler{opts: opts, pending: make(map[string]int), rounds: make(map[string]uint64)}
+}
+
+// Len возвращает число ожидающих заданий.
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queue.Len()
+}
+
+// Do ставит задание клиента в очередь и выполняет fn в вызывающей горутине, когда подойдет
+// очередь. Возвращает время ожидания в очереди. Ошибка означает, что fn не выполнялась:
+// ErrQueueFull, ErrClientQueueFull, ErrQueueTimeout или ошибка ctx при отмене ожидания.
+func (s *Scheduler) Do(ctx context.Context, client string, priority JobPriority, fn func()) (time.Duration, error) {
+	enqueued := time.Now()
+	j, err := s.enqueue(client, priority)
+	if err != nil {
+		return 0, err
+	}
+	if j != nil {
+		if err := s.wait(ctx, j); err != nil {
+			return time.Since(enqueued), err
+		}
+	}
+	waited := time.Since(enqueued)
+	defer s.release(client)
+	fn()
+	return waited, nil
+}
+
+// enqueue регистрирует задание. nil без ошибки означает, что устройство свободно и задание
+// можно выполнять сразу.
+func (s *Scheduler) enqueue(client string, priority JobPriority) (*job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.opts.MaxPerClient > 0 && s.pending[client] >= s.opts.MaxPerClient {
+		return nil, ErrClientQueueFull
+	}
+	round := s.round
+	if last, ok := s.rounds[client]; ok && last+1 > round {
+		round = last + 1
+	}
+	if !s.busy && s.queue.Len() == 0 {
+		s.busy = true
+		s.pending[client]++
+		s.rounds[client] = round
+		return nil, nil
+	}
+	if s.queue.Len() >= s.opts.MaxQueue {
+		return nil, ErrQueueFull
+	}
+	s.seq++
+	j := &job{client: client, priority: priority, round: round, seq: s.seq, ready: make(chan struct{})}
+	heap.Push(&s.queue, j)
+	s.pending[client]++
+	s.rounds[client] = round
+	s.queueChangedLocked()
+	return j, nil
+}
+
+func (s *Scheduler) wait(ctx context.Context, j *job) error {
+	var timeout <-chan time.Time
+	if s.opts.MaxWait > 0 {
+		timer := time.NewTimer(s.opts.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	select {
+	case <-j.ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrQueueTimeout
+	}
+
+	s.mu.Lock()
+	if j.index < 0 {
+		// Задание уже извлечено из очереди одновременно с отменой: очередь передается следующему.
+		s.mu.Unlock()
+		s.release(j.client)
+		return err
+	}
+	heap.Remove(&s.queue, j.index)
+	s.forgetLocked(j.client)
+	s.queueChangedLocked()
+	s.mu.Unlock()
+	return err
+}
+
+// release завершает задание клиента и передает устройство следующему заданию.
+func (s *Scheduler) release(client string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forgetLocked(client)
+	if s.queue.Len() == 0 {
+		s.busy = false
+		return
+	}
+	next := heap.Pop(&s.queue).(*job)
+	s.round = next.round
+	s.queueChangedLocked()
+	close(next.ready)
+}
+
+func (s *Scheduler) forgetLocked(client string) {
+	s.pending[client]--
+	if s.pending[client] <= 0 {
+		delete(s.pending, client)
+		delete(s.rounds, client)
+	}
+}
+
+func (s *Scheduler) queueChangedLocked() {
+	if s.opts.OnQueueChange != nil {
+		s.opts.OnQueueChange(s.queue.Len())
+	}
+}
+
+type job struct {
+	client   string
+	priority JobPriority
+	round    uint64
+	seq      uint64
+	index    int
+	ready    chan struct{}
+}
+
+// jobQueue - куча заданий, упорядоченная по приоритету, раунду клиента и порядку поступления.
+type jobQueue []*job
+
+func (q jobQueue) Len() int { return len(q) }
+
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	if q[i].round != q[j].round {
+		return q[i].round < q[j].round
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q jobQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *jobQueue) Push(x any) {
+	j := x.(*job)
+	j.index = len(*q)
+	*q = append(*q, j)
+}
+
+func (q *jobQueue) Pop() any {
+	old := *q
+	j := old[len(old)-1]
+	old[len(old)-1] = nil
+	j.index = -1
+	*q = old[:len(old)-1]
+	return j
+}
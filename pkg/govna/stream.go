@@ -0,0 +1,210 @@
+// Этот файл содержит непрерывное сканирование с раздачей кадров нескольким подписчикам.
+package govna
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultStreamBuffer - глубина очереди подписчика по умолчанию, в кадрах.
+	DefaultStreamBuffer = 4
+	// streamRetryDelay - пауза перед повторным сканированием после ошибки устройства.
+	streamRetryDelay = time.Second
+)
+
+// StreamFrame - результат одного скана непрерывного сканирования. При ошибке устройства
+// заполняется только Err; сканирование продолжается.
+type StreamFrame struct {
+	Data     VNAData
+	Metadata ScanMetadata
+	Err      error
+}
+
+// ScanScheduler выполняет scan, когда устройство освободится, например в очереди Scheduler.
+// Ошибка означает, что scan не выполнялся.
+type ScanScheduler func(ctx context.Context, scan func()) error
+
+// StreamOptions задает параметры подписки: Buffer - глубина очереди, Decimation - доставлять
+// каждый N-й скан (0 и 1 - каждый), Averaging - усреднение кадров подписки между свипами
+// (см. Averager); в среднее входят и прореженные сканы. Кадры с ошибками доставляются всегда.
+type StreamOptions struct {
+	Buffer     int
+	Decimation int
+	Averaging  *Averager
+}
+
+// Subscription - подписка на непрерывное сканирование. Если подписчик не успевает читать C,
+// старейший кадр в очереди отбрасывается, чтобы не задерживать сканирование и других подписчиков.
+// C закрывается вызовом Close или при закрытии устройства.
+type Subscription struct {
+	C          <-chan StreamFrame
+	ch         chan StreamFrame
+	vna        *VNA
+	decimation uint64
+	averaging  *Averager
+	received   uint64
+	dropped    atomic.Uint64
+	closeOnce  sync.Once
+}
+
+// Dropped возвращает число кадров, отброшенных из-за медленного чтения.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close отменяет подписку. Последняя отмененная подписка останавливает сканирование.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		v := s.vna
+		v.streamMu.Lock()
+		defer v.streamMu.Unlock()
+		if _, ok := v.subscribers[s]; !ok {
+			return
+		}
+		delete(v.subscribers, s)
+		close(s.ch)
+		if len(v.subscribers) == 0 && v.streamCancel != nil {
+			v.streamCancel()
+			v.streamCancel = nil
+		}
+	})
+}
+
+// deliver отправляет кадр без блокировки; key - настройки, с которыми получен кадр.
+// Вызывается с захваченным streamMu.
+func (s *Subscription) deliver(frame StreamFrame, key averagingKey) {
+	if frame.Err == nil {
+		if s.averaging != nil {
+			frame.Data = s.averaging.add(key, frame.Data)
+		}
+		s.received++
+		if s.decimation > 1 && (s.received-1)%s.decimation != 0 {
+			return
+		}
+	}
+	for {
+		select {
+		case s.ch <- frame:
+			return
+		default:
+		}
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// Subscribe подключается к непрерывному сканированию устройства, запуская его при первой подписке.
+// Сканирование использует цепочку обработки GetData и сетку частот, заданную SetSweep на момент
+// запуска: если Measure другого клиента перенастроил устройство, перед следующим кадром
+// восстанавливается сетка потока, поэтому все кадры одного запуска имеют одну сетку.
+func (v *VNA) Subscribe(opts StreamOptions) *Subscription {
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultStreamBuffer
+	}
+	ch := make(chan StreamFrame, opts.Buffer)
+	s := &Subscription{C: ch, ch: ch, vna: v, decimation: uint64(max(opts.Decimation, 1)), averaging: opts.Averaging}
+
+	v.streamMu.Lock()
+	defer v.streamMu.Unlock()
+	if v.ctx.Err() != nil {
+		close(ch)
+		return s
+	}
+	if v.subscribers == nil {
+		v.subscribers = make(map[*Subscription]struct{})
+	}
+	v.subscribers[s] = struct{}{}
+	if v.streamCancel == nil {
+		ctx, cancel := context.WithCancel(v.ctx)
+		v.streamCancel = cancel
+		go v.streamLoop(ctx)
+	}
+	return s
+}
+
+// SetStreamScheduler задает планировщик сканов непрерывного сканирования: каждый скан выполняется
+// через schedule и чередуется с другими заданиями устройства. nil (по умолчанию) - сканы
+// выполняются подряд, как только освобождается блокировка VNA. Ошибка планировщика доставляется
+// подписчикам кадром с Err, после чего скан повторяется.
+func (v *VNA) SetStreamScheduler(schedule ScanScheduler) {
+	v.streamMu.Lock()
+	defer v.streamMu.Unlock()
+	v.streamSchedule = schedule
+}
+
+// Subscribers возвращает число активных подписок на непрерывное сканирование.
+func (v *VNA) Subscribers() int {
+	v.streamMu.Lock()
+	defer v.streamMu.Unlock()
+	return len(v.subscribers)
+}
+
+func (v *VNA) streamLoop(ctx context.Context) {
+	sweep := v.Sweep()
+	for ctx.Err() == nil {
+		var data VNAData
+		var meta ScanMetadata
+		var key averagingKey
+		var err error
+		scan := func() { data, meta, key, err = v.streamScan(sweep) }
+		v.streamMu.Lock()
+		schedule := v.streamSchedule
+		v.streamMu.Unlock()
+		if schedule == nil {
+			scan()
+		} else if scheduleErr := schedule(ctx, scan); scheduleErr != nil {
+			err = scheduleErr
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		v.broadcast(StreamFrame{Data: data, Metadata: meta, Err: err}, key)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(streamRetryDelay):
+			}
+		}
+	}
+
+	// При закрытии устройства подписки завершаются, чтобы читатели C не ждали вечно.
+	if v.ctx.Err() != nil {
+		v.streamMu.Lock()
+		for s := range v.subscribers {
+			delete(v.subscribers, s)
+			close(s.ch)
+		}
+		v.streamCancel = nil
+		v.streamMu.Unlock()
+	}
+}
+
+// streamScan выполняет скан потока на сетке sweep, восстанавливая ее, если устройство
+// перенастроено после запуска потока. Нулевой sweep означает текущую сетку устройства.
+// Вместе с кадром возвращаются настройки, с которыми он получен, для усреднения подписок.
+func (v *VNA) streamScan(sweep SweepConfig) (VNAData, ScanMetadata, averagingKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if sweep.Points > 0 && !sweep.sameGrid(v.sweep) {
+		if err := v.applySweepLocked(sweep); err != nil {
+			v.publishLocked(EventSweepError, err.Error(), nil)
+			return VNAData{}, ScanMetadata{}, averagingKey{}, err
+		}
+	}
+	data, meta, err := v.scanLocked()
+	return data, meta, v.averagingKeyLocked(v.calibration), err
+}
+
+func (v *VNA) broadcast(frame StreamFrame, key averagingKey) {
+	v.streamMu.Lock()
+	defer v.streamMu.Unlock()
+	for s := range v.subscribers {
+		s.deliver(frame, key)
+	}
+}
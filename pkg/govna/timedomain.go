@@ -0,0 +1,339 @@
+// Этот файл содержит преобразование во временную область (TDR): режимы low-pass impulse/step и band-pass.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+type TimeDomainMode string
+
+const (
+	TimeDomainLowPassImpulse TimeDomainMode = "lowpass_impulse"
+	TimeDomainLowPassStep    TimeDomainMode = "lowpass_step"
+	TimeDomainBandPass       TimeDomainMode = "bandpass"
+)
+
+type WindowType string
+
+const (
+	WindowRectangular WindowType = "rectangular"
+	WindowHann        WindowType = "hann"
+	WindowKaiser      WindowType = "kaiser"
+)
+
+const (
+	// DefaultKaiserBeta соответствует "нормальному" окну коммерческих анализаторов.
+	DefaultKaiserBeta = 6.0
+	// DefaultTimeDomainPoints - число точек во временной области по умолчанию.
+	DefaultTimeDomainPoints = 1001
+)
+
+// TimeDomainConfig задает параметры преобразования во временную область.
+// Если Start и Stop не заданы, используется однозначный (без наложения) диапазон 0..1/Δf.
+// DCValue позволяет задать значение на постоянном токе вместо экстраполяции (только low-pass).
+type TimeDomainConfig struct {
+	Parameter      SParameter
+	Mode           TimeDomainMode
+	Window         WindowType
+	KaiserBeta     float64
+	Start, Stop    float64
+	Points         int
+	VelocityFactor float64
+	DCValue        *float64
+}
+
+// TimeDomainResult содержит отклик во временной области и производные трассы.
+// Reflection - действительный отклик для low-pass и модуль для band-pass.
+// Impedance заполняется только для low-pass step по отражению.
+type TimeDomainResult struct {
+	Mode       TimeDomainMode
+	Time       []float64
+	Distance   []float64
+	Response   []complex128
+	Reflection []float64
+	Impedance  []float64
+}
+
+// LowPassSweep возвращает гармоническую сетку частот (Start = шаг), необходимую для режимов low-pass.
+func LowPassSweep(stop float64, points int) SweepConfig {
+	return SweepConfig{Start: stop / float64(points), Stop: stop, Points: points}
+}
+
+// TimeDomain выполняет преобразование S11 или S21 во временную область.
+func (d *VNAData) TimeDomain(cfg TimeDomainConfig) (TimeDomainResult, error) {
+	if cfg.Parameter == "" {
+		cfg.Parameter = S11
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = TimeDomainLowPassImpulse
+	}
+	values, err := d.Parameter(cfg.Parameter)
+	if err != nil {
+		return TimeDomainResult{}, err
+	}
+	if len(values) < 2 {
+		return TimeDomainResult{}, errors.New("для преобразования во временную область требуется не менее двух точек")
+	}
+	step, err := uniformStep(d.Frequencies)
+	if err != nil {
+		return TimeDomainResult{}, err
+	}
+	if cfg.VelocityFactor == 0 {
+		cfg.VelocityFactor = 1
+	}
+	if cfg.VelocityFactor < 0 || cfg.VelocityFactor > 1 {
+		return TimeDomainResult{}, errors.New("коэффициент укорочения должен лежать в диапазоне (0, 1]")
+	}
+	if cfg.Points <= 0 {
+		cfg.Points = DefaultTimeDomainPoints
+	}
+	if cfg.Start == 0 && cfg.Stop == 0 {
+		cfg.Stop = 1 / step
+	}
+	if cfg.Stop <= cfg.Start {
+		return TimeDomainResult{}, errors.New("некорректный временной диапазон преобразования")
+	}
+
+	times := make([]float64, cfg.Points)
+	dt := 0.0
+	if cfg.Points > 1 {
+		dt = (cfg.Stop - cfg.Start) / float64(cfg.Points-1)
+	}
+	for i := range times {
+		times[i] = cfg.Start + float64(i)*dt
+	}
+
+	var response []complex128
+	switch cfg.Mode {
+	case TimeDomainLowPassImpulse, TimeDomainLowPassStep:
+		response, err = d.lowPass(values, step, cfg, dt)
+	case TimeDomainBandPass:
+		window, werr := makeWindow(cfg.Window, cfg.KaiserBeta, len(values), false)
+		if werr != nil {
+			return TimeDomainResult{}, werr
+		}
+		response = bandPassTransform(values, window, d.Frequencies[0], step, cfg.Start, dt, cfg.Points)
+	default:
+		err = fmt.Errorf("неизвестный режим временной области %q", cfg.Mode)
+	}
+	if err != nil {
+		return TimeDomainResult{}, err
+	}
+
+	result := TimeDomainResult{
+		Mode:       cfg.Mode,
+		Time:       times,
+		Distance:   make([]float64, len(times)),
+		Response:   response,
+		Reflection: make([]float64, len(times)),
+	}
+	propagation := SpeedOfLight * cfg.VelocityFactor
+	if cfg.Parameter == S11 || cfg.Parameter == S22 {
+		propagation /= 2
+	}
+	for i, t := range times {
+		result.Distance[i] = t * propagation
+		if cfg.Mode == TimeDomainBandPass {
+			result.Reflection[i] = cmplx.Abs(response[i])
+		} else {
+			result.Reflection[i] = real(response[i])
+		}
+	}
+
+	if cfg.Mode == TimeDomainLowPassStep && (cfg.Parameter == S11 || cfg.Parameter == S22) {
+		port := 1
+		if cfg.Parameter == S22 {
+			port = 2
+		}
+		z0 := real(d.ReferenceImpedance(port))
+		result.Impedance = make([]float64, len(times))
+		for i, rho := range result.Reflection {
+			if rho >= 1 {
+				result.Impedance[i] = math.Inf(1)
+				continue
+			}
+			result.Impedance[i] = z0 * (1 + rho) / (1 - rho)
+		}
+	}
+	return result, nil
+}
+
+// lowPass вычисляет импульсную или переходную характеристику по гармонической сетке частот,
+// дополняя спектр значением на постоянном токе и эрмитовой симметрией.
+func (d *VNAData) lowPass(values []complex128, step float64, cfg TimeDomainConfig, dt float64) ([]complex128, error) {
+	if !isHarmonicGrid(d.Frequencies, step) {
+		return nil, errors.New("режим low-pass требует гармонической сетки частот (start = шаг), см. LowPassSweep")
+	}
+	n := len(values)
+	dc := extrapolateDC(values)
+	if cfg.DCValue != nil {
+		dc = *cfg.DCValue
+	}
+
+	// Окно длины 2N+1 центрировано на постоянном токе, используется его правая половина.
+	full, err := makeWindow(cfg.Window, cfg.KaiserBeta, 2*n+1, true)
+	if err != nil {
+		return nil, err
+	}
+	window := full[n:]
+	weighted := make([]complex128, n)
+	norm := window[0]
+	for i, v := range values {
+		weighted[i] = v * complex(window[i+1], 0)
+		norm += 2 * window[i+1]
+	}
+
+	out := make([]complex128, cfg.Points)
+	if cfg.Mode == TimeDomainLowPassImpulse {
+		sums := harmonicSums(weighted, step, cfg.Start, dt, cfg.Points)
+		for k := range out {
+			out[k] = complex((window[0]*dc+2*real(sums[k]))/norm, 0)
+		}
+		return out, nil
+	}
+
+	// Переходная характеристика - интеграл импульсной по периоду, начиная с -T/2:
+	// s(t) = (1/T)[X0(t + T/2)] + (2/T)·Re Σ Wn·Xn·(exp(j2πnf₁t) - (-1)ⁿ)/(j2πnf₁).
+	period := 1 / step
+	integrated := make([]complex128, n)
+	var offset complex128
+	for i := range weighted {
+		harmonic := float64(i + 1)
+		integrated[i] = weighted[i] / complex(0, 2*math.Pi*harmonic*step)
+		sign := 1.0
+		if (i+1)%2 == 1 {
+			sign = -1
+		}
+		offset += integrated[i] * complex(sign, 0)
+	}
+	sums := harmonicSums(integrated, step, cfg.Start, dt, cfg.Points)
+	for k := range out {
+		t := cfg.Start + float64(k)*dt
+		value := window[0]*dc*(t+period/2) + 2*real(sums[k]-offset)
+		out[k] = complex(value/(period*window[0]), 0)
+	}
+	return out, nil
+}
+
+// harmonicSums вычисляет Σ x[n]·exp(j2π(n+1)·f₁·t_k) для t_k = t0 + k·dt через chirp-Z.
+func harmonicSums(x []complex128, f1, t0, dt float64, points int) []complex128 {
+	return frequencySums(x, f1, f1, t0, dt, points)
+}
+
+// frequencySums вычисляет Σ x[n]·exp(j2π(f0 + n·df)·t_k) для t_k = t0 + k·dt.
+func frequencySums(x []complex128, f0, df, t0, dt float64, points int) []complex128 {
+	// exp(j2π(f0+n·df)(t0+k·dt)) = exp(j2πf0·t_k)·exp(j2πn·df·t0)·exp(j2πnk·df·dt)
+	a := -2 * math.Pi * df * t0
+	w := 2 * math.Pi * df * dt
+	sums := chirpZ(x, points, a, w)
+	for k := range sums {
+		t := t0 + float64(k)*dt
+		sums[k] *= cmplx.Rect(1, 2*math.Pi*f0*t)
+	}
+	return sums
+}
+
+func bandPassTransform(values []complex128, window []float64, f0, df, t0, dt float64, points int) []complex128 {
+	weighted := make([]complex128, len(values))
+	norm := 0.0
+	for i, v := range values {
+		weighted[i] = v * complex(window[i], 0)
+		norm += window[i]
+	}
+	sums := frequencySums(weighted, f0, df, t0, dt, points)
+	for k := range sums {
+		sums[k] /= complex(norm, 0)
+	}
+	return sums
+}
+
+// extrapolateDC оценивает действительное значение на постоянном токе квадратичной экстраполяцией
+// по первым точкам гармонической сетки (f, 2f, 3f).
+func extrapolateDC(values []complex128) float64 {
+	switch {
+	case len(values) >= 3:
+		return real(3*values[0] - 3*values[1] + values[2])
+	case len(values) == 2:
+		return real(2*values[0] - values[1])
+	default:
+		return real(values[0])
+	}
+}
+
+func uniformStep(freqs []float64) (float64, error) {
+	if len(freqs) < 2 {
+		return 0, errors.New("для определения шага требуется не менее двух частот")
+	}
+	step := (freqs[len(freqs)-1] - freqs[0]) / float64(len(freqs)-1)
+	if step <= 0 {
+		return 0, errors.New("частоты должны возрастать")
+	}
+	for i := 1; i < len(freqs); i++ {
+		if math.Abs(freqs[i]-freqs[i-1]-step) > 1e-6*step+1e-3 {
+			return 0, errors.New("преобразование во временную область требует равномерной сетки частот")
+		}
+	}
+	return step, nil
+}
+
+func isHarmonicGrid(freqs []float64, step float64) bool {
+	return math.Abs(freqs[0]-step) <= 1e-6*step+1e-3
+}
+
+// makeWindow строит окно заданной длины. При symmetric=true окно симметрично относительно центра,
+// что требуется для low-pass режимов с окном, центрированным на постоянном токе.
+func makeWindow(kind WindowType, beta float64, length int, symmetric bool) ([]float64, error) {
+	window := make([]float64, length)
+	if length == 1 {
+		window[0] = 1
+		return window, nil
+	}
+	span := float64(length - 1)
+	if !symmetric {
+		// Для band-pass окно не должно обнулять крайние измеренные точки.
+		span = float64(length + 1)
+	}
+	offset := 0.0
+	if !symmetric {
+		offset = 1
+	}
+	switch kind {
+	case WindowRectangular:
+		for i := range window {
+			window[i] = 1
+		}
+	case WindowHann:
+		for i := range window {
+			window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*(float64(i)+offset)/span)
+		}
+	case WindowKaiser, "":
+		if beta == 0 {
+			beta = DefaultKaiserBeta
+		}
+		denom := besselI0(beta)
+		for i := range window {
+			x := 2*(float64(i)+offset)/span - 1
+			window[i] = besselI0(beta*math.Sqrt(math.Max(0, 1-x*x))) / denom
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный тип окна %q", kind)
+	}
+	return window, nil
+}
+
+// besselI0 вычисляет модифицированную функцию Бесселя первого рода нулевого порядка рядом Тейлора.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	half := x / 2
+	for k := 1; k < 200; k++ {
+		term *= (half / float64(k)) * (half / float64(k))
+		sum += term
+		if term < sum*1e-17 {
+			break
+		}
+	}
+	return sum
+}
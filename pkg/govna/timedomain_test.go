@@ -0,0 +1,84 @@
+package govna
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// delayedReflection моделирует рассогласование gamma на расстоянии, соответствующем задержке delay в одну сторону.
+func delayedReflection(cfg SweepConfig, gamma complex128, delay float64) VNAData {
+	data := VNAData{
+		Frequencies: make([]float64, cfg.Points),
+		S11:         make([]complex128, cfg.Points),
+	}
+	step := (cfg.Stop - cfg.Start) / float64(cfg.Points-1)
+	for i := range data.Frequencies {
+		f := cfg.Start + float64(i)*step
+		data.Frequencies[i] = f
+		data.S11[i] = gamma * cmplx.Rect(1, -2*math.Pi*f*2*delay)
+	}
+	return data
+}
+
+func TestTimeDomain_LowPassImpulseAndStep(t *testing.T) {
+	const delay = 5e-9
+	data := delayedReflection(LowPassSweep(1e9, 201), 0.5, delay)
+
+	impulse, err := data.TimeDomain(TimeDomainConfig{Mode: TimeDomainLowPassImpulse, Stop: 40e-9, Points: 401})
+	if err != nil {
+		t.Fatalf("TimeDomain failed: %v", err)
+	}
+	peak := 0
+	for i, v := range impulse.Reflection {
+		if v > impulse.Reflection[peak] {
+			peak = i
+		}
+	}
+	if math.Abs(impulse.Time[peak]-2*delay) > 0.2e-9 {
+		t.Fatalf("expected impulse at %g s, got %g s", 2*delay, impulse.Time[peak])
+	}
+	if math.Abs(impulse.Reflection[peak]-0.5) > 0.02 {
+		t.Fatalf("expected impulse amplitude 0.5, got %g", impulse.Reflection[peak])
+	}
+	if math.Abs(impulse.Distance[peak]-delay*SpeedOfLight) > 0.1 {
+		t.Fatalf("expected distance %g m, got %g m", delay*SpeedOfLight, impulse.Distance[peak])
+	}
+
+	step, err := data.TimeDomain(TimeDomainConfig{Mode: TimeDomainLowPassStep, Window: WindowHann, Stop: 40e-9, Points: 401})
+	if err != nil {
+		t.Fatalf("TimeDomain step failed: %v", err)
+	}
+	if math.Abs(step.Reflection[50]) > 0.02 {
+		t.Fatalf("expected no reflection before the mismatch, got %g", step.Reflection[50])
+	}
+	if math.Abs(step.Reflection[200]-0.5) > 0.02 {
+		t.Fatalf("expected step to settle at 0.5, got %g", step.Reflection[200])
+	}
+	if math.Abs(step.Impedance[200]-150) > 5 {
+		t.Fatalf("expected 150 Ω after the mismatch, got %g", step.Impedance[200])
+	}
+}
+
+func TestTimeDomain_BandPass(t *testing.T) {
+	const delay = 3e-9
+	data := delayedReflection(SweepConfig{Start: 100e6, Stop: 1e9, Points: 201}, 0.3, delay)
+
+	result, err := data.TimeDomain(TimeDomainConfig{Mode: TimeDomainBandPass, Stop: 20e-9, Points: 201})
+	if err != nil {
+		t.Fatalf("TimeDomain failed: %v", err)
+	}
+	peak := 0
+	for i, v := range result.Reflection {
+		if v > result.Reflection[peak] {
+			peak = i
+		}
+	}
+	if math.Abs(result.Time[peak]-2*delay) > 0.2e-9 || math.Abs(result.Reflection[peak]-0.3) > 0.02 {
+		t.Fatalf("expected 0.3 at %g s, got %g at %g s", 2*delay, result.Reflection[peak], result.Time[peak])
+	}
+
+	if _, err := data.TimeDomain(TimeDomainConfig{Mode: TimeDomainLowPassStep}); err == nil {
+		t.Fatalf("expected low-pass mode to reject a non-harmonic grid")
+	}
+}
@@ -0,0 +1,368 @@
+// Этот файл содержит производные форматы трасс: импеданс, потери, фазу, групповое время запаздывания и координаты диаграммы Смита.
+package govna
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+type SParameter string
+
+const (
+	S11 SParameter = "S11"
+	S21 SParameter = "S21"
+	S12 SParameter = "S12"
+	S22 SParameter = "S22"
+)
+
+type TraceFormat string
+
+const (
+	FormatLogMag          TraceFormat = "logmag"
+	FormatLinMag          TraceFormat = "linmag"
+	FormatPhase           TraceFormat = "phase"
+	FormatUnwrappedPhase  TraceFormat = "uphase"
+	FormatGroupDelay      TraceFormat = "gdelay"
+	FormatReal            TraceFormat = "real"
+	FormatImag            TraceFormat = "imag"
+	FormatVSWR            TraceFormat = "vswr"
+	FormatReturnLoss      TraceFormat = "rloss"
+	FormatMismatchLoss    TraceFormat = "mloss"
+	FormatSeriesR         TraceFormat = "rs"
+	FormatSeriesX         TraceFormat = "xs"
+	FormatParallelR       TraceFormat = "rp"
+	FormatParallelX       TraceFormat = "xp"
+	FormatImpedance       TraceFormat = "zmag"
+	FormatSeriesL         TraceFormat = "ls"
+	FormatSeriesC         TraceFormat = "cs"
+	FormatParallelL       TraceFormat = "lp"
+	FormatParallelC       TraceFormat = "cp"
+	FormatQ               TraceFormat = "q"
+	FormatSmithResistance TraceFormat = "smith_r"
+	FormatSmithReactance  TraceFormat = "smith_x"
+)
+
+var traceFormats = map[TraceFormat]bool{
+	FormatLogMag: true, FormatLinMag: true, FormatPhase: true, FormatUnwrappedPhase: true, FormatGroupDelay: true,
+	FormatReal: true, FormatImag: true, FormatVSWR: true, FormatReturnLoss: true, FormatMismatchLoss: true,
+	FormatSeriesR: true, FormatSeriesX: true, FormatParallelR: true, FormatParallelX: true, FormatImpedance: true,
+	FormatSeriesL: true, FormatSeriesC: true, FormatParallelL: true, FormatParallelC: true, FormatQ: true,
+	FormatSmithResistance: true, FormatSmithReactance: true,
+}
+
+// DefaultGroupDelayAperture - апертура группового времени запаздывания по умолчанию, в шагах сетки.
+const DefaultGroupDelayAperture = 2
+
+// TraceSpec описывает производную трассу: параметр, формат и апертуру для группового времени запаздывания.
+type TraceSpec struct {
+	Parameter SParameter  `json:"parameter"`
+	Format    TraceFormat `json:"format"`
+	Aperture  int         `json:"aperture,omitempty"`
+}
+
+func (s TraceSpec) withDefaults() TraceSpec {
+	if s.Parameter == "" {
+		s.Parameter = S11
+	}
+	if s.Format == "" {
+		s.Format = FormatLogMag
+	}
+	return s
+}
+
+// Validate проверяет описание трассы без данных: параметр и формат должны быть известны,
+// апертура - неотрицательной. Пустые параметр и формат допустимы и означают значения по умолчанию.
+func (s TraceSpec) Validate() error {
+	s = s.withDefaults()
+	switch s.Parameter {
+	case S11, S21, S12, S22:
+	default:
+		return fmt.Errorf("неизвестный S-параметр %q", s.Parameter)
+	}
+	if !traceFormats[s.Format] {
+		return fmt.Errorf("неизвестный формат трассы %q", s.Format)
+	}
+	if s.Aperture < 0 {
+		return errors.New("апертура должна быть неотрицательной")
+	}
+	return nil
+}
+
+// PolarPoint - точка трассы в полярных координатах (модуль и угол в градусах).
+type PolarPoint struct {
+	Magnitude float64
+	Angle     float64
+}
+
+// SmithPoint - точка на диаграмме Смита: коэффициент отражения и нормированный импеданс r + jx.
+type SmithPoint struct {
+	Gamma complex128
+	R, X  float64
+}
+
+// Parameter возвращает измеренные значения S-параметра.
+func (d *VNAData) Parameter(p SParameter) ([]complex128, error) {
+	var values []complex128
+	switch p {
+	case S11:
+		values = d.S11
+	case S21:
+		values = d.S21
+	case S12:
+		values = d.S12
+	case S22:
+		values = d.S22
+	default:
+		return nil, fmt.Errorf("неизвестный S-параметр %q", p)
+	}
+	if len(values) != len(d.Frequencies) || len(values) == 0 {
+		return nil, fmt.Errorf("параметр %s отсутствует в данных", p)
+	}
+	return values, nil
+}
+
+// Impedance возвращает комплексный импеданс нагрузки. Для S11/S22 используется отражательный метод,
+// для S21/S12 - метод последовательного включения ИУ между портами: Z = (Z01 + Z02)(1 - S21)/S21.
+func (d *VNAData) Impedance(p SParameter) ([]complex128, error) {
+	values, err := d.Parameter(p)
+	if err != nil {
+		return nil, err
+	}
+	z := make([]complex128, len(values))
+	switch p {
+	case S11, S22:
+		port := 1
+		if p == S22 {
+			port = 2
+		}
+		z0 := d.ReferenceImpedance(port)
+		for i, gamma := range values {
+			z[i] = reflectionToImpedance(gamma, z0)
+		}
+	default:
+		sum := complex(real(d.ReferenceImpedance(1))+real(d.ReferenceImpedance(2)), 0)
+		for i, s21 := range values {
+			if s21 == 0 {
+				z[i] = cmplx.Inf()
+				continue
+			}
+			z[i] = sum * (1 - s21) / s21
+		}
+	}
+	return z, nil
+}
+
+func reflectionToImpedance(gamma, z0 complex128) complex128 {
+	if gamma == 1 {
+		return cmplx.Inf()
+	}
+	return (cmplx.Conj(z0) + z0*gamma) / (1 - gamma)
+}
+
+func impedanceToReflection(z, z0 complex128) complex128 {
+	if cmplx.IsInf(z) {
+		return 1
+	}
+	return (z - cmplx.Conj(z0)) / (z + z0)
+}
+
+// Trace вычисляет производную трассу в заданном формате. По умолчанию используется S11 в формате logmag.
+func (d *VNAData) Trace(spec TraceSpec) ([]float64, error) {
+	spec = spec.withDefaults()
+	values, err := d.Parameter(spec.Parameter)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, len(values))
+
+	switch spec.Format {
+	case FormatLogMag:
+		for i, v := range values {
+			out[i] = 20 * math.Log10(cmplx.Abs(v))
+		}
+	case FormatLinMag:
+		for i, v := range values {
+			out[i] = cmplx.Abs(v)
+		}
+	case FormatPhase:
+		for i, v := range values {
+			out[i] = cmplx.Phase(v) * 180 / math.Pi
+		}
+	case FormatUnwrappedPhase:
+		for i, phase := range d.unwrappedPhase(values) {
+			out[i] = phase * 180 / math.Pi
+		}
+	case FormatGroupDelay:
+		return d.groupDelay(values, spec.Aperture)
+	case FormatReal:
+		for i, v := range values {
+			out[i] = real(v)
+		}
+	case FormatImag:
+		for i, v := range values {
+			out[i] = imag(v)
+		}
+	case FormatVSWR:
+		for i, v := range values {
+			out[i] = vswrFromGamma(cmplx.Abs(v))
+		}
+	case FormatReturnLoss:
+		for i, v := range values {
+			out[i] = -20 * math.Log10(cmplx.Abs(v))
+		}
+	case FormatMismatchLoss:
+		for i, v := range values {
+			out[i] = -10 * math.Log10(1-math.Min(cmplx.Abs(v)*cmplx.Abs(v), 1))
+		}
+	case FormatSmithResistance, FormatSmithReactance:
+		points, err := d.Smith(spec.Parameter)
+		if err != nil {
+			return nil, err
+		}
+		for i, p := range points {
+			if spec.Format == FormatSmithResistance {
+				out[i] = p.R
+			} else {
+				out[i] = p.X
+			}
+		}
+	default:
+		return d.impedanceTrace(spec)
+	}
+	return out, nil
+}
+
+func (d *VNAData) impedanceTrace(spec TraceSpec) ([]float64, error) {
+	z, err := d.Impedance(spec.Parameter)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, len(z))
+	for i, zi := range z {
+		r, x := real(zi), imag(zi)
+		omega := 2 * math.Pi * d.Frequencies[i]
+		mag2 := r*r + x*x
+		switch spec.Format {
+		case FormatSeriesR:
+			out[i] = r
+		case FormatSeriesX:
+			out[i] = x
+		case FormatParallelR:
+			out[i] = mag2 / r
+		case FormatParallelX:
+			out[i] = mag2 / x
+		case FormatImpedance:
+			out[i] = math.Sqrt(mag2)
+		case FormatSeriesL:
+			out[i] = x / omega
+		case FormatSeriesC:
+			out[i] = -1 / (omega * x)
+		case FormatParallelL:
+			out[i] = mag2 / x / omega
+		case FormatParallelC:
+			out[i] = -x / (omega * mag2)
+		case FormatQ:
+			out[i] = math.Abs(x) / r
+		default:
+			return nil, fmt.Errorf("неизвестный формат трассы %q", spec.Format)
+		}
+	}
+	return out, nil
+}
+
+func (d *VNAData) unwrappedPhase(values []complex128) []float64 {
+	phases := make([]float64, len(values))
+	for i, v := range values {
+		phases[i] = cmplx.Phase(v)
+	}
+	return unwrapPhase(phases)
+}
+
+// groupDelay вычисляет τ = -dφ/dω по разности фаз на краях апертуры, центрированной в каждой точке.
+func (d *VNAData) groupDelay(values []complex128, aperture int) ([]float64, error) {
+	if len(values) < 2 {
+		return nil, errors.New("для группового времени запаздывания требуется не менее двух точек")
+	}
+	if aperture <= 0 {
+		aperture = DefaultGroupDelayAperture
+	}
+	if aperture >= len(values) {
+		aperture = len(values) - 1
+	}
+	phases := d.unwrappedPhase(values)
+	out := make([]float64, len(values))
+	for i := range values {
+		lo := i - aperture/2
+		if lo < 0 {
+			lo = 0
+		}
+		hi := lo + aperture
+		if hi >= len(values) {
+			hi = len(values) - 1
+			lo = hi - aperture
+		}
+		df := d.Frequencies[hi] - d.Frequencies[lo]
+		if df == 0 {
+			return nil, fmt.Errorf("нулевой шаг частоты в точке %d", i)
+		}
+		out[i] = -(phases[hi] - phases[lo]) / (2 * math.Pi * df)
+	}
+	return out, nil
+}
+
+// Polar возвращает трассу параметра в полярных координатах.
+func (d *VNAData) Polar(p SParameter) ([]PolarPoint, error) {
+	values, err := d.Parameter(p)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]PolarPoint, len(values))
+	for i, v := range values {
+		points[i] = PolarPoint{Magnitude: cmplx.Abs(v), Angle: cmplx.Phase(v) * 180 / math.Pi}
+	}
+	return points, nil
+}
+
+// Smith возвращает координаты трассы на диаграмме Смита, нормированные к опорному импедансу.
+func (d *VNAData) Smith(p SParameter) ([]SmithPoint, error) {
+	values, err := d.Parameter(p)
+	if err != nil {
+		return nil, err
+	}
+	z, err := d.Impedance(p)
+	if err != nil {
+		return nil, err
+	}
+	port := 1
+	if p == S22 {
+		port = 2
+	}
+	z0 := real(d.ReferenceImpedance(port))
+	points := make([]SmithPoint, len(values))
+	for i, v := range values {
+		gamma := v
+		if p == S21 || p == S12 {
+			gamma = impedanceToReflection(z[i], complex(z0, 0))
+		}
+		points[i] = SmithPoint{Gamma: gamma, R: real(z[i]) / z0, X: imag(z[i]) / z0}
+	}
+	return points, nil
+}
+
+// ReturnLoss возвращает возвратные потери по S11 в дБ (положительные значения).
+func (d *VNAData) ReturnLoss() []float64 {
+	rl := make([]float64, len(d.S11))
+	for i, s11 := range d.S11 {
+		rl[i] = -20 * math.Log10(cmplx.Abs(s11))
+	}
+	return rl
+}
+
+func vswrFromGamma(gamma float64) float64 {
+	if gamma >= 1.0 {
+		return 9999.0 // Практически бесконечное значение
+	}
+	return (1 + gamma) / (1 - gamma)
+}
@@ -0,0 +1,242 @@
+// Этот файл содержит измерение полной матрицы S двухпортовой цепи на анализаторе с 1.5 портами
+// путем ручного переворота ИУ между прямым и обратным сканированием.
+package govna
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+type ReversalStep string
+
+const (
+	// ReversalForward - порт 1 ИУ подключен к порту 1 анализатора, порт 2 ИУ - к порту 2.
+	ReversalForward ReversalStep = "forward"
+	// ReversalReverse - ИУ перевернуто: порт 2 ИУ подключен к порту 1 анализатора.
+	ReversalReverse ReversalStep = "reverse"
+)
+
+// DefaultReversalTolerance - СКО комплексной разности отражений, ниже которого считается,
+// что прямое и обратное измерения совпадают.
+const DefaultReversalTolerance = 0.02
+
+// ReversalPrompt вызывается перед каждым сканированием, чтобы оператор подключил ИУ нужной стороной.
+type ReversalPrompt func(ctx context.Context, step ReversalStep) error
+
+type TwoPortCorrection string
+
+const (
+	// TwoPortCorrectionNone - калибровка не загружена, данные не скорректированы.
+	TwoPortCorrectionNone TwoPortCorrection = "none"
+	// TwoPortCorrectionReflection - профиль SOL без thru: корректируются только S11 и S22.
+	TwoPortCorrectionReflection TwoPortCorrection = "reflection"
+	// TwoPortCorrectionFull - профиль SOL с thru: полная коррекция с учетом согласования нагрузки порта 2.
+	TwoPortCorrectionFull TwoPortCorrection = "full"
+)
+
+// TwoPortOptions задает допуск проверки переворота. AllowSymmetric разрешает совпадение прямого
+// и обратного измерений (для заведомо симметричных ИУ, например аттенюаторов).
+type TwoPortOptions struct {
+	Tolerance      float64
+	AllowSymmetric bool
+}
+
+// ReversalCheck - результат проверки того, что ИУ действительно было перевернуто. Разность отражений
+// вычисляется по сырым данным, разность передач - по скорректированным S21 и S12 (СКО по всем частотам).
+type ReversalCheck struct {
+	ReflectionDifference   float64
+	TransmissionDifference float64
+	Reversed               bool
+	Warnings               []string
+}
+
+// TwoPortMeasurement содержит собранную и скорректированную матрицу S и сырые прямое и обратное измерения.
+type TwoPortMeasurement struct {
+	Data       VNAData
+	Forward    VNAData
+	Reverse    VNAData
+	Correction TwoPortCorrection
+	Check      ReversalCheck
+}
+
+// MeasureTwoPort измеряет ИУ в прямом и обратном включении и собирает полную матрицу S:
+// S11, S21 - из прямого измерения, S22, S12 - из обратного. Загруженная калибровка, расширение
+// портов и опорный импеданс применяются к собранной матрице; усреднение, сглаживание и память не используются.
+// Оба сканирования выполняются как Measure на текущей сетке VNA: сетка восстанавливается, если ее
+// изменили между шагами, сканы получают порядковые номера и доступны через LastScan, ошибки публикуются.
+// Если отражения до и после переворота совпадают, возвращается ошибка, если не задан AllowSymmetric.
+func (v *VNA) MeasureTwoPort(ctx context.Context, prompt ReversalPrompt, opts TwoPortOptions) (*TwoPortMeasurement, error) {
+	if ctx == nil {
+		ctx = v.ctx
+	}
+	if opts.Tolerance == 0 {
+		opts.Tolerance = DefaultReversalTolerance
+	}
+
+	sweep := v.Sweep()
+	if sweep.Points == 0 {
+		return nil, errors.New("сетка сканирования не задана")
+	}
+	forward, err := v.scanReversalStep(ctx, prompt, ReversalForward, sweep)
+	if err != nil {
+		return nil, err
+	}
+	reverse, err := v.scanReversalStep(ctx, prompt, ReversalReverse, sweep)
+	if err != nil {
+		return nil, err
+	}
+	if !frequenciesMatch(forward.Frequencies, reverse.Frequencies) {
+		return nil, errors.New("частотные сетки прямого и обратного измерений не совпадают")
+	}
+	for _, data := range []VNAData{forward, reverse} {
+		if len(data.S11) != len(data.Frequencies) || len(data.S21) != len(data.Frequencies) {
+			return nil, errors.New("для двухпортового измерения требуются S11 и S21")
+		}
+	}
+
+	v.mu.RLock()
+	calibration, extension, referenceZ0 := v.calibration, v.extension, v.referenceZ0
+	v.mu.RUnlock()
+
+	measurement := &TwoPortMeasurement{Forward: forward, Reverse: reverse}
+	measurement.Data, measurement.Correction, err = correctReversal(calibration, forward, reverse)
+	if err != nil {
+		return nil, err
+	}
+	measurement.Check = checkReversal(forward, reverse, measurement.Data, opts.Tolerance)
+	if !measurement.Check.Reversed && !opts.AllowSymmetric {
+		return nil, fmt.Errorf("ИУ не было перевернуто: отражения в прямом и обратном включении совпадают (СКО разности %.4f)",
+			measurement.Check.ReflectionDifference)
+	}
+	if extension != nil {
+		measurement.Data = extension.Apply(measurement.Data)
+	}
+	if referenceZ0 != nil {
+		measurement.Data, err = measurement.Data.Renormalize(referenceZ0...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return measurement, nil
+}
+
+func (v *VNA) scanReversalStep(ctx context.Context, prompt ReversalPrompt, step ReversalStep, sweep SweepConfig) (VNAData, error) {
+	if prompt != nil {
+		if err := prompt(ctx, step); err != nil {
+			return VNAData{}, err
+		}
+	}
+	data, _, err := v.measure(ctx, MeasureRequest{Sweep: sweep}, false)
+	if err != nil {
+		return VNAData{}, fmt.Errorf("ошибка сканирования (%s): %w", step, err)
+	}
+	return data, nil
+}
+
+// checkReversal сравнивает прямое и обратное измерения. У перевернутого несимметричного ИУ
+// сырые отражения различаются, а скорректированная передача взаимной цепи в обоих направлениях одинакова.
+// Сырые передачи сравнивать нельзя: они зависят от рассогласования портов анализатора.
+func checkReversal(forward, reverse, corrected VNAData, tolerance float64) ReversalCheck {
+	var reflection, transmission, level float64
+	for i := range forward.Frequencies {
+		reflection += sqAbs(forward.S11[i] - reverse.S11[i])
+		transmission += sqAbs(corrected.S21[i] - corrected.S12[i])
+		level += sqAbs(corrected.S21[i])
+	}
+	n := float64(len(forward.Frequencies))
+	check := ReversalCheck{
+		ReflectionDifference:   math.Sqrt(reflection / n),
+		TransmissionDifference: math.Sqrt(transmission / n),
+	}
+	check.Reversed = check.ReflectionDifference > tolerance
+	if !check.Reversed {
+		check.Warnings = append(check.Warnings, "отражения в прямом и обратном включении совпадают: ИУ симметрично или не было перевернуто")
+	}
+	if check.TransmissionDifference > tolerance*math.Max(math.Sqrt(level/n), 1) {
+		check.Warnings = append(check.Warnings, "передача в прямом и обратном включении различается: ИУ невзаимно или соединение изменилось между измерениями")
+	}
+	return check
+}
+
+func sqAbs(z complex128) float64 {
+	return real(z)*real(z) + imag(z)*imag(z)
+}
+
+// correctReversal собирает матрицу S и корректирует ее. Поскольку оба направления измерены одним
+// и тем же трактом анализатора, прямые и обратные коэффициенты ошибок модели из 12 членов совпадают.
+func correctReversal(profile *CalibrationProfile, forward, reverse VNAData) (VNAData, TwoPortCorrection, error) {
+	data := VNAData{
+		Frequencies: cloneFloat64Slice(forward.Frequencies),
+		S11:         cloneComplexSlice(forward.S11),
+		S21:         cloneComplexSlice(forward.S21),
+		S12:         cloneComplexSlice(reverse.S21),
+		S22:         cloneComplexSlice(reverse.S11),
+	}
+	if profile == nil {
+		return data, TwoPortCorrectionNone, nil
+	}
+	if !frequenciesMatch(data.Frequencies, profile.Frequencies) {
+		return VNAData{}, "", errors.New("частоты данных не совпадают с калибровкой")
+	}
+
+	loadMatch, transmission, ok := profile.transmissionTerms()
+	if !ok {
+		reflections, err := profile.apply(VNAData{Frequencies: data.Frequencies, S11: data.S11})
+		if err != nil {
+			return VNAData{}, "", err
+		}
+		data.S11 = reflections.S11
+		reflections, err = profile.apply(VNAData{Frequencies: data.Frequencies, S11: data.S22})
+		if err != nil {
+			return VNAData{}, "", err
+		}
+		data.S22 = reflections.S11
+		return data, TwoPortCorrectionReflection, nil
+	}
+
+	// В профиле SOL поле SourceMatch хранит e10e01, а ReflectionTracking - e11 (см. computeErrorTerms).
+	terms := profile.ErrorTerms
+	for i := range data.Frequencies {
+		ed, er, es := terms.Directivity[i], terms.SourceMatch[i], terms.ReflectionTracking[i]
+		el, et := loadMatch[i], transmission[i]
+		a := (data.S11[i] - ed) / er
+		b := data.S21[i] / et
+		c := data.S12[i] / et
+		e := (data.S22[i] - ed) / er
+		denominator := (1+a*es)*(1+e*es) - b*c*el*el
+		if denominator == 0 {
+			return VNAData{}, "", fmt.Errorf("деление на ноль при коррекции на частоте %.3f Гц", data.Frequencies[i])
+		}
+		data.S11[i] = (a*(1+e*es) - el*b*c) / denominator
+		data.S21[i] = b * (1 + e*(es-el)) / denominator
+		data.S12[i] = c * (1 + a*(es-el)) / denominator
+		data.S22[i] = (e*(1+a*es) - el*b*c) / denominator
+	}
+	return data, TwoPortCorrectionFull, nil
+}
+
+// transmissionTerms вычисляет по измерению эталона thru (прямое соединение нулевой длины)
+// согласование нагрузки порта 2 и трекинг передачи. ok = false, если thru не измерялся.
+func (p *CalibrationProfile) transmissionTerms() (loadMatch, tracking []complex128, ok bool) {
+	thru, found := p.Standards[CalibrationStandardThru]
+	if !found || len(thru.S11) != len(p.Frequencies) || len(thru.S21) != len(p.Frequencies) {
+		return nil, nil, false
+	}
+	corrected, err := p.apply(VNAData{Frequencies: p.Frequencies, S11: thru.S11})
+	if err != nil {
+		return nil, nil, false
+	}
+	loadMatch = corrected.S11
+	tracking = make([]complex128, len(p.Frequencies))
+	for i := range tracking {
+		es := p.ErrorTerms.ReflectionTracking[i]
+		tracking[i] = thru.S21[i] * (1 - es*loadMatch[i])
+		if cmplx.Abs(tracking[i]) == 0 {
+			return nil, nil, false
+		}
+	}
+	return loadMatch, tracking, true
+}
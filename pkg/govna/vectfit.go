@@ -0,0 +1,795 @@
+// Этот файл содержит рациональную аппроксимацию S-параметров методом векторной подгонки (vector fitting),
+// проверку и обеспечение пассивности модели и ее экспорт в SPICE и JSON.
+package govna
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+)
+
+const (
+	DefaultVectorFitOrder      = 10
+	DefaultVectorFitIterations = 10
+	// passivityMargin - запас, с которым EnforcePassivity опускает сингулярные числа ниже единицы.
+	passivityMargin = 1e-3
+	// passivityTolerance - допустимое превышение единицы из-за ошибок округления (например, у цепей без потерь).
+	passivityTolerance    = 1e-9
+	passivityMaxPasses    = 50
+	passivityCheckSamples = 4001
+)
+
+// VectorFitConfig задает порядок модели (число полюсов), число итераций перемещения полюсов
+// и число портов (0 - два, если в данных есть S21, иначе один).
+type VectorFitConfig struct {
+	Order            int
+	Iterations       int
+	Ports            int
+	EnforcePassivity bool
+}
+
+// RationalModel - модель S(s) = D + Σ R/(s - P) с общими для всех элементов полюсами (рад/с).
+// Poles содержит вещественные полюса и по одному полюсу из каждой комплексно-сопряженной пары (Im > 0);
+// сопряженному полюсу соответствует сопряженный вычет. Residues индексируются как [выход][вход][полюс].
+type RationalModel struct {
+	Ports    int
+	Z0       []float64
+	Poles    []complex128
+	Residues [][][]complex128
+	D        [][]float64
+	FitStart float64
+	FitStop  float64
+	RMSError float64
+	MaxError float64
+}
+
+// PassivityViolation - локальный максимум наибольшего сингулярного числа S, превышающий единицу
+// (с допуском passivityTolerance на ошибки округления).
+type PassivityViolation struct {
+	Frequency     float64
+	SingularValue float64
+}
+
+// VectorFit аппроксимирует S-параметры рациональной моделью. Если S12/S22 не измерены,
+// двухпортовая цепь считается взаимной и симметричной (S12=S21, S22=S11).
+func (d *VNAData) VectorFit(cfg VectorFitConfig) (*RationalModel, error) {
+	if cfg.Order == 0 {
+		cfg.Order = DefaultVectorFitOrder
+	}
+	if cfg.Iterations == 0 {
+		cfg.Iterations = DefaultVectorFitIterations
+	}
+	if cfg.Order < 1 || cfg.Iterations < 1 {
+		return nil, errors.New("порядок модели и число итераций должны быть положительными")
+	}
+	ports := cfg.Ports
+	if ports == 0 {
+		ports = 1
+		if len(d.S21) == len(d.Frequencies) && len(d.S21) > 0 {
+			ports = 2
+		}
+	}
+	responses, err := d.portMatrix(ports)
+	if err != nil {
+		return nil, err
+	}
+	n := len(d.Frequencies)
+	if 2*n*len(responses) < (len(responses)+1)*(cfg.Order+1) {
+		return nil, fmt.Errorf("недостаточно точек для модели порядка %d", cfg.Order)
+	}
+	for i := 1; i < n; i++ {
+		if d.Frequencies[i] <= d.Frequencies[i-1] {
+			return nil, errors.New("частоты должны строго возрастать")
+		}
+	}
+	z0 := make([]float64, ports)
+	for i := range z0 {
+		ref := d.ReferenceImpedance(i + 1)
+		if imag(ref) != 0 || real(ref) <= 0 {
+			return nil, errors.New("рациональная модель требует вещественного положительного опорного импеданса")
+		}
+		z0[i] = real(ref)
+	}
+
+	// Частота нормируется к верхней границе свипа, что улучшает обусловленность систем.
+	scale := 2 * math.Pi * d.Frequencies[n-1]
+	s := make([]complex128, n)
+	for i, f := range d.Frequencies {
+		s[i] = complex(0, 2*math.Pi*f/scale)
+	}
+
+	poles := initialPoles(cfg.Order, d.Frequencies[0]/d.Frequencies[n-1])
+	for iter := 0; iter < cfg.Iterations; iter++ {
+		poles, err = relocatePoles(s, responses, poles)
+		if err != nil {
+			return nil, fmt.Errorf("итерация %d: %w", iter+1, err)
+		}
+	}
+
+	model := &RationalModel{
+		Ports:    ports,
+		Z0:       z0,
+		FitStart: d.Frequencies[0],
+		FitStop:  d.Frequencies[n-1],
+		Poles:    make([]complex128, len(poles)),
+		Residues: make([][][]complex128, ports),
+		D:        make([][]float64, ports),
+	}
+	for k, p := range poles {
+		model.Poles[k] = p * complex(scale, 0)
+	}
+	for i := 0; i < ports; i++ {
+		model.Residues[i] = make([][]complex128, ports)
+		model.D[i] = make([]float64, ports)
+		for j := 0; j < ports; j++ {
+			residues, dterm, err := fitResidues(s, responses[i*ports+j], poles)
+			if err != nil {
+				return nil, err
+			}
+			for k := range residues {
+				residues[k] *= complex(scale, 0)
+			}
+			model.Residues[i][j] = residues
+			model.D[i][j] = dterm
+		}
+	}
+	model.updateError(d.Frequencies, responses)
+
+	if cfg.EnforcePassivity {
+		if err := model.EnforcePassivity(); err != nil {
+			return nil, err
+		}
+		model.updateError(d.Frequencies, responses)
+	}
+	return model, nil
+}
+
+// portMatrix возвращает элементы матрицы S по строкам (S11, S12, S21, S22 для двух портов).
+func (d *VNAData) portMatrix(ports int) ([][]complex128, error) {
+	s11, err := d.Parameter(S11)
+	if err != nil {
+		return nil, err
+	}
+	switch ports {
+	case 1:
+		return [][]complex128{s11}, nil
+	case 2:
+		s21, err := d.Parameter(S21)
+		if err != nil {
+			return nil, err
+		}
+		s12, s22 := s21, s11
+		if len(d.S12) == len(d.Frequencies) {
+			s12 = d.S12
+		}
+		if len(d.S22) == len(d.Frequencies) {
+			s22 = d.S22
+		}
+		return [][]complex128{s11, s12, s21, s22}, nil
+	}
+	return nil, fmt.Errorf("неподдерживаемое число портов %d", ports)
+}
+
+// initialPoles расставляет слабозатухающие комплексные пары равномерно по нормированной полосе.
+func initialPoles(order int, start float64) []complex128 {
+	pairs := order / 2
+	low := math.Max(start, 1e-3)
+	var poles []complex128
+	for k := 0; k < pairs; k++ {
+		beta := low
+		if pairs > 1 {
+			beta = low + (1-low)*float64(k)/float64(pairs-1)
+		}
+		poles = append(poles, complex(-beta/100, beta))
+	}
+	if order%2 == 1 {
+		poles = append(poles, complex(-(low+1)/2, 0))
+	}
+	return poles
+}
+
+// poleBasis вычисляет вещественный базис частичных дробей: для вещественного полюса 1/(s-p),
+// для пары - 1/(s-p) + 1/(s-p*) и j/(s-p) - j/(s-p*).
+func poleBasis(s complex128, poles []complex128) []complex128 {
+	var basis []complex128
+	for _, p := range poles {
+		if imag(p) == 0 {
+			basis = append(basis, 1/(s-p))
+			continue
+		}
+		a, b := 1/(s-p), 1/(s-cmplx.Conj(p))
+		basis = append(basis, a+b, complex(0, 1)*(a-b))
+	}
+	return basis
+}
+
+// basisResidues переводит вещественные коэффициенты базиса в комплексные вычеты полюсов.
+func basisResidues(poles []complex128, coeffs []float64) []complex128 {
+	residues := make([]complex128, len(poles))
+	k := 0
+	for i, p := range poles {
+		if imag(p) == 0 {
+			residues[i] = complex(coeffs[k], 0)
+			k++
+			continue
+		}
+		residues[i] = complex(coeffs[k], coeffs[k+1])
+		k += 2
+	}
+	return residues
+}
+
+// relocatePoles выполняет итерацию векторной подгонки: находит весовую функцию σ(s) с общими
+// для всех откликов полюсами и возвращает ее нули как новые полюса (неустойчивые отражаются).
+func relocatePoles(s []complex128, responses [][]complex128, poles []complex128) ([]complex128, error) {
+	basis := make([][]complex128, len(s))
+	for m := range s {
+		basis[m] = poleBasis(s[m], poles)
+	}
+	nb := len(basis[0])
+	cols := len(responses)*(nb+1) + nb
+	var a [][]float64
+	var b []float64
+	for k, h := range responses {
+		offset := k * (nb + 1)
+		for m := range s {
+			re := make([]float64, cols)
+			im := make([]float64, cols)
+			for n, phi := range basis[m] {
+				re[offset+n], im[offset+n] = real(phi), imag(phi)
+				weighted := -h[m] * phi
+				re[len(responses)*(nb+1)+n], im[len(responses)*(nb+1)+n] = real(weighted), imag(weighted)
+			}
+			re[offset+nb] = 1
+			a = append(a, re, im)
+			b = append(b, real(h[m]), imag(h[m]))
+		}
+	}
+	x, err := leastSquares(a, b)
+	if err != nil {
+		return nil, err
+	}
+	sigma := basisResidues(poles, x[len(responses)*(nb+1):])
+
+	// Нули σ(s) = 1 + Σ r/(s - p) - собственные значения diag(p) - 1·rᵀ (с учетом сопряженных полюсов).
+	var full, fullResidues []complex128
+	for i, p := range poles {
+		full = append(full, p)
+		fullResidues = append(fullResidues, sigma[i])
+		if imag(p) != 0 {
+			full = append(full, cmplx.Conj(p))
+			fullResidues = append(fullResidues, cmplx.Conj(sigma[i]))
+		}
+	}
+	matrix := cmatNew(len(full), len(full))
+	for i := range full {
+		for j := range full {
+			matrix[i][j] = -fullResidues[j]
+		}
+		matrix[i][i] += full[i]
+	}
+	zeros, err := complexEigenvalues(matrix)
+	if err != nil {
+		return nil, err
+	}
+
+	var relocated []complex128
+	for _, z := range zeros {
+		if real(z) > 0 {
+			z = complex(-real(z), imag(z))
+		}
+		if real(z) == 0 {
+			z = complex(-1e-6*math.Max(cmplx.Abs(z), 1e-3), imag(z))
+		}
+		switch {
+		case math.Abs(imag(z)) <= 1e-9*math.Max(cmplx.Abs(z), 1):
+			relocated = append(relocated, complex(real(z), 0))
+		case imag(z) > 0:
+			relocated = append(relocated, z)
+		}
+	}
+	if len(relocated) == 0 {
+		return nil, errors.New("не удалось найти полюса модели")
+	}
+	return relocated, nil
+}
+
+// fitResidues находит вычеты и постоянный член отклика при заданных полюсах.
+func fitResidues(s []complex128, h []complex128, poles []complex128) ([]complex128, float64, error) {
+	var a [][]float64
+	var b []float64
+	for m := range s {
+		basis := poleBasis(s[m], poles)
+		re := make([]float64, len(basis)+1)
+		im := make([]float64, len(basis)+1)
+		for n, phi := range basis {
+			re[n], im[n] = real(phi), imag(phi)
+		}
+		re[len(basis)] = 1
+		a = append(a, re, im)
+		b = append(b, real(h[m]), imag(h[m]))
+	}
+	x, err := leastSquares(a, b)
+	if err != nil {
+		return nil, 0, err
+	}
+	return basisResidues(poles, x[:len(x)-1]), x[len(x)-1], nil
+}
+
+func (m *RationalModel) updateError(freqs []float64, responses [][]complex128) {
+	sum, maxErr, count := 0.0, 0.0, 0
+	for i := 0; i < m.Ports; i++ {
+		for j := 0; j < m.Ports; j++ {
+			for k, f := range freqs {
+				e := cmplx.Abs(m.Response(i+1, j+1, f) - responses[i*m.Ports+j][k])
+				sum += e * e
+				maxErr = math.Max(maxErr, e)
+				count++
+			}
+		}
+	}
+	m.RMSError = math.Sqrt(sum / float64(count))
+	m.MaxError = maxErr
+}
+
+// Response вычисляет элемент S[out][in] модели (порты нумеруются с 1) на частоте freq.
+func (m *RationalModel) Response(out, in int, freq float64) complex128 {
+	s := complex(0, 2*math.Pi*freq)
+	value := complex(m.D[out-1][in-1], 0)
+	for k, p := range m.Poles {
+		r := m.Residues[out-1][in-1][k]
+		value += r / (s - p)
+		if imag(p) != 0 {
+			value += cmplx.Conj(r) / (s - cmplx.Conj(p))
+		}
+	}
+	return value
+}
+
+func (m *RationalModel) matrixAt(freq float64) [][]complex128 {
+	s := cmatNew(m.Ports, m.Ports)
+	for i := range s {
+		for j := range s[i] {
+			s[i][j] = m.Response(i+1, j+1, freq)
+		}
+	}
+	return s
+}
+
+// Evaluate рассчитывает S-параметры модели на произвольной сетке частот.
+func (m *RationalModel) Evaluate(frequencies []float64) VNAData {
+	data := VNAData{
+		Frequencies: cloneFloat64Slice(frequencies),
+		S11:         make([]complex128, len(frequencies)),
+		S21:         make([]complex128, len(frequencies)),
+	}
+	if m.Ports == 2 {
+		data.S12 = make([]complex128, len(frequencies))
+		data.S22 = make([]complex128, len(frequencies))
+	}
+	for k, f := range frequencies {
+		data.S11[k] = m.Response(1, 1, f)
+		if m.Ports == 2 {
+			data.S21[k] = m.Response(2, 1, f)
+			data.S12[k] = m.Response(1, 2, f)
+			data.S22[k] = m.Response(2, 2, f)
+		}
+	}
+	for _, z := range m.Z0 {
+		if z != DefaultReferenceImpedance {
+			data.Z0 = make([]complex128, len(m.Z0))
+			for i := range m.Z0 {
+				data.Z0[i] = complex(m.Z0[i], 0)
+			}
+			break
+		}
+	}
+	return data
+}
+
+// largestSingular возвращает наибольшее сингулярное число матрицы S (1x1 или 2x2) и
+// соответствующие левый и правый сингулярные векторы.
+func largestSingular(s [][]complex128) (float64, []complex128, []complex128) {
+	if len(s) == 1 {
+		sigma := cmplx.Abs(s[0][0])
+		u := complex128(1)
+		if sigma > 0 {
+			u = s[0][0] / complex(sigma, 0)
+		}
+		return sigma, []complex128{u}, []complex128{1}
+	}
+	// Собственный вектор эрмитовой матрицы SᴴS = [[a, b], [b*, c]] для наибольшего собственного числа.
+	a := real(cmplx.Conj(s[0][0])*s[0][0] + cmplx.Conj(s[1][0])*s[1][0])
+	c := real(cmplx.Conj(s[0][1])*s[0][1] + cmplx.Conj(s[1][1])*s[1][1])
+	b := cmplx.Conj(s[0][0])*s[0][1] + cmplx.Conj(s[1][0])*s[1][1]
+	lambda := (a+c)/2 + math.Sqrt((a-c)*(a-c)/4+real(b*cmplx.Conj(b)))
+	v := []complex128{b, complex(lambda-a, 0)}
+	if alt := []complex128{complex(lambda-c, 0), cmplx.Conj(b)}; cmplx.Abs(alt[0])+cmplx.Abs(alt[1]) > cmplx.Abs(v[0])+cmplx.Abs(v[1]) {
+		v = alt
+	}
+	norm := math.Hypot(cmplx.Abs(v[0]), cmplx.Abs(v[1]))
+	if norm == 0 {
+		v, norm = []complex128{1, 0}, 1
+	}
+	v[0] /= complex(norm, 0)
+	v[1] /= complex(norm, 0)
+	sigma := math.Sqrt(math.Max(lambda, 0))
+	u := []complex128{s[0][0]*v[0] + s[0][1]*v[1], s[1][0]*v[0] + s[1][1]*v[1]}
+	if sigma > 0 {
+		u[0] /= complex(sigma, 0)
+		u[1] /= complex(sigma, 0)
+	}
+	return sigma, u, v
+}
+
+// passivityGrid возвращает частоты проверки пассивности: от 0 до удвоенной наибольшей из верхней
+// частоты аппроксимации и резонансных частот полюсов, а также частоту, на которой модель близка к D.
+func (m *RationalModel) passivityGrid() []float64 {
+	top := m.FitStop
+	for _, p := range m.Poles {
+		top = math.Max(top, cmplx.Abs(p)/(2*math.Pi))
+	}
+	top *= 2
+	grid := make([]float64, passivityCheckSamples, passivityCheckSamples+1)
+	for i := range grid {
+		grid[i] = top * float64(i) / float64(passivityCheckSamples-1)
+	}
+	return append(grid, 1e3*top)
+}
+
+// CheckPassivity ищет частоты, на которых наибольшее сингулярное число S превышает единицу.
+func (m *RationalModel) CheckPassivity() []PassivityViolation {
+	grid := m.passivityGrid()
+	sigma := make([]float64, len(grid))
+	for i, f := range grid {
+		sigma[i], _, _ = largestSingular(m.matrixAt(f))
+	}
+	var violations []PassivityViolation
+	for i := range grid {
+		if sigma[i] <= 1+passivityTolerance {
+			continue
+		}
+		if (i > 0 && sigma[i-1] > sigma[i]) || (i < len(grid)-1 && sigma[i+1] >= sigma[i]) {
+			continue
+		}
+		violations = append(violations, PassivityViolation{Frequency: grid[i], SingularValue: sigma[i]})
+	}
+	return violations
+}
+
+// EnforcePassivity корректирует вычеты и D так, чтобы сингулярные числа S не превышали единицу.
+// На каждом проходе в точках нарушений требуется уменьшить S вдоль старших сингулярных векторов,
+// а в полосе аппроксимации - минимально изменить отклик (метод возмущения вычетов).
+func (m *RationalModel) EnforcePassivity() error {
+	grid := m.passivityGrid()
+	var band []float64
+	for _, f := range grid {
+		if f >= m.FitStart && f <= m.FitStop {
+			band = append(band, f)
+		}
+	}
+	for pass := 0; pass < passivityMaxPasses; pass++ {
+		violations := m.CheckPassivity()
+		if len(violations) == 0 {
+			return nil
+		}
+		targets := make([][][]complex128, len(violations))
+		for k, v := range violations {
+			sigma, u, w := largestSingular(m.matrixAt(v.Frequency))
+			delta := sigma - (1 - passivityMargin)
+			targets[k] = cmatNew(m.Ports, m.Ports)
+			for i := range u {
+				for j := range w {
+					targets[k][i][j] = -complex(delta, 0) * u[i] * cmplx.Conj(w[j])
+				}
+			}
+		}
+		for i := 0; i < m.Ports; i++ {
+			for j := 0; j < m.Ports; j++ {
+				if err := m.perturbResidues(i, j, violations, targets, band); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if len(m.CheckPassivity()) > 0 {
+		return errors.New("не удалось обеспечить пассивность модели")
+	}
+	return nil
+}
+
+// perturbResidues решает задачу наименьших квадратов для поправок к вычетам и D элемента S[i][j].
+func (m *RationalModel) perturbResidues(i, j int, violations []PassivityViolation, targets [][][]complex128, band []float64) error {
+	const violationWeight = 100
+	var a [][]float64
+	var b []float64
+	addRows := func(freq float64, target complex128, weight float64) {
+		basis := poleBasis(complex(0, 2*math.Pi*freq), m.Poles)
+		re := make([]float64, len(basis)+1)
+		im := make([]float64, len(basis)+1)
+		for n, phi := range basis {
+			re[n], im[n] = weight*real(phi), weight*imag(phi)
+		}
+		re[len(basis)] = weight
+		a = append(a, re, im)
+		b = append(b, weight*real(target), weight*imag(target))
+	}
+	for k, v := range violations {
+		addRows(v.Frequency, targets[k][i][j], violationWeight)
+	}
+	for _, f := range band {
+		addRows(f, 0, 1)
+	}
+	x, err := leastSquares(a, b)
+	if err != nil {
+		return fmt.Errorf("коррекция пассивности: %w", err)
+	}
+	delta := basisResidues(m.Poles, x[:len(x)-1])
+	for k := range delta {
+		m.Residues[i][j][k] += delta[k]
+	}
+	m.D[i][j] += x[len(x)-1]
+	return nil
+}
+
+// complexEigenvalues вычисляет собственные значения комплексной матрицы: приведение к форме
+// Хессенберга отражениями Хаусхолдера и QR-итерации со сдвигами Уилкинсона.
+func complexEigenvalues(a [][]complex128) ([]complex128, error) {
+	n := len(a)
+	h := cmatNew(n, n)
+	for i := range a {
+		copy(h[i], a[i])
+	}
+	for k := 0; k < n-2; k++ {
+		v := make([]complex128, n-k-1)
+		norm := 0.0
+		for i := range v {
+			v[i] = h[k+1+i][k]
+			norm += real(v[i] * cmplx.Conj(v[i]))
+		}
+		alpha := math.Sqrt(norm)
+		if alpha == 0 {
+			continue
+		}
+		phase := complex128(1)
+		if v[0] != 0 {
+			phase = v[0] / complex(cmplx.Abs(v[0]), 0)
+		}
+		v[0] += phase * complex(alpha, 0)
+		vnorm := 0.0
+		for _, x := range v {
+			vnorm += real(x * cmplx.Conj(x))
+		}
+		for j := 0; j < n; j++ {
+			var dot complex128
+			for i := range v {
+				dot += cmplx.Conj(v[i]) * h[k+1+i][j]
+			}
+			dot *= complex(2/vnorm, 0)
+			for i := range v {
+				h[k+1+i][j] -= v[i] * dot
+			}
+		}
+		for i := 0; i < n; i++ {
+			var dot complex128
+			for j := range v {
+				dot += h[i][k+1+j] * v[j]
+			}
+			dot *= complex(2/vnorm, 0)
+			for j := range v {
+				h[i][k+1+j] -= dot * cmplx.Conj(v[j])
+			}
+		}
+	}
+
+	eigenvalues := make([]complex128, n)
+	hi, iter := n-1, 0
+	for hi >= 0 {
+		if hi == 0 {
+			eigenvalues[0] = h[0][0]
+			break
+		}
+		l := hi
+		for ; l > 0; l-- {
+			if cmplx.Abs(h[l][l-1]) <= 1e-14*(cmplx.Abs(h[l][l])+cmplx.Abs(h[l-1][l-1])) {
+				h[l][l-1] = 0
+				break
+			}
+		}
+		if l == hi {
+			eigenvalues[hi] = h[hi][hi]
+			hi--
+			iter = 0
+			continue
+		}
+		iter++
+		if iter > 100*n {
+			return nil, errors.New("QR-итерации собственных значений не сошлись")
+		}
+
+		a11, a12, a21, a22 := h[hi-1][hi-1], h[hi-1][hi], h[hi][hi-1], h[hi][hi]
+		half := (a11 + a22) / 2
+		disc := cmplx.Sqrt(half*half - (a11*a22 - a12*a21))
+		mu := half + disc
+		if cmplx.Abs(half-disc-a22) < cmplx.Abs(mu-a22) {
+			mu = half - disc
+		}
+		if iter%10 == 0 {
+			// Исключительный сдвиг выводит итерации из возможного цикла.
+			mu = a22 + complex(cmplx.Abs(a21), 0)
+		}
+
+		for k := l; k <= hi; k++ {
+			h[k][k] -= mu
+		}
+		cs := make([]complex128, hi-l)
+		sn := make([]complex128, hi-l)
+		for k := l; k < hi; k++ {
+			x, y := h[k][k], h[k+1][k]
+			r := math.Hypot(cmplx.Abs(x), cmplx.Abs(y))
+			c, s := complex128(1), complex128(0)
+			if r != 0 {
+				c, s = x/complex(r, 0), y/complex(r, 0)
+			}
+			cs[k-l], sn[k-l] = c, s
+			for j := k; j <= hi; j++ {
+				t1, t2 := h[k][j], h[k+1][j]
+				h[k][j] = cmplx.Conj(c)*t1 + cmplx.Conj(s)*t2
+				h[k+1][j] = -s*t1 + c*t2
+			}
+		}
+		for k := l; k < hi; k++ {
+			c, s := cs[k-l], sn[k-l]
+			last := k + 2
+			if last > hi {
+				last = hi
+			}
+			for i := l; i <= last; i++ {
+				t1, t2 := h[i][k], h[i][k+1]
+				h[i][k] = t1*c + t2*s
+				h[i][k+1] = -t1*cmplx.Conj(s) + t2*cmplx.Conj(c)
+			}
+		}
+		for k := l; k <= hi; k++ {
+			h[k][k] += mu
+		}
+	}
+	return eigenvalues, nil
+}
+
+type jsonComplex struct {
+	Re float64 `json:"re"`
+	Im float64 `json:"im"`
+}
+
+type jsonResponse struct {
+	Output   int           `json:"output"`
+	Input    int           `json:"input"`
+	D        float64       `json:"d"`
+	Residues []jsonComplex `json:"residues"`
+}
+
+type jsonRationalModel struct {
+	Ports     int            `json:"ports"`
+	Z0        []float64      `json:"z0"`
+	FitStart  float64        `json:"fit_start"`
+	FitStop   float64        `json:"fit_stop"`
+	RMSError  float64        `json:"rms_error"`
+	MaxError  float64        `json:"max_error"`
+	Passive   bool           `json:"passive"`
+	Poles     []jsonComplex  `json:"poles"`
+	Responses []jsonResponse `json:"responses"`
+}
+
+// WriteJSON записывает описание модели полюсами и вычетами. В JSON перечислены все полюса,
+// включая сопряженные, поэтому S(s) = d + Σ residues[k]/(s - poles[k]) без дополнительных соглашений.
+func (m *RationalModel) WriteJSON(w io.Writer) error {
+	out := jsonRationalModel{
+		Ports:    m.Ports,
+		Z0:       m.Z0,
+		FitStart: m.FitStart,
+		FitStop:  m.FitStop,
+		RMSError: m.RMSError,
+		MaxError: m.MaxError,
+		Passive:  len(m.CheckPassivity()) == 0,
+	}
+	for _, p := range m.Poles {
+		out.Poles = append(out.Poles, jsonComplex{real(p), imag(p)})
+		if imag(p) != 0 {
+			out.Poles = append(out.Poles, jsonComplex{real(p), -imag(p)})
+		}
+	}
+	for i := 0; i < m.Ports; i++ {
+		for j := 0; j < m.Ports; j++ {
+			response := jsonResponse{Output: i + 1, Input: j + 1, D: m.D[i][j]}
+			for k, p := range m.Poles {
+				r := m.Residues[i][j][k]
+				response.Residues = append(response.Residues, jsonComplex{real(r), imag(r)})
+				if imag(p) != 0 {
+					response.Residues = append(response.Residues, jsonComplex{real(r), -imag(r)})
+				}
+			}
+			out.Responses = append(out.Responses, response)
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// WriteSPICE записывает модель как подсхему SPICE с выводами p1[, p2] относительно узла 0.
+// Порт i реализован источником b-волны за сопротивлением Z0: V - Z0·I = Σ S_ij (V_j + Z0·I_j);
+// каждый полюс - емкость с управляемыми источниками тока (вещественная форма пары полюсов).
+func (m *RationalModel) WriteSPICE(w io.Writer, name string) error {
+	if name == "" {
+		name = "govna_model"
+	}
+	// Емкости, проводимости и выходные крутизны масштабируются коэффициентом k = 1/max|p|,
+	// чтобы номиналы элементов и напряжения узлов состояния оставались порядка единицы.
+	k := 1.0
+	for _, p := range m.Poles {
+		k = math.Max(k, cmplx.Abs(p))
+	}
+	k = 1 / k
+
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	nodes := ""
+	for i := 1; i <= m.Ports; i++ {
+		nodes += fmt.Sprintf(" p%d", i)
+	}
+	add("* GoVNA rational model: %d port(s), %d pole(s), RMS fit error %.3g", m.Ports, len(m.Poles), m.RMSError)
+	add(".SUBCKT %s%s", name, nodes)
+	for i := 1; i <= m.Ports; i++ {
+		z0 := m.Z0[i-1]
+		add("* Port %d", i)
+		add("VSENSE%d p%d n%d DC 0", i, i, i)
+		add("RPORT%d n%d s%d %.12g", i, i, i, z0)
+		add("EPORT%d s%d 0 o%d 0 1", i, i, i)
+		add("EIN%d ai%d 0 p%d 0 1", i, i, i)
+		add("HIN%d a%d ai%d VSENSE%d %.12g", i, i, i, i, z0)
+		add("ROUT%d o%d 0 1", i, i)
+	}
+	for i := 1; i <= m.Ports; i++ {
+		for j := 1; j <= m.Ports; j++ {
+			add("* S%d%d", i, j)
+			tag := fmt.Sprintf("%d%d", i, j)
+			add("GD%s 0 o%d a%d 0 %.12g", tag, i, j, m.D[i-1][j-1])
+			for n, p := range m.Poles {
+				r := m.Residues[i-1][j-1][n]
+				alpha, beta := real(p), imag(p)
+				x := fmt.Sprintf("x%s_%d", tag, n+1)
+				if beta == 0 {
+					add("C%s %s 0 %.12g", x, x, k)
+					add("GL%s %s 0 %s 0 %.12g", x, x, x, -alpha*k)
+					add("GI%s 0 %s a%d 0 1", x, x, j)
+					add("GO%s 0 o%d %s 0 %.12g", x, i, x, real(r)*k)
+					continue
+				}
+				// Пара полюсов α±jβ: k·x1' = k(αx1 + βx2) + 2u, k·x2' = k(-βx1 + αx2), вклад в выход k(Re(r)x1 + Im(r)x2).
+				x1, x2 := x+"a", x+"b"
+				add("C%s %s 0 %.12g", x1, x1, k)
+				add("GL%s %s 0 %s 0 %.12g", x1, x1, x1, -alpha*k)
+				add("GC%s 0 %s %s 0 %.12g", x1, x1, x2, beta*k)
+				add("GI%s 0 %s a%d 0 2", x1, x1, j)
+				add("C%s %s 0 %.12g", x2, x2, k)
+				add("GL%s %s 0 %s 0 %.12g", x2, x2, x2, -alpha*k)
+				add("GC%s 0 %s %s 0 %.12g", x2, x2, x1, -beta*k)
+				add("GO%s 0 o%d %s 0 %.12g", x1, i, x1, real(r)*k)
+				add("GO%s 0 o%d %s 0 %.12g", x2, i, x2, imag(r)*k)
+			}
+		}
+	}
+	add(".ENDS %s", name)
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package govna
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestVNAData_VectorFit(t *testing.T) {
+	// Последовательный RLC между портами: полосовой фильтр второго порядка.
+	freq := make([]float64, 201)
+	data := VNAData{Frequencies: freq}
+	for i := range freq {
+		freq[i] = 10e6 + float64(i)*1e6
+		w := 2 * math.Pi * freq[i]
+		z := complex(5, w*100e-9-1/(w*10e-12))
+		data.S11 = append(data.S11, z/(z+100))
+		data.S21 = append(data.S21, 100/(z+100))
+	}
+
+	model, err := data.VectorFit(VectorFitConfig{Order: 6})
+	if err != nil {
+		t.Fatalf("VectorFit failed: %v", err)
+	}
+	if model.Ports != 2 || model.RMSError > 1e-9 {
+		t.Fatalf("unexpected model: ports=%d rms=%g", model.Ports, model.RMSError)
+	}
+	check := model.Evaluate([]float64{159e6})
+	if cmplx.Abs(check.S21[0]-data.S21[149]) > 1e-9 {
+		t.Fatalf("model S21 %v does not match data %v", check.S21[0], data.S21[149])
+	}
+	if violations := model.CheckPassivity(); len(violations) != 0 {
+		t.Fatalf("expected passive model, got %d violations", len(violations))
+	}
+
+	// Искусственное усиление делает модель активной; коррекция должна восстановить пассивность.
+	for i := range model.Residues {
+		for j := range model.Residues[i] {
+			for k := range model.Residues[i][j] {
+				model.Residues[i][j][k] *= 1.05
+			}
+			model.D[i][j] *= 1.05
+		}
+	}
+	if len(model.CheckPassivity()) == 0 {
+		t.Fatalf("expected passivity violations after scaling")
+	}
+	if err := model.EnforcePassivity(); err != nil {
+		t.Fatalf("EnforcePassivity failed: %v", err)
+	}
+	if len(model.CheckPassivity()) != 0 {
+		t.Fatalf("model is still not passive")
+	}
+
+	var spice bytes.Buffer
+	if err := model.WriteSPICE(&spice, "dut"); err != nil {
+		t.Fatalf("WriteSPICE failed: %v", err)
+	}
+	if !strings.Contains(spice.String(), ".SUBCKT dut p1 p2") || !strings.Contains(spice.String(), ".ENDS dut") {
+		t.Fatalf("unexpected SPICE netlist:\n%s", spice.String())
+	}
+	// Схема подсхемы должна воспроизводить S-параметры модели.
+	for _, f := range []float64{20e6, 120e6, 159e6, 175e6, 205e6} {
+		s, err := simulateSPICE(spice.String(), model.Z0, f)
+		if err != nil {
+			t.Fatalf("netlist simulation at %g Hz failed: %v", f, err)
+		}
+		for i := 0; i < model.Ports; i++ {
+			for j := 0; j < model.Ports; j++ {
+				if want := model.Response(i+1, j+1, f); cmplx.Abs(s[i][j]-want) > 1e-6 {
+					t.Fatalf("netlist S%d%d at %g Hz: expected %v, got %v", i+1, j+1, f, want, s[i][j])
+				}
+			}
+		}
+	}
+	var description struct {
+		Poles     []map[string]float64 `json:"poles"`
+		Responses []struct {
+			Residues []map[string]float64 `json:"residues"`
+		} `json:"responses"`
+	}
+	var encoded bytes.Buffer
+	if err := model.WriteJSON(&encoded); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if err := json.Unmarshal(encoded.Bytes(), &description); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(description.Responses) != 4 || len(description.Responses[0].Residues) != len(description.Poles) {
+		t.Fatalf("unexpected pole/residue description: %s", encoded.String())
+	}
+}
+
+// simulateSPICE рассчитывает S-параметры подсхемы, записанной WriteSPICE, методом узловых потенциалов
+// на частоте freq. Каждый порт нагружен на Z0 и по очереди возбуждается источником с падающей
+// волной a = (V + Z0·I)/2 = 1, поэтому S_ij = V_i - δ_ij.
+func simulateSPICE(netlist string, z0 []float64, freq float64) ([][]complex128, error) {
+	type element struct {
+		name   string
+		fields []string
+	}
+	var elements []element
+	nodes := map[string]int{"0": -1}
+	node := func(name string) int {
+		if _, ok := nodes[name]; !ok {
+			nodes[name] = len(nodes) - 1
+		}
+		return nodes[name]
+	}
+	branches := map[string]int{}
+	for _, line := range strings.Split(netlist, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "*") || strings.HasPrefix(fields[0], ".") {
+			continue
+		}
+		elements = append(elements, element{name: fields[0], fields: fields[1:]})
+		node(fields[1])
+		node(fields[2])
+		switch fields[0][0] {
+		case 'E', 'G':
+			node(fields[3])
+			node(fields[4])
+		}
+	}
+	size := len(nodes) - 1
+	for _, e := range elements {
+		switch e.name[0] {
+		case 'V', 'E', 'H':
+			branches[e.name] = size
+			size++
+		}
+	}
+	ports := len(z0)
+	portNodes := make([]int, ports)
+	for i := range portNodes {
+		portNodes[i] = node(fmt.Sprintf("p%d", i+1))
+	}
+
+	a := cmatNew(size, size)
+	stamp := func(row, col int, value complex128) {
+		if row >= 0 && col >= 0 {
+			a[row][col] += value
+		}
+	}
+	admittance := func(n1, n2 int, y complex128) {
+		stamp(n1, n1, y)
+		stamp(n2, n2, y)
+		stamp(n1, n2, -y)
+		stamp(n2, n1, -y)
+	}
+	omega := 2 * math.Pi * freq
+	for _, e := range elements {
+		value := func(i int) complex128 {
+			v, err := strconv.ParseFloat(e.fields[i], 64)
+			if err != nil {
+				panic(fmt.Sprintf("%s: %v", e.name, err))
+			}
+			return complex(v, 0)
+		}
+		n1, n2 := nodes[e.fields[0]], nodes[e.fields[1]]
+		switch e.name[0] {
+		case 'R':
+			admittance(n1, n2, 1/value(2))
+		case 'C':
+			admittance(n1, n2, complex(0, omega)*value(2))
+		case 'G':
+			// Ток gm·(Vc+ - Vc-) течет через источник от n+ к n-.
+			c1, c2, gm := nodes[e.fields[2]], nodes[e.fields[3]], value(4)
+			stamp(n1, c1, gm)
+			stamp(n1, c2, -gm)
+			stamp(n2, c1, -gm)
+			stamp(n2, c2, gm)
+		case 'V', 'E', 'H':
+			b := branches[e.name]
+			stamp(n1, b, 1)
+			stamp(n2, b, -1)
+			stamp(b, n1, 1)
+			stamp(b, n2, -1)
+			switch e.name[0] {
+			case 'E':
+				stamp(b, nodes[e.fields[2]], -value(4))
+				stamp(b, nodes[e.fields[3]], value(4))
+			case 'H':
+				stamp(b, branches[e.fields[2]], -value(3))
+			}
+		default:
+			return nil, fmt.Errorf("неизвестный элемент %s", e.name)
+		}
+	}
+	for i, n := range portNodes {
+		admittance(n, -1, complex(1/z0[i], 0))
+	}
+	inv, err := cmatInverse(a)
+	if err != nil {
+		return nil, err
+	}
+
+	s := cmatNew(ports, ports)
+	for j := 0; j < ports; j++ {
+		// Источник Нортона: ток 2/Z0 параллельно нагрузке Z0 эквивалентен ЭДС 2 В за Z0.
+		current := complex(2/z0[j], 0)
+		for i := 0; i < ports; i++ {
+			s[i][j] = inv[portNodes[i]][portNodes[j]] * current
+		}
+		s[j][j] -= 1
+	}
+	return s, nil
+}
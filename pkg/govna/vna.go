@@ -16,6 +16,33 @@ type VNA struct {
 	ctx         context.Context
 	cancel      context.CancelFunc
 	calibration *CalibrationProfile
+	extension   *PortExtensionSettings
+	referenceZ0 []complex128
+	sweep       SweepConfig
+	averaging   *averager
+	// corrections увеличивается при каждой смене калибровки, расширения портов или опорного
+	// импеданса: средние Averager, накопленные до смены, сбрасываются.
+	corrections uint64
+	smoothing   int
+	memory      *VNAData
+	memoryMath  MemoryOperation
+	sequence    uint64
+	// lastScan и lastMeta - результат последнего успешного сканирования до математики с памятью:
+	// его возвращает LastScan и сохраняет StoreMemory.
+	lastScan *VNAData
+	lastMeta ScanMetadata
+
+	events *EventBus
+	device string
+	// onDisconnect вызывается один раз при потере связи с устройством; disconnected - признак
+	// того, что связь потеряна или устройство закрыто.
+	onDisconnect func()
+	disconnected bool
+
+	streamMu       sync.Mutex
+	subscribers    map[*Subscription]struct{}
+	streamCancel   context.CancelFunc
+	streamSchedule ScanScheduler
 }
 
 func NewVNA(driver Driver) *VNA {
@@ -24,48 +51,353 @@ func NewVNA(driver Driver) *VNA {
 }
 
 type SweepConfig struct {
-	Start, Stop float64
-	Points      int
+	Start  float64 `json:"start"`
+	Stop   float64 `json:"stop"`
+	Points int     `json:"points"`
+	// Type - тип сетки частот; пустое значение означает SweepLinear.
+	Type SweepType `json:"type,omitempty"`
 }
 
 type VNAData struct {
 	Frequencies []float64
 	S11, S21    []complex128
+	// S12 и S22 заполняются только при полном двухпортовом измерении.
+	S12, S22 []complex128
+	// Z0 - опорные импедансы портов; nil означает DefaultReferenceImpedance.
+	Z0 []complex128
+}
+
+func (d VNAData) clone() VNAData {
+	return VNAData{
+		Frequencies: cloneFloat64Slice(d.Frequencies),
+		S11:         cloneComplexSlice(d.S11),
+		S21:         cloneComplexSlice(d.S21),
+		S12:         cloneComplexSlice(d.S12),
+		S22:         cloneComplexSlice(d.S22),
+		Z0:          cloneComplexSlice(d.Z0),
+	}
 }
 
+// SetSweep проверяет параметры по возможностям устройства (см. ValidateSweep) и передает их драйверу.
 func (v *VNA) SetSweep(config SweepConfig) error {
-	if config.Start >= config.Stop || config.Points <= 0 {
-		return errors.New("некорректные параметры сканирования")
+	if err := v.Capabilities().ValidateSweep(config); err != nil {
+		return err
 	}
 	v.mu.Lock()
 	defer v.mu.Unlock()
-	return v.driver.SetSweep(config)
+	return v.applySweepLocked(config)
 }
 
-func (v *VNA) GetData() (VNAData, error) {
+// applySweepLocked передает параметры драйверу; при их изменении усреднение начинается заново.
+// Вызывается с захваченным v.mu.
+func (v *VNA) applySweepLocked(config SweepConfig) error {
+	if err := v.driver.SetSweep(config); err != nil {
+		v.driverErrorLocked(err)
+		return err
+	}
+	if config != v.sweep && v.averaging != nil {
+		v.averaging.reset()
+	}
+	v.sweep = config
+	return nil
+}
+
+// sameGrid сообщает, задают ли параметры одну и ту же сетку частот; пустой Type равен SweepLinear.
+func (c SweepConfig) sameGrid(other SweepConfig) bool {
+	if c.Type == "" {
+		c.Type = SweepLinear
+	}
+	if other.Type == "" {
+		other.Type = SweepLinear
+	}
+	return c == other
+}
+
+// Identify опрашивает устройство и возвращает строку идентификации драйвера.
+func (v *VNA) Identify() (string, error) {
 	v.mu.Lock()
 	defer v.mu.Unlock()
+	id, err := v.driver.Identify()
+	if err != nil {
+		v.driverErrorLocked(err)
+	}
+	return id, err
+}
 
+// driverScanLocked выполняет сканирование драйвером и сообщает о потере связи с устройством.
+// Вызывается с захваченным v.mu.
+func (v *VNA) driverScanLocked() (VNAData, error) {
 	data, err := v.driver.Scan()
 	if err != nil {
-		return VNAData{}, err
+		v.driverErrorLocked(err)
+	}
+	return data, err
+}
+
+// driverErrorLocked публикует EventDeviceDisconnected, если ошибка драйвера означает потерю
+// связи с устройством (см. isDisconnectError), и сообщает об этом пулу, открывшему устройство.
+// Вызывается с захваченным v.mu.
+func (v *VNA) driverErrorLocked(err error) {
+	if v.disconnected || !isDisconnectError(err) {
+		return
+	}
+	v.disconnected = true
+	v.publishLocked(EventDeviceDisconnected, "связь с устройством потеряна: "+err.Error(), nil)
+	if v.onDisconnect != nil {
+		// Пул закрывает устройство, захватывая v.mu, поэтому вызов выполняется в отдельной горутине.
+		go v.onDisconnect()
+	}
+}
+
+// Sweep возвращает текущие параметры сканирования; нулевое значение означает, что SetSweep не вызывался.
+func (v *VNA) Sweep() SweepConfig {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.sweep
+}
+
+func (v *VNA) GetData() (VNAData, error) {
+	data, _, err := v.GetDataWithMetadata()
+	return data, err
+}
+
+// GetDataWithMetadata выполняет сканирование как GetData и дополнительно возвращает сведения
+// об устройстве, калибровке, времени сканирования и порядковый номер скана.
+func (v *VNA) GetDataWithMetadata() (VNAData, ScanMetadata, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.scanLocked()
+}
+
+// MeasureRequest - параметры одного сканирования Measure. Калибровка и обработка задаются только
+// запросом: настройки VNA (LoadCalibration, SetAveraging, SetSmoothing, трасса памяти) в Measure
+// не применяются и не меняются, поэтому одновременные клиенты с разными параметрами не влияют
+// друг на друга. Расширение портов и опорный импеданс VNA применяются.
+type MeasureRequest struct {
+	Sweep SweepConfig
+	// Calibration - профиль коррекции; nil - без калибровки. Чтобы использовать загруженный
+	// в устройство профиль, передайте Calibration().
+	Calibration *CalibrationProfile
+	// Averages - число свипов, векторно усредняемых в результате; 0 и 1 - один свип.
+	Averages int
+	// Averaging - усреднение между вызовами Measure (см. Averager): результат запроса добавляется
+	// в среднее, и возвращается среднее; nil - без усреднения между вызовами.
+	Averaging *Averager
+	// Smoothing - апертура сглаживания по точкам; 0 и 1 - без сглаживания.
+	Smoothing int
+	// Memory и MemoryMath - трасса памяти клиента и операция с ней (см. ApplyMemory).
+	Memory     *VNAData
+	MemoryMath MemoryOperation
+}
+
+func (r MeasureRequest) validate() error {
+	if r.Averages < 0 {
+		return errors.New("число усреднений не может быть отрицательным")
+	}
+	if r.Smoothing < 0 {
+		return errors.New("апертура сглаживания не может быть отрицательной")
+	}
+	if r.Calibration != nil {
+		if err := r.Calibration.Validate(); err != nil {
+			return err
+		}
+	}
+	switch r.MemoryMath {
+	case "", MemoryOff:
+	case MemoryDivide, MemorySubtract, MemoryAdd:
+		if r.Memory == nil {
+			return errors.New("трасса памяти не сохранена")
+		}
+	default:
+		return fmt.Errorf("неизвестная операция с памятью %q", r.MemoryMath)
+	}
+	return nil
+}
+
+// Measure настраивает сканирование и выполняет его с обработкой из request под одной блокировкой,
+// поэтому другая горутина не может изменить параметры между настройкой и сканированием, а все
+// свипы усреднения относятся к одному запросу. Параметры сканирования передаются драйверу, только
+// если они отличаются от текущих. ScanMetadata.Sweep - параметры, которыми получены данные.
+// LastScan после Measure возвращает данные до математики с памятью.
+func (v *VNA) Measure(ctx context.Context, request MeasureRequest) (VNAData, ScanMetadata, error) {
+	return v.measure(ctx, request, true)
+}
+
+// measure выполняет Measure; без correct калибровка, расширение портов и опорный импеданс
+// не применяются, и возвращаются сырые данные драйвера (для MeasureTwoPort).
+func (v *VNA) measure(ctx context.Context, request MeasureRequest, correct bool) (VNAData, ScanMetadata, error) {
+	if ctx == nil {
+		ctx = v.ctx
+	}
+	if err := v.Capabilities().ValidateSweep(request.Sweep); err != nil {
+		return VNAData{}, ScanMetadata{}, err
+	}
+	if err := request.validate(); err != nil {
+		return VNAData{}, ScanMetadata{}, err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return VNAData{}, ScanMetadata{}, err
+	}
+	if v.sweep.Points == 0 || !request.Sweep.sameGrid(v.sweep) {
+		if err := v.applySweepLocked(request.Sweep); err != nil {
+			return VNAData{}, ScanMetadata{}, err
+		}
+	}
+
+	meta := ScanMetadata{Device: v.Capabilities().Model, Sweep: v.sweep, Started: time.Now()}
+	if request.Calibration != nil {
+		meta.Calibration = request.Calibration.Name
+	}
+	// Первые Count свипов экспоненциальное усреднение складывает с равными весами.
+	average := &averager{config: AveragingConfig{Mode: AveragingExponential, Count: max(request.Averages, 1)}}
+	var data VNAData
+	for i := 0; i < average.config.Count; i++ {
+		if err := ctx.Err(); err != nil {
+			return VNAData{}, ScanMetadata{}, err
+		}
+		raw, err := v.driverScanLocked()
+		if err == nil && correct {
+			raw, err = v.correctLocked(raw, request.Calibration)
+		}
+		if err != nil {
+			v.publishLocked(EventSweepError, err.Error(), nil)
+			return VNAData{}, ScanMetadata{}, err
+		}
+		data = average.add(raw)
+	}
+	meta.Duration = time.Since(meta.Started)
+	if request.Averaging != nil {
+		data = request.Averaging.add(v.averagingKeyLocked(request.Calibration), data)
+	}
+
+	var err error
+	if request.Smoothing > 1 {
+		if data, err = data.Smooth(request.Smoothing); err != nil {
+			return VNAData{}, ScanMetadata{}, err
+		}
+	}
+	result := data
+	if request.Memory != nil && request.MemoryMath != MemoryOff && request.MemoryMath != "" {
+		if result, err = data.ApplyMemory(*request.Memory, request.MemoryMath); err != nil {
+			v.publishLocked(EventSweepError, err.Error(), nil)
+			return VNAData{}, ScanMetadata{}, err
+		}
+	}
+	v.recordLocked(data, &meta)
+	return result, meta, nil
+}
+
+// scanLocked выполняет сканирование и обработку данных с настройками VNA. Вызывается с захваченным v.mu.
+func (v *VNA) scanLocked() (VNAData, ScanMetadata, error) {
+	meta := ScanMetadata{Device: v.Capabilities().Model, Sweep: v.sweep, Started: time.Now()}
+	data, err := v.driverScanLocked()
+	if err != nil {
+		v.publishLocked(EventSweepError, err.Error(), nil)
+		return VNAData{}, ScanMetadata{}, err
+	}
+	meta.Duration = time.Since(meta.Started)
+	data, err = v.processLocked(data)
+	if err != nil {
+		v.publishLocked(EventSweepError, err.Error(), nil)
+		return VNAData{}, ScanMetadata{}, err
+	}
+	result := data
+	if v.memory != nil && v.memoryMath != MemoryOff && v.memoryMath != "" {
+		result, err = data.ApplyMemory(*v.memory, v.memoryMath)
+		if err != nil {
+			v.publishLocked(EventSweepError, err.Error(), nil)
+			return VNAData{}, ScanMetadata{}, err
+		}
 	}
+	if v.calibration != nil {
+		meta.Calibration = v.calibration.Name
+	}
+	v.recordLocked(data, &meta)
+	return result, meta, nil
+}
 
-	if v.calibration == nil {
-		return data, nil
+// recordLocked присваивает скану порядковый номер и сохраняет его для LastScan. Вызывается с захваченным v.mu.
+func (v *VNA) recordLocked(data VNAData, meta *ScanMetadata) {
+	v.sequence++
+	meta.Sequence = v.sequence
+	lastScan := data.clone()
+	v.lastScan, v.lastMeta = &lastScan, *meta
+}
+
+// LastScan возвращает копию результата последнего успешного сканирования без нового сканирования
+// (до математики с памятью, которая относится к отображению у клиента); false означает,
+// что сканирований еще не было.
+func (v *VNA) LastScan() (VNAData, ScanMetadata, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.lastScan == nil {
+		return VNAData{}, ScanMetadata{}, false
 	}
+	return v.lastScan.clone(), v.lastMeta, true
+}
 
-	calibrated, err := v.calibration.apply(data)
+// processLocked применяет к сырым данным цепочку коррекций с настройками VNA: калибровку,
+// расширение портов, пересчет опорного импеданса, усреднение и сглаживание. Математика
+// с памятью выполняется в scanLocked после сохранения последнего скана.
+// Вызывается с захваченным v.mu.
+func (v *VNA) processLocked(data VNAData) (VNAData, error) {
+	data, err := v.correctLocked(data, v.calibration)
 	if err != nil {
 		return VNAData{}, err
 	}
-	return calibrated, nil
+
+	if v.averaging != nil {
+		data = v.averaging.add(data)
+	}
+
+	if v.smoothing > 1 {
+		data, err = data.Smooth(v.smoothing)
+		if err != nil {
+			return VNAData{}, err
+		}
+	}
+	return data, nil
+}
+
+// correctLocked применяет калибровку profile (nil - без калибровки), расширение портов
+// и пересчет опорного импеданса. Вызывается с захваченным v.mu.
+func (v *VNA) correctLocked(data VNAData, profile *CalibrationProfile) (VNAData, error) {
+	var err error
+	if profile != nil {
+		data, err = profile.apply(data)
+		if err != nil {
+			return VNAData{}, err
+		}
+	}
+
+	if v.extension != nil {
+		data = v.extension.Apply(data)
+	}
+
+	if v.referenceZ0 != nil {
+		z0 := v.referenceZ0
+		// Без S12/S22 порт 2 пересчитать нельзя, поэтому одно значение относится только к порту 1.
+		if len(z0) == 1 && len(data.S21) == len(data.S11) && !data.fullTwoPort() {
+			z0 = []complex128{z0[0], data.ReferenceImpedance(2)}
+		}
+		data, err = data.Renormalize(z0...)
+		if err != nil {
+			return VNAData{}, err
+		}
+	}
+	return data, nil
 }
 
 func (v *VNA) Close() error {
 	v.cancel()
 	v.mu.Lock()
 	defer v.mu.Unlock()
+	if !v.disconnected {
+		v.disconnected = true
+		v.publishLocked(EventDeviceDisconnected, "устройство закрыто", nil)
+	}
 	return v.driver.Close()
 }
 
@@ -79,14 +411,92 @@ func (v *VNA) LoadCalibration(profile *CalibrationProfile) error {
 
 	v.mu.Lock()
 	defer v.mu.Unlock()
-	v.calibration = profile
+	v.setCalibrationLocked(profile)
 	return nil
 }
 
 func (v *VNA) ClearCalibration() {
 	v.mu.Lock()
 	defer v.mu.Unlock()
-	v.calibration = nil
+	v.setCalibrationLocked(nil)
+}
+
+// setCalibrationLocked заменяет калибровочный профиль и сообщает об изменении в шину событий.
+// Вызывается с захваченным v.mu.
+func (v *VNA) setCalibrationLocked(profile *CalibrationProfile) {
+	changed := v.calibration != profile
+	v.calibration = profile
+	v.resetAveragingLocked()
+	if changed {
+		message, data := calibrationEvent(profile)
+		v.publishLocked(EventCalibrationChanged, message, data)
+	}
+}
+
+// Calibration возвращает загруженный калибровочный профиль или nil.
+func (v *VNA) Calibration() *CalibrationProfile {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.calibration
+}
+
+// SetPortExtension включает расширение портов, применяемое после калибровки в GetData.
+func (v *VNA) SetPortExtension(settings PortExtensionSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.extension = &settings
+	v.resetAveragingLocked()
+	return nil
+}
+
+func (v *VNA) ClearPortExtension() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.extension = nil
+	v.resetAveragingLocked()
+}
+
+// PortExtension возвращает текущие настройки расширения портов и признак их активности.
+func (v *VNA) PortExtension() (PortExtensionSettings, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.extension == nil {
+		return PortExtensionSettings{}, false
+	}
+	return *v.extension, true
+}
+
+// SetReferenceImpedance задает опорные импедансы, к которым GetData пересчитывает данные.
+// Одно значение относится ко всем портам, два - к портам 1 и 2. Для данных без S12/S22 одно
+// значение меняет только порт 1, а два значения с другим импедансом порта 2 приводят к ошибке
+// GetData (см. VNAData.Renormalize).
+func (v *VNA) SetReferenceImpedance(z0 ...complex128) error {
+	if len(z0) == 0 || len(z0) > 2 {
+		return errors.New("требуется один или два опорных импеданса")
+	}
+	for i, z := range z0 {
+		if real(z) <= 0 {
+			return fmt.Errorf("опорный импеданс порта %d должен иметь положительную действительную часть", i+1)
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.referenceZ0 = cloneComplexSlice(z0)
+	v.resetAveragingLocked()
+	return nil
+}
+
+// ClearReferenceImpedance возвращает данные к собственному опорному импедансу прибора.
+func (v *VNA) ClearReferenceImpedance() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.referenceZ0 = nil
+	v.resetAveragingLocked()
 }
 
 func (v *VNA) ApplyCalibration(data VNAData) (VNAData, error) {
@@ -101,28 +511,61 @@ func (v *VNA) ApplyCalibration(data VNAData) (VNAData, error) {
 	return profile.apply(data)
 }
 
-func (d *VNAData) ToTouchstone() string {
+// ToTouchstone формирует файл Touchstone 1.x. При наличии S12 и S22 строки данных имеют
+// стандартный для .s2p порядок f S11 S21 S12 S22, без них - f S11 S21 (1.5-порт, как у NanoVNA),
+// без S21 - f S11 (.s1p). Строка параметров формата содержит один действительный опорный
+// импеданс, поэтому для комплексного или различного по портам Z0 возвращается ошибка: такие
+// данные сначала пересчитайте к общему действительному импедансу (Renormalize).
+func (d *VNAData) ToTouchstone() (string, error) {
+	columns := [][]complex128{d.S11}
+	if len(d.S21) == len(d.Frequencies) {
+		columns = append(columns, d.S21)
+		if len(d.S12) == len(d.Frequencies) && len(d.S22) == len(d.Frequencies) {
+			columns = append(columns, d.S12, d.S22)
+		}
+	}
+	z1, z2 := d.ReferenceImpedance(1), d.ReferenceImpedance(2)
+	if imag(z1) != 0 || (len(columns) > 1 && z1 != z2) {
+		return "", fmt.Errorf("Touchstone 1.x допускает один действительный опорный импеданс, а у данных порт 1 %s Ом, порт 2 %s Ом",
+			formatImpedance(z1), formatImpedance(z2))
+	}
+
 	var sb strings.Builder
 	sb.WriteString("! GoVNA Data Export\n")
 	sb.WriteString("! Date: " + time.Now().Format(time.RFC3339) + "\n")
-	sb.WriteString("# Hz S RI R 50\n")
+	sb.WriteString(fmt.Sprintf("# Hz S RI R %g\n", real(z1)))
 	for i := range d.Frequencies {
-		sb.WriteString(fmt.Sprintf("%.6f %.6f %.6f %.6f %.6f\n",
-			d.Frequencies[i], real(d.S11[i]), imag(d.S11[i]),
-			real(d.S21[i]), imag(d.S21[i])))
+		sb.WriteString(fmt.Sprintf("%.6f", d.Frequencies[i]))
+		for _, column := range columns {
+			sb.WriteString(fmt.Sprintf(" %.6f %.6f", real(column[i]), imag(column[i])))
+		}
+		sb.WriteByte('\n')
 	}
-	return sb.String()
+	return sb.String(), nil
+}
+
+func formatImpedance(z complex128) string {
+	if imag(z) == 0 {
+		return fmt.Sprintf("%g", real(z))
+	}
+	return fmt.Sprintf("%g%+gj", real(z), imag(z))
+}
+
+// CalculateVSWRAt вычисляет КСВ относительно импеданса z0, отличного от опорного импеданса данных.
+// КСВ зависит только от S11, поэтому пересчитывается лишь порт 1, и для данных 1.5-порта
+// S12/S22 не требуются.
+func (d *VNAData) CalculateVSWRAt(z0 complex128) ([]float64, error) {
+	renormalized, err := d.Renormalize(z0, d.ReferenceImpedance(2))
+	if err != nil {
+		return nil, err
+	}
+	return renormalized.CalculateVSWR(), nil
 }
 
 func (d *VNAData) CalculateVSWR() []float64 {
 	vswr := make([]float64, len(d.S11))
 	for i, s11 := range d.S11 {
-		gamma := cmplx.Abs(s11)
-		if gamma >= 1.0 {
-			vswr[i] = 9999.0 // Практически бесконечное значение
-		} else {
-			vswr[i] = (1 + gamma) / (1 - gamma)
-		}
+		vswr[i] = vswrFromGamma(cmplx.Abs(s11))
 	}
 	return vswr
 }
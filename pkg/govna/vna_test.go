@@ -4,14 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/cmplx"
+	"slices"
 	"strings"
 
 	//	"errors"
 	//	"fmt"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 	//"github.com/momentics/govna/internal/util"
@@ -209,12 +214,101 @@ func TestVNAData_ToTouchstonePrecision(t *testing.T) {
 		S21:         []complex128{complex(0.1, -0.1)},
 	}
 
-	touchstone := data.ToTouchstone()
+	touchstone, err := data.ToTouchstone()
+	if err != nil {
+		t.Fatalf("ToTouchstone failed: %v", err)
+	}
 	if !strings.Contains(touchstone, "1234567.890000") {
 		t.Fatalf("expected frequency to retain fractional part, got %s", touchstone)
 	}
 }
 
+func TestVNAData_ToTouchstoneTwoPortRoundTrip(t *testing.T) {
+	data := VNAData{
+		Frequencies: []float64{1e6, 2e6, 3e6},
+		S11:         []complex128{complex(0.5, -0.5), complex(0.4, -0.3), complex(0.3, -0.2)},
+		S21:         []complex128{complex(0.1, -0.1), complex(0.2, -0.15), complex(0.25, -0.2)},
+		S12:         []complex128{complex(0.11, -0.12), complex(0.21, -0.16), complex(0.26, -0.21)},
+		S22:         []complex128{complex(-0.3, 0.2), complex(-0.2, 0.1), complex(-0.1, 0.05)},
+		Z0:          []complex128{75, 75},
+	}
+
+	touchstone, err := data.ToTouchstone()
+	if err != nil {
+		t.Fatalf("ToTouchstone failed: %v", err)
+	}
+	if !strings.Contains(touchstone, "1000000.000000 0.500000 -0.500000 0.100000 -0.100000 0.110000 -0.120000 -0.300000 0.200000\n") {
+		t.Fatalf("expected f S11 S21 S12 S22 row, got %s", touchstone)
+	}
+	decoded, err := ReadTouchstone(strings.NewReader(touchstone))
+	if err != nil {
+		t.Fatalf("ReadTouchstone failed: %v", err)
+	}
+	if decoded.ReferenceImpedance(1) != 75 || decoded.ReferenceImpedance(2) != 75 {
+		t.Fatalf("reference impedance lost: %v", decoded.Z0)
+	}
+	pairs := []struct {
+		name      string
+		want, got []complex128
+	}{
+		{"S11", data.S11, decoded.S11},
+		{"S21", data.S21, decoded.S21},
+		{"S12", data.S12, decoded.S12},
+		{"S22", data.S22, decoded.S22},
+	}
+	for _, pair := range pairs {
+		if len(pair.got) != len(pair.want) {
+			t.Fatalf("%s: expected %d points, got %d", pair.name, len(pair.want), len(pair.got))
+		}
+		for i := range pair.want {
+			if cmplx.Abs(pair.got[i]-pair.want[i]) > 1e-6 {
+				t.Fatalf("%s[%d]: expected %v, got %v", pair.name, i, pair.want[i], pair.got[i])
+			}
+		}
+	}
+	for i := range data.Frequencies {
+		if decoded.Frequencies[i] != data.Frequencies[i] {
+			t.Fatalf("frequency %d: expected %g, got %g", i, data.Frequencies[i], decoded.Frequencies[i])
+		}
+	}
+}
+
+func TestVNAData_ToTouchstoneOnePort(t *testing.T) {
+	data := VNAData{
+		Frequencies: []float64{1e6},
+		S11:         []complex128{complex(0.5, -0.5)},
+	}
+
+	touchstone, err := data.ToTouchstone()
+	if err != nil {
+		t.Fatalf("ToTouchstone failed: %v", err)
+	}
+	decoded, err := ReadTouchstone(strings.NewReader(touchstone))
+	if err != nil {
+		t.Fatalf("ReadTouchstone failed: %v", err)
+	}
+	if len(decoded.S11) != 1 || decoded.S21 != nil || decoded.S12 != nil || decoded.S22 != nil {
+		t.Fatalf("expected one-port data, got %+v", decoded)
+	}
+}
+
+func TestReadTouchstone_MagnitudeAngle(t *testing.T) {
+	input := "! comment\n# MHz S DB R 50\n1 -6.0206 90 0 0 0 0 -20 180\n"
+	data, err := ReadTouchstone(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadTouchstone failed: %v", err)
+	}
+	if data.Frequencies[0] != 1e6 || data.Z0 != nil {
+		t.Fatalf("unexpected frequency or reference impedance: %v %v", data.Frequencies, data.Z0)
+	}
+	if cmplx.Abs(data.S11[0]-complex(0, 0.5)) > 1e-4 || cmplx.Abs(data.S22[0]-complex(-0.1, 0)) > 1e-9 {
+		t.Fatalf("unexpected values: S11=%v S22=%v", data.S11[0], data.S22[0])
+	}
+	if _, err := ReadTouchstone(strings.NewReader("# Hz S RI R 50\n1 0 0 0\n")); err == nil {
+		t.Fatalf("expected error for malformed row")
+	}
+}
+
 func float32ToBytes(f float32) []byte {
 	var buf [4]byte
 	binary.LittleEndian.PutUint32(buf[:], math.Float32bits(f))
@@ -338,9 +432,1094 @@ func TestVNA_CalibrationWorkflow(t *testing.T) {
 	}
 }
 
+func TestCalibrationSession_StepwiseAndJSON(t *testing.T) {
+	freq := []float64{1e9}
+	e00 := complex(0.05, -0.01)
+	e11 := complex(0.92, 0.02)
+	tracking := complex(0.12, -0.03)
+	unknownGamma := complex(0.3, -0.1)
+
+	driver := newStubDriver([]VNAData{
+		{Frequencies: freq, S11: []complex128{applyThreeTermErrorModel(e00, e11, tracking, 1)}},
+		{Frequencies: freq, S11: []complex128{applyThreeTermErrorModel(e00, e11, tracking, -1)}},
+		{Frequencies: freq, S11: []complex128{applyThreeTermErrorModel(e00, e11, tracking, 0)}},
+		{Frequencies: freq, S11: []complex128{applyThreeTermErrorModel(e00, e11, tracking, unknownGamma)}},
+	})
+	vna := NewVNA(driver)
+
+	session, err := vna.StartCalibration(CalibrationPlan{
+		Name:  "stepwise",
+		Sweep: SweepConfig{Start: 1e9, Stop: 1e9 + 1, Points: 1},
+		Steps: []CalibrationStep{
+			{Standard: CalibrationStandardOpen},
+			{Standard: CalibrationStandardShort},
+			{Standard: CalibrationStandardLoad},
+		},
+	})
+	if err != nil {
+		t.Fatalf("StartCalibration failed: %v", err)
+	}
+	for _, expected := range []CalibrationStandard{CalibrationStandardOpen, CalibrationStandardShort, CalibrationStandardLoad} {
+		if _, err := session.Finish(); err == nil {
+			t.Fatalf("expected Finish to fail before %s is measured", expected)
+		}
+		step, ok := session.Next()
+		if !ok || step.Standard != expected {
+			t.Fatalf("expected next standard %s, got %v (ok=%v)", expected, step.Standard, ok)
+		}
+		if err := session.Measure(context.Background()); err != nil {
+			t.Fatalf("Measure(%s) failed: %v", expected, err)
+		}
+	}
+	if _, ok := session.Next(); ok || session.Completed() != 3 {
+		t.Fatalf("expected all 3 steps completed, got %d", session.Completed())
+	}
+	profile, err := session.Finish()
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if vna.Calibration() != nil {
+		t.Fatalf("session must not activate the profile")
+	}
+
+	var buf bytes.Buffer
+	if err := profile.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	loaded, err := LoadCalibrationJSON(&buf)
+	if err != nil {
+		t.Fatalf("LoadCalibrationJSON failed: %v", err)
+	}
+	if loaded.Name != "stepwise" || len(loaded.Standards) != 3 {
+		t.Fatalf("unexpected loaded profile: %s with %d standards", loaded.Name, len(loaded.Standards))
+	}
+	if err := vna.LoadCalibration(loaded); err != nil {
+		t.Fatalf("LoadCalibration failed: %v", err)
+	}
+	data, err := vna.GetData()
+	if err != nil {
+		t.Fatalf("GetData failed: %v", err)
+	}
+	if cmplx.Abs(data.S11[0]-unknownGamma) > 1e-6 {
+		t.Fatalf("expected calibrated gamma %v, got %v", unknownGamma, data.S11[0])
+	}
+}
+
 func TestVNA_ApplyCalibrationWithoutProfile(t *testing.T) {
 	vna := NewVNA(newStubDriver(nil))
 	if _, err := vna.ApplyCalibration(VNAData{}); err == nil {
 		t.Fatalf("expected error when applying calibration without profile")
 	}
 }
+
+func TestAutoPortExtension_RecoversDelayAndLoss(t *testing.T) {
+	const delay = 1.5e-9
+	const lossDC = 0.1
+	const lossSlope = 2e-5 // дБ/sqrt(Гц)
+
+	freqs := make([]float64, 51)
+	s11 := make([]complex128, len(freqs))
+	for i := range freqs {
+		freqs[i] = 10e6 + float64(i)*10e6
+		oneWay := lossDC + lossSlope*math.Sqrt(freqs[i])
+		s11[i] = cmplx.Rect(math.Pow(10, -2*oneWay/20), -2*2*math.Pi*freqs[i]*delay)
+	}
+
+	ext, err := AutoPortExtension(VNAData{Frequencies: freqs, S11: s11}, CalibrationStandardOpen)
+	if err != nil {
+		t.Fatalf("AutoPortExtension failed: %v", err)
+	}
+	if math.Abs(ext.Delay-delay) > 1e-13 {
+		t.Fatalf("expected delay %g, got %g", delay, ext.Delay)
+	}
+	if math.Abs(ext.LossDC-lossDC) > 1e-6 {
+		t.Fatalf("expected DC loss %g, got %g", lossDC, ext.LossDC)
+	}
+
+	driver := newStubDriver([]VNAData{{Frequencies: freqs, S11: s11}})
+	vna := NewVNA(driver)
+	if err := vna.SetPortExtension(PortExtensionSettings{Port1: ext}); err != nil {
+		t.Fatalf("SetPortExtension failed: %v", err)
+	}
+	data, err := vna.GetData()
+	if err != nil {
+		t.Fatalf("GetData failed: %v", err)
+	}
+	for i, gamma := range data.S11 {
+		if cmplx.Abs(gamma-1) > 1e-6 {
+			t.Fatalf("expected ideal open at point %d after port extension, got %v", i, gamma)
+		}
+	}
+}
+
+func TestPortExtensionFromLength(t *testing.T) {
+	ext, err := PortExtensionFromLength(0.5, 0.66)
+	if err != nil {
+		t.Fatalf("PortExtensionFromLength failed: %v", err)
+	}
+	if want := 0.5 / (SpeedOfLight * 0.66); math.Abs(ext.Delay-want) > 1e-18 {
+		t.Fatalf("expected delay %g, got %g", want, ext.Delay)
+	}
+	if length := ext.Length(0.66); math.Abs(length-0.5) > 1e-12 {
+		t.Fatalf("expected length 0.5 m at the same velocity factor, got %g", length)
+	}
+	if length := ext.Length(1); math.Abs(length-0.5/0.66) > 1e-12 {
+		t.Fatalf("expected electrical length %g m in vacuum, got %g", 0.5/0.66, length)
+	}
+	if _, err := PortExtensionFromLength(0.5, 0); err == nil {
+		t.Fatalf("expected error for zero velocity factor")
+	}
+}
+
+func TestVNAData_Renormalize(t *testing.T) {
+	load := VNAData{Frequencies: []float64{1e6}, S11: []complex128{complex(0.2, 0)}, S21: []complex128{0}}
+	renormalized, err := load.Renormalize(75, 50)
+	if err != nil {
+		t.Fatalf("Renormalize failed: %v", err)
+	}
+	if cmplx.Abs(renormalized.S11[0]) > 1e-12 {
+		t.Fatalf("expected 75 Ω load to be matched at 75 Ω, got %v", renormalized.S11[0])
+	}
+	// Опорные импедансы портов различаются, и строка параметров Touchstone не может их описать.
+	if _, err := renormalized.ToTouchstone(); err == nil {
+		t.Fatalf("expected ToTouchstone error for different port impedances")
+	}
+	onePort := VNAData{Frequencies: renormalized.Frequencies, S11: renormalized.S11, Z0: renormalized.Z0}
+	if touchstone, err := onePort.ToTouchstone(); err != nil || !strings.Contains(touchstone, "# Hz S RI R 75\n") {
+		t.Fatalf("expected Touchstone option line with R 75, got %q, %v", touchstone, err)
+	}
+	complexZ0 := VNAData{Frequencies: load.Frequencies, S11: load.S11, Z0: []complex128{complex(50, 5)}}
+	if _, err := complexZ0.ToTouchstone(); err == nil {
+		t.Fatalf("expected ToTouchstone error for a complex reference impedance")
+	}
+
+	// Последовательный импеданс 20+10j Ом между портами 50 Ом.
+	z := complex(20, 10)
+	twoPort := VNAData{
+		Frequencies: []float64{1e6},
+		S11:         []complex128{z / (z + 100)},
+		S21:         []complex128{100 / (z + 100)},
+		S12:         []complex128{100 / (z + 100)},
+		S22:         []complex128{z / (z + 100)},
+	}
+	there, err := twoPort.Renormalize(complex(75, 5), 60)
+	if err != nil {
+		t.Fatalf("Renormalize failed: %v", err)
+	}
+	back, err := there.Renormalize(50)
+	if err != nil {
+		t.Fatalf("Renormalize back failed: %v", err)
+	}
+	if cmplx.Abs(back.S11[0]-twoPort.S11[0]) > 1e-12 || cmplx.Abs(back.S21[0]-twoPort.S21[0]) > 1e-12 {
+		t.Fatalf("round-trip renormalization mismatch: %v %v", back.S11[0], back.S21[0])
+	}
+}
+
+func TestVNAData_RenormalizeHalfTwoPort(t *testing.T) {
+	// Несимметричная цепь: последовательный резистор 30 Ом и параллельный резистор 100 Ом у порта 2.
+	full := VNAData{Frequencies: []float64{1e6}}
+	z := [][]complex128{{130, 100}, {100, 100}}
+	s, err := ZToS(z, []complex128{50, 50})
+	if err != nil {
+		t.Fatalf("ZToS failed: %v", err)
+	}
+	full.S11, full.S21 = []complex128{s[0][0]}, []complex128{s[1][0]}
+	full.S12, full.S22 = []complex128{s[0][1]}, []complex128{s[1][1]}
+	half := VNAData{Frequencies: full.Frequencies, S11: full.S11, S21: full.S21}
+
+	if _, err := half.Renormalize(75); err == nil {
+		t.Fatalf("expected error when renormalizing port 2 without S12/S22")
+	}
+	if _, err := half.RenormalizeReciprocal(75); err != nil {
+		t.Fatalf("RenormalizeReciprocal failed: %v", err)
+	}
+
+	// Если импеданс порта 2 не меняется, S12 и S22 не нужны.
+	want, err := full.Renormalize(75, 50)
+	if err != nil {
+		t.Fatalf("Renormalize failed: %v", err)
+	}
+	got, err := half.Renormalize(75, 50)
+	if err != nil {
+		t.Fatalf("Renormalize of 1.5-port data failed: %v", err)
+	}
+	if cmplx.Abs(got.S11[0]-want.S11[0]) > 1e-12 || cmplx.Abs(got.S21[0]-want.S21[0]) > 1e-12 {
+		t.Fatalf("1.5-port renormalization mismatch: got %v %v, want %v %v", got.S11[0], got.S21[0], want.S11[0], want.S21[0])
+	}
+	if got.S12 != nil || got.S22 != nil {
+		t.Fatalf("expected S12/S22 to stay absent, got %v %v", got.S12, got.S22)
+	}
+
+	// КСВ зависит только от S11, поэтому S12/S22 для него не нужны.
+	vswr, err := half.CalculateVSWRAt(75)
+	if err != nil {
+		t.Fatalf("CalculateVSWRAt of 1.5-port data failed: %v", err)
+	}
+	if wantVSWR := want.CalculateVSWR(); math.Abs(vswr[0]-wantVSWR[0]) > 1e-12 {
+		t.Fatalf("expected VSWR %g at 75 Ω, got %g", wantVSWR[0], vswr[0])
+	}
+
+	// Одно значение опорного импеданса прибора без S12/S22 относится только к порту 1.
+	vna := NewVNA(newStubDriver([]VNAData{half}))
+	if err := vna.SetReferenceImpedance(75); err != nil {
+		t.Fatalf("SetReferenceImpedance failed: %v", err)
+	}
+	data, err := vna.GetData()
+	if err != nil {
+		t.Fatalf("GetData of 1.5-port data at 75 Ω failed: %v", err)
+	}
+	if cmplx.Abs(data.S11[0]-want.S11[0]) > 1e-12 || data.ReferenceImpedance(1) != 75 || data.ReferenceImpedance(2) != 50 {
+		t.Fatalf("expected port 1 renormalized to 75 Ω, got S11 %v, Z0 %v", data.S11[0], data.Z0)
+	}
+}
+
+func TestVNAData_DerivedTraces(t *testing.T) {
+	const inductance = 100e-9
+	const resistance = 10.0
+	freqs := []float64{10e6, 20e6, 30e6, 40e6}
+	s11 := make([]complex128, len(freqs))
+	s21 := make([]complex128, len(freqs))
+	const delay = 2e-9
+	for i, f := range freqs {
+		z := complex(resistance, 2*math.Pi*f*inductance)
+		s11[i] = (z - 50) / (z + 50)
+		s21[i] = cmplx.Rect(0.5, -2*math.Pi*f*delay)
+	}
+	data := VNAData{Frequencies: freqs, S11: s11, S21: s21}
+
+	ls, err := data.Trace(TraceSpec{Parameter: S11, Format: FormatSeriesL})
+	if err != nil {
+		t.Fatalf("Trace failed: %v", err)
+	}
+	rs, _ := data.Trace(TraceSpec{Parameter: S11, Format: FormatSeriesR})
+	for i := range freqs {
+		if math.Abs(ls[i]-inductance) > 1e-15 || math.Abs(rs[i]-resistance) > 1e-9 {
+			t.Fatalf("point %d: expected Ls=%g Rs=%g, got %g %g", i, inductance, resistance, ls[i], rs[i])
+		}
+	}
+
+	gd, err := data.Trace(TraceSpec{Parameter: S21, Format: FormatGroupDelay, Aperture: 2})
+	if err != nil {
+		t.Fatalf("group delay failed: %v", err)
+	}
+	for i, v := range gd {
+		if math.Abs(v-delay) > 1e-15 {
+			t.Fatalf("point %d: expected group delay %g, got %g", i, delay, v)
+		}
+	}
+
+	loss, _ := data.Trace(TraceSpec{Parameter: S21, Format: FormatLogMag})
+	if math.Abs(loss[0]+6.0206) > 1e-3 {
+		t.Fatalf("expected -6.02 dB, got %g", loss[0])
+	}
+}
+
+func TestVNA_AveragingAndMemory(t *testing.T) {
+	freq := []float64{1e6, 2e6, 3e6}
+	sweep := func(v complex128) VNAData {
+		return VNAData{Frequencies: freq, S11: []complex128{v, v, v}, S21: []complex128{2 * v, 2 * v, 2 * v}}
+	}
+	driver := newStubDriver([]VNAData{sweep(1), sweep(3), sweep(5), sweep(7), sweep(2)})
+	vna := NewVNA(driver)
+	if err := vna.SetSweep(SweepConfig{Start: 1e6, Stop: 3e6, Points: 3}); err != nil {
+		t.Fatalf("SetSweep failed: %v", err)
+	}
+	if err := vna.SetAveraging(AveragingConfig{Mode: AveragingRunning, Count: 2}); err != nil {
+		t.Fatalf("SetAveraging failed: %v", err)
+	}
+
+	expected := []complex128{1, 2, 4, 6}
+	for i, want := range expected {
+		data, err := vna.GetData()
+		if err != nil {
+			t.Fatalf("GetData failed: %v", err)
+		}
+		if data.S11[1] != want || data.S21[1] != 2*want {
+			t.Fatalf("sweep %d: expected running average %v, got %v", i, want, data.S11[1])
+		}
+	}
+
+	if err := vna.StoreMemory(); err != nil {
+		t.Fatalf("StoreMemory failed: %v", err)
+	}
+	if err := vna.SetMemoryMath(MemoryDivide); err != nil {
+		t.Fatalf("SetMemoryMath failed: %v", err)
+	}
+	// Смена свипа сбрасывает усреднение, поэтому следующий результат - сырые данные, деленные на память.
+	if err := vna.SetSweep(SweepConfig{Start: 1e6, Stop: 3e6, Points: 3}); err != nil {
+		t.Fatalf("SetSweep failed: %v", err)
+	}
+	if vna.AveragedSweeps() != 2 {
+		t.Fatalf("expected averaging to survive an unchanged sweep")
+	}
+	if err := vna.SetSweep(SweepConfig{Start: 1e6, Stop: 3.5e6, Points: 3}); err != nil {
+		t.Fatalf("SetSweep failed: %v", err)
+	}
+	data, err := vna.GetData()
+	if err != nil {
+		t.Fatalf("GetData failed: %v", err)
+	}
+	if cmplx.Abs(data.S11[0]-complex(2.0/6.0, 0)) > 1e-12 {
+		t.Fatalf("expected data/memory 1/3, got %v", data.S11[0])
+	}
+	if last, _, ok := vna.LastScan(); !ok || last.S11[0] != 2 {
+		t.Fatalf("expected last scan before memory math to be 2, got %v", last.S11)
+	}
+}
+
+func TestVNAData_Smooth(t *testing.T) {
+	data := VNAData{Frequencies: []float64{1, 2, 3, 4, 5}, S11: []complex128{0, 3, 0, 3, 0}}
+	smoothed, err := data.Smooth(3)
+	if err != nil {
+		t.Fatalf("Smooth failed: %v", err)
+	}
+	want := []complex128{0, 1, 2, 1, 0}
+	for i := range want {
+		if cmplx.Abs(smoothed.S11[i]-want[i]) > 1e-12 {
+			t.Fatalf("point %d: expected %v, got %v", i, want[i], smoothed.S11[i])
+		}
+	}
+}
+
+func applyTwelveTermErrorModel(ed, er, es, el, et, s11, s21, s12, s22 complex128) (complex128, complex128) {
+	delta := s11*s22 - s21*s12
+	denominator := 1 - es*s11 - el*s22 + es*el*delta
+	return ed + er*(s11-el*delta)/denominator, et * s21 / denominator
+}
+
+func TestVNA_MeasureTwoPort(t *testing.T) {
+	freq := []float64{1e9}
+	ed, er, es := complex(0.05, -0.01), complex(0.92, 0.02), complex(0.12, -0.03)
+	el, et := complex(-0.08, 0.05), complex(0.85, -0.1)
+	s11, s21, s22 := complex(0.2, 0.1), complex(0.7, -0.3), complex(-0.35, 0.05)
+
+	scan := func(a, b, c, d complex128) VNAData {
+		reflection, transmission := applyTwelveTermErrorModel(ed, er, es, el, et, a, b, c, d)
+		return VNAData{Frequencies: freq, S11: []complex128{reflection}, S21: []complex128{transmission}}
+	}
+	thru := scan(0, 1, 1, 0)
+	driver := newStubDriver([]VNAData{
+		scan(1, 0, 0, 0),
+		scan(-1, 0, 0, 0),
+		scan(0, 0, 0, 0),
+		thru,
+		scan(s11, s21, s21, s22),
+		scan(s22, s21, s21, s11),
+		scan(s11, s21, s21, s22),
+		scan(s11, s21, s21, s22),
+	})
+	vna := NewVNA(driver)
+
+	plan := CalibrationPlan{
+		Name:  "two-port",
+		Sweep: SweepConfig{Start: 1e9, Stop: 1e9 + 1, Points: 1},
+		Steps: []CalibrationStep{
+			{Standard: CalibrationStandardOpen},
+			{Standard: CalibrationStandardShort},
+			{Standard: CalibrationStandardLoad},
+			{Standard: CalibrationStandardThru},
+		},
+	}
+	if _, err := vna.AcquireCalibration(context.Background(), plan, nil); err != nil {
+		t.Fatalf("AcquireCalibration failed: %v", err)
+	}
+
+	var steps []ReversalStep
+	prompt := func(ctx context.Context, step ReversalStep) error {
+		steps = append(steps, step)
+		// Другой клиент меняет сетку, пока оператор переворачивает ИУ.
+		if step == ReversalReverse {
+			return vna.SetSweep(SweepConfig{Start: 2e9, Stop: 3e9, Points: 1})
+		}
+		return nil
+	}
+	_, before, _ := vna.LastScan()
+	measurement, err := vna.MeasureTwoPort(context.Background(), prompt, TwoPortOptions{})
+	if err != nil {
+		t.Fatalf("MeasureTwoPort failed: %v", err)
+	}
+	if len(steps) != 2 || steps[0] != ReversalForward || steps[1] != ReversalReverse {
+		t.Fatalf("unexpected prompt sequence %v", steps)
+	}
+	if _, after, ok := vna.LastScan(); !ok || after.Sequence != before.Sequence+2 || after.Sweep != plan.Sweep {
+		t.Fatalf("expected two recorded scans on the calibration grid, got %+v after %+v", after, before)
+	}
+	if measurement.Correction != TwoPortCorrectionFull {
+		t.Fatalf("expected full correction, got %s", measurement.Correction)
+	}
+	expected := map[string][2]complex128{
+		"S11": {measurement.Data.S11[0], s11},
+		"S21": {measurement.Data.S21[0], s21},
+		"S12": {measurement.Data.S12[0], s21},
+		"S22": {measurement.Data.S22[0], s22},
+	}
+	for name, pair := range expected {
+		if cmplx.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Fatalf("%s: expected %v, got %v", name, pair[1], pair[0])
+		}
+	}
+	if !measurement.Check.Reversed || len(measurement.Check.Warnings) != 0 {
+		t.Fatalf("unexpected reversal check %+v", measurement.Check)
+	}
+
+	if _, err := vna.MeasureTwoPort(context.Background(), nil, TwoPortOptions{}); err == nil {
+		t.Fatalf("expected error when DUT was not reversed")
+	}
+}
+
+func TestDeviceCapabilities_ValidateSweep(t *testing.T) {
+	caps := (&V1Driver{}).Capabilities()
+	cases := []struct {
+		config SweepConfig
+		field  string
+	}{
+		{SweepConfig{Start: 1e6, Stop: 900e6, Points: 101}, ""},
+		{SweepConfig{Start: 10e3, Stop: 900e6, Points: 101}, "start"},
+		{SweepConfig{Start: 1e6, Stop: 1.5e9, Points: 101}, "stop"},
+		{SweepConfig{Start: 1e6, Stop: 900e6, Points: 401}, "points"},
+		{SweepConfig{Start: 1e6, Stop: 900e6, Points: 101, Type: "log"}, "type"},
+	}
+	for _, c := range cases {
+		err := caps.ValidateSweep(c.config)
+		var sweepErr *SweepError
+		switch {
+		case c.field == "" && err != nil:
+			t.Fatalf("%+v: unexpected error %v", c.config, err)
+		case c.field != "" && (!errors.As(err, &sweepErr) || sweepErr.Field != c.field):
+			t.Fatalf("%+v: expected error for field %s, got %v", c.config, c.field, err)
+		}
+	}
+
+	vna := NewVNA(&V1Driver{})
+	if err := vna.SetSweep(SweepConfig{Start: 1e6, Stop: 3e9, Points: 101}); err == nil {
+		t.Fatalf("expected SetSweep to reject sweep outside device range")
+	}
+}
+
+func TestVNAData_ExportFormats(t *testing.T) {
+	data := VNAData{
+		Frequencies: []float64{1e6, 2e6},
+		S11:         []complex128{complex(0.5, -0.25), 0},
+		S21:         []complex128{complex(0.1, 0.2), complex(-0.3, 0.4)},
+	}
+	meta := ScanMetadata{Device: "stub", Calibration: "cal", Started: time.Unix(1700000000, 5), Duration: 42 * time.Millisecond, Sequence: 7}
+
+	var buf bytes.Buffer
+	if err := data.WriteBinary(&buf, meta); err != nil {
+		t.Fatalf("WriteBinary failed: %v", err)
+	}
+	if expected := 4 + 2 + 4 + 8 + 8 + 8 + 32 + 2 + 4 + 2 + 3 + 2*(8+16); buf.Len() != expected {
+		t.Fatalf("expected %d bytes, got %d", expected, buf.Len())
+	}
+	decoded, decodedMeta, err := ReadBinary(&buf)
+	if err != nil {
+		t.Fatalf("ReadBinary failed: %v", err)
+	}
+	if decodedMeta.Sequence != 7 || decodedMeta.Device != "stub" || decodedMeta.Calibration != "cal" ||
+		!decodedMeta.Started.Equal(meta.Started) || decodedMeta.Duration != meta.Duration {
+		t.Fatalf("unexpected metadata %+v", decodedMeta)
+	}
+	if decoded.S12 != nil || cmplx.Abs(decoded.S21[1]-data.S21[1]) > 1e-7 || decoded.Frequencies[1] != 2e6 {
+		t.Fatalf("unexpected decoded data %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := data.WriteJSON(&buf, meta); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var parsed struct {
+		Metadata ScanMetadata `json:"metadata"`
+		S11      [][2]*float64
+		Traces   []struct {
+			Parameter SParameter
+			Format    TraceFormat
+			Values    []*float64
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if parsed.Metadata.Sequence != 7 || *parsed.S11[0][1] != -0.25 || len(parsed.Traces) != len(DefaultExportTraces) {
+		t.Fatalf("unexpected JSON %s", buf.String())
+	}
+	if parsed.Traces[0].Values[1] != nil {
+		t.Fatalf("expected null for logmag of zero reflection, got %v", *parsed.Traces[0].Values[1])
+	}
+
+	buf.Reset()
+	if err := data.WriteCSV(&buf, meta, TraceSpec{Parameter: S21, Format: FormatLinMag}); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[len(lines)-3] != "frequency,S11_re,S11_im,S21_re,S21_im,S21_linmag" || lines[len(lines)-1] != "2e+06,0,0,-0.3,0.4,0.5" {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+	if err := data.WriteCSV(&buf, meta, TraceSpec{Parameter: S22}); err == nil {
+		t.Fatalf("expected error for trace of missing parameter")
+	}
+}
+
+// sweepingDriver бесконечно возвращает один и тот же скан с задержкой, имитирующей время сканирования.
+type sweepingDriver struct {
+	delay time.Duration
+}
+
+func (d sweepingDriver) Identify() (string, error)         { return "sweeping", nil }
+func (d sweepingDriver) SetSweep(config SweepConfig) error { return nil }
+func (d sweepingDriver) Close() error                      { return nil }
+
+func (d sweepingDriver) Scan() (VNAData, error) {
+	time.Sleep(d.delay)
+	return VNAData{Frequencies: []float64{1e6}, S11: []complex128{0.5}}, nil
+}
+
+func TestVNA_SubscribeAveraging(t *testing.T) {
+	freq := []float64{1e6}
+	vna := NewVNA(newStubDriver([]VNAData{
+		{Frequencies: freq, S11: []complex128{1}},
+		{Frequencies: freq, S11: []complex128{3}},
+	}))
+	defer vna.Close()
+	averager, err := NewAverager(AveragingConfig{Mode: AveragingRunning, Count: 4})
+	if err != nil {
+		t.Fatalf("NewAverager failed: %v", err)
+	}
+
+	subscription := vna.Subscribe(StreamOptions{Averaging: averager})
+	defer subscription.Close()
+	for _, want := range []complex128{1, 2} {
+		frame := <-subscription.C
+		if frame.Err != nil || frame.Data.S11[0] != want {
+			t.Fatalf("expected averaged frame %v, got %+v", want, frame)
+		}
+	}
+	// Ошибки сканирования доставляются без усреднения.
+	if frame := <-subscription.C; frame.Err == nil {
+		t.Fatalf("expected a scan error frame, got %+v", frame)
+	}
+	if averager.Sweeps() != 2 {
+		t.Fatalf("expected 2 averaged sweeps, got %d", averager.Sweeps())
+	}
+}
+
+func TestVNA_SubscribeFanOut(t *testing.T) {
+	vna := NewVNA(sweepingDriver{delay: time.Millisecond})
+
+	fast := vna.Subscribe(StreamOptions{})
+	decimated := vna.Subscribe(StreamOptions{Decimation: 3})
+	slow := vna.Subscribe(StreamOptions{Buffer: 1})
+	if vna.Subscribers() != 3 {
+		t.Fatalf("expected 3 subscribers, got %d", vna.Subscribers())
+	}
+
+	var last uint64
+	for i := 0; i < 10; i++ {
+		frame := <-fast.C
+		if frame.Err != nil || frame.Metadata.Sequence <= last {
+			t.Fatalf("unexpected frame %+v after sequence %d", frame, last)
+		}
+		last = frame.Metadata.Sequence
+	}
+	for i := 0; i < 3; i++ {
+		frame := <-decimated.C
+		if frame.Err == nil && (frame.Metadata.Sequence-1)%3 != 0 {
+			t.Fatalf("expected every third sweep, got sequence %d", frame.Metadata.Sequence)
+		}
+	}
+	if slow.Dropped() == 0 {
+		t.Fatalf("expected frames to be dropped for slow subscriber")
+	}
+
+	fast.Close()
+	decimated.Close()
+	vna.Close()
+	for range slow.C {
+	}
+	if vna.Subscribers() != 0 {
+		t.Fatalf("expected no subscribers after close, got %d", vna.Subscribers())
+	}
+}
+
+func TestVNA_PublishesEvents(t *testing.T) {
+	bus := NewEventBus()
+	all := bus.Subscribe(EventFilter{Devices: []string{"/dev/test"}}, 0)
+	calibrations := bus.Subscribe(EventFilter{Types: []EventType{EventCalibrationChanged}}, 0)
+	other := bus.Subscribe(EventFilter{Devices: []string{"/dev/other"}}, 0)
+
+	vna := NewVNA(newStubDriver(nil))
+	vna.SetEventBus(bus, "/dev/test")
+	profile := &CalibrationProfile{
+		Name:        "cal",
+		Frequencies: []float64{1e6},
+		ErrorTerms: CalibrationErrorTerms{
+			Directivity:        []complex128{0},
+			SourceMatch:        []complex128{1},
+			ReflectionTracking: []complex128{0},
+		},
+	}
+	if err := vna.LoadCalibration(profile); err != nil {
+		t.Fatalf("LoadCalibration failed: %v", err)
+	}
+	vna.ClearCalibration()
+	vna.ClearCalibration()
+	if _, err := vna.GetData(); err == nil {
+		t.Fatalf("expected scan error from empty stub")
+	}
+	vna.Close()
+
+	expected := []EventType{EventCalibrationChanged, EventCalibrationChanged, EventSweepError, EventDeviceDisconnected}
+	for _, eventType := range expected {
+		event := <-all.C
+		if event.Type != eventType || event.Device != "/dev/test" || event.Time.IsZero() {
+			t.Fatalf("expected %s event, got %+v", eventType, event)
+		}
+	}
+	if len(all.C) != 0 || len(calibrations.C) != 2 || len(other.C) != 0 {
+		t.Fatalf("unexpected queued events: all %d, calibrations %d, other %d", len(all.C), len(calibrations.C), len(other.C))
+	}
+	all.Close()
+	if _, ok := <-all.C; ok {
+		t.Fatalf("expected closed subscription channel")
+	}
+}
+
+// failingDriver - устройство, сканирование которого завершается ошибкой err.
+type failingDriver struct{ err error }
+
+func (d failingDriver) Identify() (string, error)         { return "failing", nil }
+func (d failingDriver) SetSweep(config SweepConfig) error { return nil }
+func (d failingDriver) Scan() (VNAData, error)            { return VNAData{}, d.err }
+func (d failingDriver) Close() error                      { return nil }
+
+func TestVNAPool_RemovesDisconnectedDevice(t *testing.T) {
+	pool := NewVNAPool()
+	events := pool.Events().Subscribe(EventFilter{Types: []EventType{EventDeviceDisconnected}}, 0)
+	timeout, err := pool.Add("/dev/timeout", failingDriver{err: errors.New("v1: недостаточно данных от устройства")})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	gone, err := pool.Add("/dev/gone", failingDriver{err: fmt.Errorf("v1: ошибка чтения строки 1: %w", syscall.EIO)})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if _, err := timeout.GetData(); err == nil {
+		t.Fatalf("expected scan error")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := gone.GetData(); err == nil {
+			t.Fatalf("expected scan error")
+		}
+	}
+	event := <-events.C
+	if event.Device != "/dev/gone" {
+		t.Fatalf("expected disconnect of /dev/gone, got %+v", event)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for slices.Contains(pool.Devices(), "/dev/gone") {
+		if time.Now().After(deadline) {
+			t.Fatalf("disconnected device was not removed from the pool")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !slices.Contains(pool.Devices(), "/dev/timeout") {
+		t.Fatalf("device with a non-I/O error was removed from the pool")
+	}
+	// Закрытие потерявшего связь устройства не публикует событие повторно.
+	if len(events.C) != 0 {
+		t.Fatalf("expected a single disconnect event, got %d more", len(events.C))
+	}
+}
+
+func TestVNA_LastScan(t *testing.T) {
+	freq := []float64{1e6, 2e6}
+	vna := NewVNA(newStubDriver([]VNAData{{Frequencies: freq, S11: []complex128{0.1, 0.2}}}))
+	if _, _, ok := vna.LastScan(); ok {
+		t.Fatalf("expected no last scan before GetData")
+	}
+	data, meta, err := vna.GetDataWithMetadata()
+	if err != nil {
+		t.Fatalf("GetDataWithMetadata failed: %v", err)
+	}
+	last, lastMeta, ok := vna.LastScan()
+	if !ok || lastMeta.Sequence != meta.Sequence || last.S11[1] != data.S11[1] {
+		t.Fatalf("expected last scan #%d, got #%d (ok=%v)", meta.Sequence, lastMeta.Sequence, ok)
+	}
+	last.S11[0] = 0
+	if again, _, _ := vna.LastScan(); again.S11[0] != data.S11[0] {
+		t.Fatalf("LastScan must return a copy")
+	}
+	if _, _, err := vna.GetDataWithMetadata(); err == nil {
+		t.Fatalf("expected scan error from exhausted stub")
+	}
+	if _, again, _ := vna.LastScan(); again.Sequence != meta.Sequence {
+		t.Fatalf("failed scan must not replace the last scan")
+	}
+}
+
+// gridDriver возвращает сетку частот текущих параметров сканирования и считает вызовы SetSweep.
+type gridDriver struct {
+	mu     sync.Mutex
+	sweep  SweepConfig
+	sweeps int
+}
+
+func (d *gridDriver) Identify() (string, error) { return "grid", nil }
+func (d *gridDriver) Close() error              { return nil }
+
+func (d *gridDriver) SetSweep(config SweepConfig) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sweep = config
+	d.sweeps++
+	return nil
+}
+
+func (d *gridDriver) Scan() (VNAData, error) {
+	d.mu.Lock()
+	sweep := d.sweep
+	d.mu.Unlock()
+	time.Sleep(100 * time.Microsecond)
+	data := VNAData{}
+	for i := 0; i < sweep.Points; i++ {
+		data.Frequencies = append(data.Frequencies, sweep.Start+float64(i)*(sweep.Stop-sweep.Start)/float64(max(sweep.Points-1, 1)))
+		data.S11 = append(data.S11, 0.5)
+	}
+	return data, nil
+}
+
+func TestVNA_Measure(t *testing.T) {
+	driver := &gridDriver{}
+	vna := NewVNA(driver)
+	config := SweepConfig{Start: 1e6, Stop: 10e6, Points: 11}
+
+	for i := 0; i < 3; i++ {
+		data, meta, err := vna.Measure(context.Background(), MeasureRequest{Sweep: config})
+		if err != nil {
+			t.Fatalf("Measure failed: %v", err)
+		}
+		if meta.Sweep != config || len(data.Frequencies) != config.Points {
+			t.Fatalf("expected data for %+v, got %d points for %+v", config, len(data.Frequencies), meta.Sweep)
+		}
+	}
+	if driver.sweeps != 1 {
+		t.Fatalf("expected unchanged sweep to be sent once, sent %d times", driver.sweeps)
+	}
+	if _, _, err := vna.Measure(context.Background(), MeasureRequest{Sweep: SweepConfig{Start: 1e6, Stop: 1e6, Points: 11}}); err == nil {
+		t.Fatalf("expected invalid sweep to be rejected")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := vna.Measure(ctx, MeasureRequest{Sweep: config}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// Параллельные измерения с разными параметрами не должны получать данные чужой сетки.
+	configs := []SweepConfig{
+		{Start: 1e6, Stop: 2e6, Points: 3},
+		{Start: 5e6, Stop: 9e6, Points: 5},
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(configs))
+	for _, config := range configs {
+		wg.Add(1)
+		go func(config SweepConfig) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				data, meta, err := vna.Measure(context.Background(), MeasureRequest{Sweep: config})
+				if err == nil && (meta.Sweep != config || len(data.Frequencies) != config.Points || data.Frequencies[0] != config.Start) {
+					err = fmt.Errorf("requested %+v, got %d points starting at %g for %+v",
+						config, len(data.Frequencies), data.Frequencies[0], meta.Sweep)
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(config)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+// offsetProfile строит профиль на сетке gridDriver, вычитающий из S11 постоянную направленность offset.
+func offsetProfile(name string, sweep SweepConfig, offset complex128) *CalibrationProfile {
+	profile := &CalibrationProfile{Name: name, Sweep: sweep}
+	for i := 0; i < sweep.Points; i++ {
+		profile.Frequencies = append(profile.Frequencies, sweep.Start+float64(i)*(sweep.Stop-sweep.Start)/float64(max(sweep.Points-1, 1)))
+		profile.ErrorTerms.Directivity = append(profile.ErrorTerms.Directivity, offset)
+		profile.ErrorTerms.SourceMatch = append(profile.ErrorTerms.SourceMatch, 1)
+		profile.ErrorTerms.ReflectionTracking = append(profile.ErrorTerms.ReflectionTracking, 0)
+	}
+	return profile
+}
+
+func TestVNA_MeasureConcurrentCalibrations(t *testing.T) {
+	vna := NewVNA(&gridDriver{})
+	sweep := SweepConfig{Start: 1e6, Stop: 10e6, Points: 11}
+	// gridDriver возвращает S11 = 0.5, поэтому каждый клиент должен получить 0.5 - offset.
+	clients := []struct {
+		name string
+		want complex128
+	}{
+		{"a", 0.4},
+		{"b", 0.2},
+		{"", 0.5},
+	}
+	profiles := map[string]*CalibrationProfile{
+		"a": offsetProfile("a", sweep, 0.1),
+		"b": offsetProfile("b", sweep, 0.3),
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(clients))
+	for _, client := range clients {
+		wg.Add(1)
+		go func(name string, want complex128) {
+			defer wg.Done()
+			profile := profiles[name]
+			for i := 0; i < 20; i++ {
+				data, meta, err := vna.Measure(context.Background(), MeasureRequest{Sweep: sweep, Calibration: profile})
+				if err == nil && cmplx.Abs(data.S11[0]-want) > 1e-12 {
+					err = fmt.Errorf("calibration %q: expected S11 %v, got %v (metadata %q)", name, want, data.S11[0], meta.Calibration)
+				}
+				if err == nil && meta.Calibration != name {
+					err = fmt.Errorf("expected metadata calibration %q, got %q", name, meta.Calibration)
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(client.name, client.want)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if vna.Calibration() != nil {
+		t.Fatalf("expected Measure to leave the device calibration unchanged")
+	}
+}
+
+func TestVNA_MeasureProcessing(t *testing.T) {
+	freq := []float64{1e6, 2e6, 3e6}
+	sweep := func(v complex128) VNAData {
+		return VNAData{Frequencies: freq, S11: []complex128{v, v, v}}
+	}
+	vna := NewVNA(newStubDriver([]VNAData{sweep(1), sweep(3), sweep(5), sweep(7)}))
+	config := SweepConfig{Start: 1e6, Stop: 3e6, Points: 3}
+	memory := sweep(2)
+
+	data, meta, err := vna.Measure(context.Background(), MeasureRequest{
+		Sweep: config, Averages: 3, Memory: &memory, MemoryMath: MemoryDivide,
+	})
+	if err != nil {
+		t.Fatalf("Measure failed: %v", err)
+	}
+	if cmplx.Abs(data.S11[1]-1.5) > 1e-12 {
+		t.Fatalf("expected average of three sweeps divided by memory 3/2, got %v", data.S11[1])
+	}
+	if last, lastMeta, ok := vna.LastScan(); !ok || cmplx.Abs(last.S11[1]-3) > 1e-12 || lastMeta.Sequence != meta.Sequence {
+		t.Fatalf("expected last scan to hold the average before memory math, got %v", last.S11)
+	}
+	if vna.AveragedSweeps() != 0 {
+		t.Fatalf("expected Measure to leave device averaging unchanged")
+	}
+	if _, _, err := vna.Measure(context.Background(), MeasureRequest{Sweep: config, MemoryMath: MemoryDivide}); err == nil {
+		t.Fatalf("expected error for memory math without a memory trace")
+	}
+	data, _, err = vna.Measure(context.Background(), MeasureRequest{Sweep: config})
+	if err != nil || data.S11[1] != 7 {
+		t.Fatalf("expected a single raw sweep 7, got %v (%v)", data.S11, err)
+	}
+}
+
+func TestVNA_MeasureAverager(t *testing.T) {
+	freq := []float64{1e6, 2e6, 3e6}
+	var sweeps []VNAData
+	for _, v := range []complex128{1, 3, 5, 7, 9, 11} {
+		sweeps = append(sweeps, VNAData{Frequencies: freq, S11: []complex128{v, v, v}})
+	}
+	vna := NewVNA(newStubDriver(sweeps))
+	averager, err := NewAverager(AveragingConfig{Mode: AveragingRunning, Count: 4})
+	if err != nil {
+		t.Fatalf("NewAverager failed: %v", err)
+	}
+	config := SweepConfig{Start: 1e6, Stop: 3e6, Points: 3}
+	measure := func(sweep SweepConfig) complex128 {
+		t.Helper()
+		data, _, err := vna.Measure(context.Background(), MeasureRequest{Sweep: sweep, Averaging: averager})
+		if err != nil {
+			t.Fatalf("Measure failed: %v", err)
+		}
+		return data.S11[0]
+	}
+
+	// Среднее продолжается между вызовами Measure.
+	if got := measure(config); got != 1 {
+		t.Fatalf("expected the first sweep 1, got %v", got)
+	}
+	if got := measure(config); got != 2 || averager.Sweeps() != 2 {
+		t.Fatalf("expected the average of two sweeps 2, got %v over %d sweeps", got, averager.Sweeps())
+	}
+	// Смена сетки частот начинает среднее заново.
+	if got := measure(SweepConfig{Start: 1e6, Stop: 4e6, Points: 3}); got != 5 {
+		t.Fatalf("expected the average to restart after a sweep change, got %v", got)
+	}
+	if got := measure(SweepConfig{Start: 1e6, Stop: 4e6, Points: 3}); got != 6 {
+		t.Fatalf("expected the average of two sweeps 6, got %v", got)
+	}
+	// Смена коррекции данных тоже сбрасывает среднее.
+	if err := vna.SetPortExtension(PortExtensionSettings{}); err != nil {
+		t.Fatalf("SetPortExtension failed: %v", err)
+	}
+	if got := measure(SweepConfig{Start: 1e6, Stop: 4e6, Points: 3}); got != 9 || averager.Sweeps() != 1 {
+		t.Fatalf("expected the average to restart after a correction change, got %v over %d sweeps", got, averager.Sweeps())
+	}
+	averager.Reset()
+	if got := measure(SweepConfig{Start: 1e6, Stop: 4e6, Points: 3}); got != 11 {
+		t.Fatalf("expected the average to restart after Reset, got %v", got)
+	}
+}
+
+func TestScheduler_OrderAndLimits(t *testing.T) {
+	scheduler := NewScheduler(SchedulerOptions{MaxQueue: 5, MaxPerClient: 3})
+
+	// Занимаем устройство, чтобы остальные задания встали в очередь.
+	running := make(chan struct{})
+	unblock := make(chan struct{})
+	go scheduler.Do(context.Background(), "x", PriorityNormal, func() {
+		close(running)
+		<-unblock
+	})
+	<-running
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	submit := func(name, client string, priority JobPriority) {
+		queued := scheduler.Len()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := scheduler.Do(context.Background(), client, priority, func() {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+			}); err != nil {
+				t.Errorf("job %s failed: %v", name, err)
+			}
+		}()
+		for scheduler.Len() == queued {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	submit("a1", "a", PriorityNormal)
+	submit("a2", "a", PriorityNormal)
+	submit("a3", "a", PriorityNormal)
+	submit("b1", "b", PriorityNormal)
+	if _, err := scheduler.Do(context.Background(), "a", PriorityNormal, func() {}); !errors.Is(err, ErrClientQueueFull) {
+		t.Fatalf("expected ErrClientQueueFull, got %v", err)
+	}
+	submit("c1", "c", PriorityHigh)
+	if _, err := scheduler.Do(context.Background(), "d", PriorityHigh, func() {}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	close(unblock)
+	wg.Wait()
+	expected := []string{"c1", "a1", "b1", "a2", "a3"}
+	if fmt.Sprint(order) != fmt.Sprint(expected) {
+		t.Fatalf("expected order %v, got %v", expected, order)
+	}
+
+	timed := NewScheduler(SchedulerOptions{MaxWait: 5 * time.Millisecond})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go timed.Do(context.Background(), "x", PriorityNormal, func() {
+		close(started)
+		<-release
+	})
+	<-started
+	if _, err := timed.Do(context.Background(), "y", PriorityNormal, func() {}); !errors.Is(err, ErrQueueTimeout) {
+		t.Fatalf("expected ErrQueueTimeout, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := timed.Do(ctx, "z", PriorityNormal, func() { t.Errorf("cancelled job must not run") }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if timed.Len() != 0 {
+		t.Fatalf("cancelled job must leave the queue, queued %d", timed.Len())
+	}
+	close(release)
+}
+
+func TestVNA_StreamScheduler(t *testing.T) {
+	driver := &gridDriver{}
+	vna := NewVNA(driver)
+	defer vna.Close()
+	streamSweep := SweepConfig{Start: 1e6, Stop: 10e6, Points: 11}
+	if err := vna.SetSweep(streamSweep); err != nil {
+		t.Fatalf("SetSweep failed: %v", err)
+	}
+	scheduler := NewScheduler(SchedulerOptions{})
+	var scheduled atomic.Int64
+	vna.SetStreamScheduler(func(ctx context.Context, scan func()) error {
+		_, err := scheduler.Do(ctx, "stream", PriorityLow, func() {
+			scheduled.Add(1)
+			scan()
+		})
+		return err
+	})
+
+	subscription := vna.Subscribe(StreamOptions{Buffer: 1})
+	defer subscription.Close()
+	if frame := <-subscription.C; frame.Err != nil || len(frame.Data.Frequencies) != streamSweep.Points {
+		t.Fatalf("unexpected first frame %+v", frame)
+	}
+
+	// Пока устройство занято заданием очереди, поток не сканирует.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go scheduler.Do(context.Background(), "client", PriorityNormal, func() {
+		close(started)
+		<-release
+	})
+	<-started
+	for len(subscription.C) > 0 {
+		<-subscription.C
+	}
+	before := scheduled.Load()
+	time.Sleep(20 * time.Millisecond)
+	if scheduled.Load() != before {
+		t.Fatalf("stream scanned while the device was busy: %d scans", scheduled.Load()-before)
+	}
+	close(release)
+
+	// Measure с другой сеткой выполняется в очереди между кадрами и не меняет сетку потока.
+	other := SweepConfig{Start: 20e6, Stop: 30e6, Points: 5}
+	var data VNAData
+	var err error
+	if _, err := scheduler.Do(context.Background(), "client", PriorityNormal, func() {
+		data, _, err = vna.Measure(context.Background(), MeasureRequest{Sweep: other})
+	}); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if err != nil || len(data.Frequencies) != other.Points {
+		t.Fatalf("Measure: unexpected result %d points, %v", len(data.Frequencies), err)
+	}
+	for i := 0; i < 5; i++ {
+		frame := <-subscription.C
+		if frame.Err != nil || frame.Metadata.Sweep != streamSweep || len(frame.Data.Frequencies) != streamSweep.Points {
+			t.Fatalf("frame %d: expected stream grid %+v, got %+v with %d points", i, streamSweep, frame.Metadata.Sweep, len(frame.Data.Frequencies))
+		}
+	}
+	if scheduled.Load() == 0 {
+		t.Fatalf("expected stream scans to run through the scheduler")
+	}
+}
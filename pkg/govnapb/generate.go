@@ -0,0 +1,4 @@
+// Package govnapb содержит описание gRPC-сервиса управления VNA и сгенерированные клиентские и серверные заглушки.
+package govnapb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative govna.proto